@@ -2,22 +2,41 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+
+	grpcHandler "github.com/hickar/crtex_test_assignment/account/internal/controllers/grpc"
 	"github.com/hickar/crtex_test_assignment/account/internal/controllers/kafka"
 
 	"github.com/hickar/crtex_test_assignment/account/internal/config"
 	"github.com/hickar/crtex_test_assignment/account/internal/domain"
 	"github.com/hickar/crtex_test_assignment/account/internal/repository"
+	"github.com/hickar/crtex_test_assignment/account/proto"
+	"github.com/hickar/crtex_test_assignment/events"
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
+	"github.com/hickar/crtex_test_assignment/pkg/interceptors"
 	kconsumer "github.com/hickar/crtex_test_assignment/pkg/kafka/consumer"
 	"github.com/hickar/crtex_test_assignment/pkg/postgres"
+	"github.com/hickar/crtex_test_assignment/pkg/requestid"
+	"github.com/hickar/crtex_test_assignment/pkg/schemaregistry"
+	"github.com/hickar/crtex_test_assignment/pkg/tlsconfig"
 )
 
 var configPath = flag.String("config", "./config.yaml", "Path to configuration file. Defaults to './config.yaml'")
@@ -32,9 +51,31 @@ func main() {
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGABRT)
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	// В режимах replay, reset-offsets и dlq в stdout выводятся результаты команд.
+	logOutput := os.Stdout
+	switch flag.Arg(0) {
+	case kconsumer.ReplayCommand, kconsumer.ResetOffsetsCommand, kconsumer.DeadLetterCommand:
+		logOutput = os.Stderr
+	}
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(logOutput, &slog.HandlerOptions{
 		Level: cfg.Logger.Level,
-	}))
+	})))
+
+	kafkaCfg, err := kafkaConsumerConfiguration(cfg.Kafka, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to initialize kafka consumer: %s", err))
+		os.Exit(1)
+	}
+
+	if flag.Arg(0) == kconsumer.ResetOffsetsCommand {
+		err = kconsumer.RunResetOffsetsCommand(ctx, flag.Args()[1:], kafkaCfg, os.Stdout)
+		cancel()
+		if err != nil {
+			logger.Error(fmt.Sprintf("offsets reset failed: %s", err))
+			os.Exit(1)
+		}
+		return
+	}
 
 	repo, err := initAccountRepo(ctx, cfg.DB)
 	if err != nil {
@@ -43,13 +84,101 @@ func main() {
 	}
 	service := domain.NewAccountService(repo)
 
-	kafkaConsumer, err := initKafkaConsumer(cfg.Kafka, service, logger)
+	codec, err := initEventCodec(cfg.SchemaRegistry)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to initialize event codec: %s", err))
+		os.Exit(1)
+	}
+
+	// Утилиты повторной обработки вызывают обработчики напрямую: ошибки выводятся
+	// пользователю, а не отправляются в DLQ, и не размыкают предохранитель.
+	toolRouter := initKafkaRouter(cfg.Kafka, service, codec, nil, nil, logger)
+
+	if flag.Arg(0) == kconsumer.ReplayCommand {
+		err = kconsumer.RunReplayCommand(ctx, flag.Args()[1:], kafkaCfg, toolRouter, os.Stdout)
+		cancel()
+		if err != nil {
+			logger.Error(fmt.Sprintf("replay failed: %s", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == kconsumer.DeadLetterCommand {
+		decode := kconsumer.DeadLetterDecoderFor(codec.DecodeOrderCreatedEvent)
+		err = kconsumer.RunDeadLetterCommand(ctx, flag.Args()[1:], kafkaCfg, cfg.Kafka.DLQTopic, toolRouter, decode, os.Stdout)
+		cancel()
+		if err != nil {
+			logger.Error(fmt.Sprintf("dead letter command failed: %s", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	var dlqWriter kconsumer.MessageWriter
+	if cfg.Kafka.DLQTopic != "" {
+		writer := kconsumer.NewKafkaWriter(kafkaCfg)
+		defer writer.Close()
+		dlqWriter = writer
+	}
+	var breaker *kconsumer.CircuitBreaker
+	if cfg.Kafka.CircuitBreaker.Enabled {
+		breaker = kconsumer.NewCircuitBreaker(kconsumer.CircuitBreakerConfiguration{
+			FailureThreshold: cfg.Kafka.CircuitBreaker.FailureThreshold,
+			IsFailure:        postgres.IsUnavailable,
+			Probe:            repo.Ping,
+			ProbeInterval:    cfg.Kafka.CircuitBreaker.ProbeInterval,
+			RetryBackoff:     cfg.Kafka.CircuitBreaker.RetryBackoff,
+			Logger:           logger.With(slog.String("module", "circuit_breaker")),
+		})
+	}
+	kafkaRouter := initKafkaRouter(cfg.Kafka, service, codec, dlqWriter, breaker, logger)
+
+	// Настройка сервера GRPC
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCServer.Port))
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to open tcp connection on port %d: %s", cfg.GRPCServer.Port, err))
+		os.Exit(1)
+	}
+	grpcServer, err := initGRPCServer(ctx, cfg.GRPCServer, cfg.Auth, service, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to initialize grpc server: %s", err))
+		os.Exit(1)
+	}
+
+	kafkaCfg.Metrics, err = kconsumer.NewMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to register kafka consumer metrics: %s", err))
+		os.Exit(1)
+	}
+	kafkaConsumer, err := kconsumer.NewConsumer(kafkaCfg, kafkaRouter)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to initialize kafka consumer: %s", err))
 		os.Exit(1)
 	}
+	if breaker != nil {
+		breaker.Attach(kafkaConsumer)
+	}
 
 	errCh := make(chan error)
+	go func() {
+		logger.Info(fmt.Sprintf("launching server on port %d", cfg.GRPCServer.Port))
+		if cerr := grpcServer.Serve(ln); cerr != nil {
+			errCh <- cerr
+		}
+	}()
+
+	var adminServer *http.Server
+	if cfg.Admin.Port != 0 {
+		adminServer = initAdminServer(cfg.Admin, kafkaCfg)
+		go func() {
+			logger.Info(fmt.Sprintf("launching admin server on port %d", cfg.Admin.Port))
+			if cerr := adminServer.ListenAndServe(); cerr != nil && !errors.Is(cerr, http.ErrServerClosed) {
+				errCh <- cerr
+			}
+		}()
+	}
+
 	go func() {
 		logger.Info("launching kafka consumer")
 		if cerr := kafkaConsumer.Run(ctx); cerr != nil {
@@ -63,14 +192,97 @@ func main() {
 		stopErr = ctx.Err()
 	case stopErr = <-errCh:
 	}
-	if stopErr != nil && !errors.Is(err, context.Canceled) {
+	if stopErr != nil && !errors.Is(stopErr, context.Canceled) {
 		logger.Error(fmt.Sprintf("application stopped with error: %s", stopErr))
 		cancel()
 		os.Exit(1)
 	}
 
 	logger.Info("gracefully shutting down server")
+
+	if adminServer != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Admin.ShutdownTimeout)
+		if err = adminServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error(fmt.Sprintf("failed to shutdown admin server: %s", err))
+		}
+		shutdownCancel()
+	}
+
 	cancel()
+	grpcServer.GracefulStop()
+}
+
+func initGRPCServer(
+	ctx context.Context,
+	cfg config.GRPCConfiguration,
+	authCfg config.AuthConfiguration,
+	accountService domain.Service,
+	logger *slog.Logger,
+) (*grpc.Server, error) {
+	metrics, err := interceptors.NewMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register grpc metrics: %w", err)
+	}
+
+	chainCfg := interceptors.ChainConfiguration{
+		Logger:      logger.With(slog.String("module", "grpc_server")),
+		ErrorDomain: domain.ErrorDomain,
+		Metrics:     metrics,
+	}
+
+	if authCfg.Enabled {
+		chainCfg.Verifier, err = auth.NewVerifier(ctx, auth.Configuration{
+			HMACSecret:          authCfg.HMACSecret,
+			JWKSFile:            authCfg.JWKSFile,
+			JWKSURL:             authCfg.JWKSURL,
+			JWKSRefreshInterval: authCfg.JWKSRefreshInterval,
+			Issuer:              authCfg.Issuer,
+			Audience:            authCfg.Audience,
+			AdminScope:          authCfg.AdminScope,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize token verifier: %w", err)
+		}
+	} else {
+		// Методы API обращаются к счёту вызывающего или требуют прав администратора.
+		logger.Warn("auth is disabled, account grpc methods will reject calls as unauthenticated")
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle: cfg.MaxIdleConnLifetime,
+			MaxConnectionAge:  cfg.MaxConnectionAge,
+			Timeout:           cfg.Timeout,
+		}),
+	}
+	opts = append(opts, interceptors.ServerOptions(chainCfg)...)
+
+	if cfg.TLS.Enabled {
+		tlsCfg, err := tlsconfig.NewServerConfig(tlsConfiguration(cfg.TLS))
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize grpc tls: %w", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	grpcServer := grpc.NewServer(opts...)
+	proto.RegisterAccountServer(grpcServer, grpcHandler.NewAccountHandler(accountService))
+
+	return grpcServer, nil
+}
+
+// initAdminServer создаёт служебный HTTP-сервер с метриками Prometheus
+// и состоянием группы консьюмеров.
+func initAdminServer(cfg config.AdminConfiguration, kafkaCfg kconsumer.Configuration) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/kafka/group", kconsumer.GroupStateHandler(kconsumer.NewAdmin(kafkaCfg), kafkaCfg))
+
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           mux,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+	}
 }
 
 func initAccountRepo(ctx context.Context, cfg config.DatabaseConfiguration) (*repository.AccountRepository, error) {
@@ -80,6 +292,10 @@ func initAccountRepo(ctx context.Context, cfg config.DatabaseConfiguration) (*re
 		User:                    cfg.User,
 		Password:                cfg.Password,
 		Name:                    cfg.Name,
+		SSLMode:                 cfg.SSLMode,
+		SSLRootCert:             cfg.SSLRootCert,
+		SSLCert:                 cfg.SSLCert,
+		SSLKey:                  cfg.SSLKey,
 		MaxOpenConns:            cfg.MaxConns,
 		MaxConnLifetime:         cfg.MaxConnLifetime,
 		MaxIdleConnLifetime:     cfg.MaxIdleConnLifetime,
@@ -93,32 +309,124 @@ func initAccountRepo(ctx context.Context, cfg config.DatabaseConfiguration) (*re
 	return repository.NewAccountRepository(pgdb), nil
 }
 
-func initKafkaConsumer(
+func initEventCodec(cfg config.SchemaRegistryConfiguration) (*events.Codec, error) {
+	var registry *schemaregistry.Client
+	if cfg.URL != "" {
+		registry = schemaregistry.NewClient(schemaregistry.Configuration{
+			URL:      cfg.URL,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			Timeout:  cfg.Timeout,
+		})
+	}
+
+	return events.NewCodec(registry, schemaregistry.SchemaType(cfg.SchemaType))
+}
+
+func initKafkaRouter(
 	cfg config.KafkaConsumerConfiguration,
 	service domain.Service,
+	codec *events.Codec,
+	dlqWriter kconsumer.MessageWriter,
+	breaker *kconsumer.CircuitBreaker,
 	logger *slog.Logger,
-) (*kconsumer.Consumer, error) {
-	handler := kafka.NewAccountHandler(service)
+) *kconsumer.TopicRouter {
+	handler := kafka.NewAccountHandler(service, codec)
 	router := kconsumer.NewTopicRouter()
-	router.Handle(
-		cfg.Topic,
-		handler.NewOrderEvent,
-		kconsumer.LoggerMiddleware(logger.With(
-			slog.String("module", "kafka_router"),
-		)),
+	routerLogger := logger.With(slog.String("module", "kafka_router"))
+	// Middleware, зарегистрированный последним, выполняется первым.
+	router.Use(kconsumer.RecoveryMiddleware(routerLogger))
+	// В пакетном режиме предохранитель оборачивает пакетный обработчик.
+	if breaker != nil && cfg.BatchSize <= 1 {
+		// Регистрируется до DLQ, чтобы сообщения не попадали туда во время сбоя БД.
+		router.Use(breaker.Middleware())
+	}
+	if dlqWriter != nil {
+		// Паники, перехваченные RecoveryMiddleware, тоже попадают в DLQ.
+		router.Use(kconsumer.DeadLetterMiddleware(dlqWriter, cfg.DLQTopic))
+	}
+	router.Use(
+		kconsumer.LoggerMiddleware(routerLogger),
+		kconsumer.RequestIDMiddleware(),
 	)
+	if cfg.BatchSize > 1 {
+		var batchHandler kconsumer.BatchRouteHandler = handler.NewOrderEvents
+		if breaker != nil {
+			batchHandler = breaker.WrapBatch(batchHandler)
+		}
+		router.HandleBatch(cfg.Topic, batchHandler)
+	} else {
+		router.Handle(cfg.Topic, handler.NewOrderEvent)
+	}
+	if cfg.UserTopic != "" {
+		router.Handle(cfg.UserTopic, handler.UserEvent)
+	}
 
-	return kconsumer.NewConsumer(
-		kconsumer.Configuration{
-			BrokerURLs:        cfg.BrokerURLs,
-			GroupID:           cfg.GroupID,
-			GroupTopics:       cfg.GroupTopics,
-			Topic:             cfg.Topic,
-			SessionTimeout:    cfg.SessionTimeout,
-			HeartbeatInterval: cfg.HeartbeatInterval,
-			WorkerCount:       cfg.WorkerCount,
-			HandlerTimeout:    cfg.HandlerTimeout,
-		},
-		router,
-	)
+	return router
+}
+
+func kafkaConsumerConfiguration(
+	cfg config.KafkaConsumerConfiguration,
+	logger *slog.Logger,
+) (kconsumer.Configuration, error) {
+	var tlsCfg *tls.Config
+	if cfg.TLS.Enabled {
+		var err error
+		tlsCfg, err = tlsconfig.NewClientConfig(tlsConfiguration(cfg.TLS))
+		if err != nil {
+			return kconsumer.Configuration{}, fmt.Errorf("failed to initialize kafka tls: %w", err)
+		}
+	}
+
+	saslMechanism, err := kconsumer.NewSASLMechanism(cfg.SASL.Mechanism, cfg.SASL.Username, cfg.SASL.Password)
+	if err != nil {
+		return kconsumer.Configuration{}, err
+	}
+
+	var startTime time.Time
+	if cfg.StartTime != "" {
+		if startTime, err = time.Parse(time.RFC3339, cfg.StartTime); err != nil {
+			return kconsumer.Configuration{}, fmt.Errorf("invalid kafka start time: %w", err)
+		}
+	}
+
+	// Консьюмер читает либо один топик, либо несколько топиков в группе.
+	topic, groupTopics := cfg.Topic, cfg.GroupTopics
+	if cfg.UserTopic != "" {
+		if len(groupTopics) == 0 {
+			groupTopics = []string{topic}
+		}
+		groupTopics = append(slices.Clone(groupTopics), cfg.UserTopic)
+		topic = ""
+	}
+
+	return kconsumer.Configuration{
+		BrokerURLs:        cfg.BrokerURLs,
+		GroupID:           cfg.GroupID,
+		GroupTopics:       groupTopics,
+		Topic:             topic,
+		SessionTimeout:    cfg.SessionTimeout,
+		HeartbeatInterval: cfg.HeartbeatInterval,
+		WorkerCount:       cfg.WorkerCount,
+		HandlerTimeout:    cfg.HandlerTimeout,
+		BatchSize:         cfg.BatchSize,
+		BatchTimeout:      cfg.BatchTimeout,
+		StatsInterval:     cfg.StatsInterval,
+		StartOffset:       kconsumer.StartOffset(cfg.StartOffset),
+		StartTime:         startTime,
+		TLS:               tlsCfg,
+		SASL:              saslMechanism,
+		Logger:            logger.With(slog.String("module", "kafka_consumer")),
+	}, nil
+}
+
+func tlsConfiguration(cfg config.TLSConfiguration) tlsconfig.Configuration {
+	return tlsconfig.Configuration{
+		CertFile:           cfg.CertFile,
+		KeyFile:            cfg.KeyFile,
+		CAFile:             cfg.CAFile,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		ReloadInterval:     cfg.ReloadInterval,
+	}
 }
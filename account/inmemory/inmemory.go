@@ -0,0 +1,41 @@
+// Package inmemory собирает сервис счетов поверх in-memory хранилища, чтобы
+// его можно было запускать в одном процессе с другими сервисами.
+package inmemory
+
+import (
+	"context"
+
+	"github.com/hickar/crtex_test_assignment/account/internal/controllers/kafka"
+	"github.com/hickar/crtex_test_assignment/account/internal/domain"
+	"github.com/hickar/crtex_test_assignment/account/internal/repository"
+	"github.com/hickar/crtex_test_assignment/events"
+	kconsumer "github.com/hickar/crtex_test_assignment/pkg/kafka/consumer"
+)
+
+type AccountService struct {
+	repo    *repository.MemoryAccountRepository
+	service *domain.AccountService
+}
+
+func NewAccountService() *AccountService {
+	repo := repository.NewMemoryAccountRepository()
+
+	return &AccountService{
+		repo:    repo,
+		service: domain.NewAccountService(repo),
+	}
+}
+
+func (s *AccountService) CreateAccount(ctx context.Context, userID, amountCents int64) error {
+	_, err := s.service.OpenAccount(ctx, userID, amountCents)
+	return err
+}
+
+// HandleOrderEvents регистрирует обработчик событий сервиса заказов.
+func (s *AccountService) HandleOrderEvents(router *kconsumer.TopicRouter, topic string, codec *events.Codec) {
+	router.Handle(topic, kafka.NewAccountHandler(s.service, codec).NewOrderEvent)
+}
+
+func (s *AccountService) OutboxEvents(afterID int64) []events.AccountOrderPaymentEvent {
+	return s.repo.OutboxEvents(afterID)
+}
@@ -0,0 +1,249 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/hickar/crtex_test_assignment/account/internal/domain"
+	"github.com/hickar/crtex_test_assignment/account/proto"
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
+)
+
+type GRPCAccountHandler struct {
+	proto.UnimplementedAccountServer
+	service domain.Service
+}
+
+func NewAccountHandler(service domain.Service) *GRPCAccountHandler {
+	return &GRPCAccountHandler{service: service}
+}
+
+func (h *GRPCAccountHandler) ListTransactions(ctx context.Context, req *proto.ListTransactionsRequest) (*proto.ListTransactionsResponse, error) {
+	userID, err := authorizedUserID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := h.service.ListTransactions(ctx, userID, domain.TransactionQuery{
+		From:      timeFromProto(req.GetFrom()),
+		To:        timeFromProto(req.GetTo()),
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &proto.ListTransactionsResponse{
+		Transactions:  make([]*proto.Transaction, 0, len(page.Transactions)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, transaction := range page.Transactions {
+		t, err := transactionToProto(transaction)
+		if err != nil {
+			return nil, err
+		}
+		resp.Transactions = append(resp.Transactions, t)
+	}
+
+	return resp, nil
+}
+
+func (h *GRPCAccountHandler) GetStatement(ctx context.Context, req *proto.GetStatementRequest) (*proto.GetStatementResponse, error) {
+	userID, err := authorizedUserID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	statement, err := h.service.GetStatement(ctx, userID, timeFromProto(req.GetFrom()), timeFromProto(req.GetTo()))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &proto.GetStatementResponse{
+		AccountId:      statement.AccountID,
+		From:           timestamppb.New(statement.From),
+		To:             timestamppb.New(statement.To),
+		OpeningBalance: statement.OpeningBalanceCents,
+		ClosingBalance: statement.ClosingBalanceCents,
+		TotalCredits:   statement.CreditsCents,
+		TotalDebits:    statement.DebitsCents,
+	}
+
+	if req.GetFormat() == proto.StatementFormat_STATEMENT_FORMAT_CSV {
+		var buf bytes.Buffer
+		if err = statement.WriteCSV(&buf); err != nil {
+			return nil, err
+		}
+		resp.Csv = buf.Bytes()
+
+		return resp, nil
+	}
+
+	resp.Transactions = make([]*proto.Transaction, 0, len(statement.Transactions))
+	for _, transaction := range statement.Transactions {
+		t, err := transactionToProto(transaction)
+		if err != nil {
+			return nil, err
+		}
+		resp.Transactions = append(resp.Transactions, t)
+	}
+
+	return resp, nil
+}
+
+func (h *GRPCAccountHandler) GetSpendingPolicy(ctx context.Context, req *proto.GetSpendingPolicyRequest) (*proto.SpendingPolicy, error) {
+	userID, err := authorizedUserID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := h.service.GetSpendingPolicy(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return spendingPolicyToProto(policy), nil
+}
+
+func (h *GRPCAccountHandler) UpdateSpendingPolicy(ctx context.Context, req *proto.UpdateSpendingPolicyRequest) (*proto.SpendingPolicy, error) {
+	// Пользователи не могут сами менять ограничения своих счетов.
+	if _, err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	policy := domain.SpendingPolicy{
+		OverdraftLimitCents: req.GetPolicy().GetOverdraftLimit(),
+		MaxOrderAmountCents: req.GetPolicy().GetMaxOrderAmount(),
+		DailyLimitCents:     req.GetPolicy().GetDailyLimit(),
+		MonthlyLimitCents:   req.GetPolicy().GetMonthlyLimit(),
+	}
+	if err := h.service.UpdateSpendingPolicy(ctx, req.GetUserId(), policy); err != nil {
+		return nil, err
+	}
+
+	return spendingPolicyToProto(policy), nil
+}
+
+func (h *GRPCAccountHandler) UpdateAccountStatus(ctx context.Context, req *proto.UpdateAccountStatusRequest) (*proto.UpdateAccountStatusResponse, error) {
+	admin, err := requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Незаданный и неизвестный статусы отклоняются сервисом.
+	status := domain.AccountStatus(req.GetStatus().String())
+	actor := "user:" + strconv.FormatInt(admin.UserID, 10)
+	account, err := h.service.ChangeAccountStatus(ctx, req.GetUserId(), status, actor, req.GetReason())
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.UpdateAccountStatusResponse{Status: accountStatusToProto(account.Status)}, nil
+}
+
+func (h *GRPCAccountHandler) ListAccountStatusChanges(
+	ctx context.Context,
+	req *proto.ListAccountStatusChangesRequest,
+) (*proto.ListAccountStatusChangesResponse, error) {
+	if _, err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	changes, err := h.service.ListStatusChanges(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &proto.ListAccountStatusChangesResponse{
+		Changes: make([]*proto.AccountStatusChange, 0, len(changes)),
+	}
+	for _, change := range changes {
+		resp.Changes = append(resp.Changes, &proto.AccountStatusChange{
+			FromStatus: accountStatusToProto(change.FromStatus),
+			ToStatus:   accountStatusToProto(change.ToStatus),
+			Actor:      change.Actor,
+			Reason:     change.Reason,
+			CreatedAt:  timestamppb.New(change.CreatedAt),
+		})
+	}
+
+	return resp, nil
+}
+
+// requireAdmin разрешает вызов только администраторам. Без аутентификации
+// администратора определить нельзя, поэтому вызов отклоняется как
+// неаутентифицированный.
+func requireAdmin(ctx context.Context) (auth.Identity, error) {
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		return auth.Identity{}, domain.ErrUnauthenticated
+	}
+	if !identity.Admin {
+		return auth.Identity{}, domain.ErrPermissionDenied
+	}
+
+	return identity, nil
+}
+
+// authorizedUserID возвращает пользователя, к счёту которого обращается
+// вызывающий. Без user_id это сам вызывающий; к чужим счетам имеет доступ
+// только администратор. Без аутентификации доступ к счетам запрещён.
+func authorizedUserID(ctx context.Context, userID int64) (int64, error) {
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		return 0, domain.ErrUnauthenticated
+	}
+
+	if userID == 0 {
+		userID = identity.UserID
+	}
+	if !identity.Admin && userID != identity.UserID {
+		return 0, domain.ErrPermissionDenied
+	}
+
+	return userID, nil
+}
+
+func transactionToProto(transaction domain.Transaction) (*proto.Transaction, error) {
+	typeNum, ok := proto.TransactionType_value[string(transaction.Type)]
+	if !ok {
+		return nil, fmt.Errorf("invalid transaction type %q", transaction.Type)
+	}
+
+	return &proto.Transaction{
+		Id:        transaction.ID,
+		Type:      proto.TransactionType(typeNum),
+		Amount:    transaction.AmountCents,
+		Balance:   transaction.BalanceCents,
+		OrderId:   transaction.OrderID,
+		CreatedAt: timestamppb.New(transaction.CreatedAt),
+	}, nil
+}
+
+func spendingPolicyToProto(policy domain.SpendingPolicy) *proto.SpendingPolicy {
+	return &proto.SpendingPolicy{
+		OverdraftLimit: policy.OverdraftLimitCents,
+		MaxOrderAmount: policy.MaxOrderAmountCents,
+		DailyLimit:     policy.DailyLimitCents,
+		MonthlyLimit:   policy.MonthlyLimitCents,
+	}
+}
+
+func accountStatusToProto(status domain.AccountStatus) proto.AccountStatus {
+	return proto.AccountStatus(proto.AccountStatus_value[string(status)])
+}
+
+// timeFromProto возвращает нулевое время для незаданной отметки.
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
@@ -0,0 +1,247 @@
+//go:build unit_test
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/hickar/crtex_test_assignment/account/internal/domain"
+	"github.com/hickar/crtex_test_assignment/account/internal/repository"
+	"github.com/hickar/crtex_test_assignment/account/proto"
+	"github.com/hickar/crtex_test_assignment/events"
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
+)
+
+// newTestService создаёт счёт пользователя 5 с балансом 1000.
+func newTestService(t *testing.T) *domain.AccountService {
+	t.Helper()
+
+	service := domain.NewAccountService(repository.NewMemoryAccountRepository())
+	_, err := service.OpenAccount(context.Background(), 5, 1000)
+	require.NoError(t, err)
+
+	return service
+}
+
+// payOrder списывает со счёта пользователя 5 оплату заказа orderID.
+func payOrder(t *testing.T, service *domain.AccountService, orderID, amount int64) {
+	t.Helper()
+
+	require.NoError(t, service.ProcessNewOrder(context.Background(), events.OrderCreatedEvent{
+		ID:          orderID,
+		OrderID:     orderID,
+		UserID:      5,
+		AmountCents: amount,
+	}))
+}
+
+func TestListTransactionsPagination(t *testing.T) {
+	service := newTestService(t)
+	payOrder(t, service, 1, 100)
+	payOrder(t, service, 2, 200)
+	payOrder(t, service, 3, 300)
+	handler := NewAccountHandler(service)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: 5})
+
+	var (
+		ids      []int64
+		balances []int64
+		token    string
+	)
+	for {
+		resp, err := handler.ListTransactions(ctx, &proto.ListTransactionsRequest{
+			UserId:    5,
+			PageSize:  3,
+			PageToken: token,
+		})
+		require.NoError(t, err)
+
+		for _, transaction := range resp.Transactions {
+			ids = append(ids, transaction.Id)
+			balances = append(balances, transaction.Balance)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		token = resp.NextPageToken
+	}
+
+	assert.Equal(t, []int64{4, 3, 2, 1}, ids)
+	assert.Equal(t, []int64{400, 700, 900, 1000}, balances)
+}
+
+func TestGetStatement(t *testing.T) {
+	service := newTestService(t)
+	payOrder(t, service, 1, 100)
+	handler := NewAccountHandler(service)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: 5})
+
+	// Операции до начала периода учитываются только в начальном балансе.
+	time.Sleep(time.Millisecond)
+	from := time.Now()
+	time.Sleep(time.Millisecond)
+	payOrder(t, service, 2, 250)
+
+	resp, err := handler.GetStatement(ctx, &proto.GetStatementRequest{
+		UserId: 5,
+		From:   timestamppb.New(from),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(900), resp.OpeningBalance)
+	assert.Equal(t, int64(650), resp.ClosingBalance)
+	assert.Zero(t, resp.TotalCredits)
+	assert.Equal(t, int64(250), resp.TotalDebits)
+	require.Len(t, resp.Transactions, 1)
+	assert.Equal(t, proto.TransactionType_PAYMENT, resp.Transactions[0].Type)
+	assert.Equal(t, int64(2), resp.Transactions[0].OrderId)
+	assert.Nil(t, resp.Csv)
+
+	resp, err = handler.GetStatement(ctx, &proto.GetStatementRequest{
+		UserId: 5,
+		Format: proto.StatementFormat_STATEMENT_FORMAT_CSV,
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, resp.Transactions)
+	assert.Contains(t, string(resp.Csv), ",DEPOSIT,,10.00,10.00\n")
+	assert.Contains(t, string(resp.Csv), ",PAYMENT,2,-2.50,6.50\n")
+}
+
+func TestListTransactionsAuthorization(t *testing.T) {
+	handler := NewAccountHandler(newTestService(t))
+
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		userID   int64
+		err      error
+	}{
+		{name: "Unauthenticated_AnyUser", userID: 5, err: domain.ErrUnauthenticated},
+		{name: "Owner_DefaultUserID", identity: &auth.Identity{UserID: 5}},
+		{name: "Admin_OtherUser", identity: &auth.Identity{UserID: 1, Admin: true}, userID: 5},
+		{name: "Forbidden_OtherUser", identity: &auth.Identity{UserID: 1}, userID: 5, err: domain.ErrPermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.identity != nil {
+				ctx = auth.WithIdentity(ctx, *tt.identity)
+			}
+
+			resp, err := handler.ListTransactions(ctx, &proto.ListTransactionsRequest{UserId: tt.userID})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, resp.Transactions, 1)
+		})
+	}
+}
+
+func TestUpdateSpendingPolicy(t *testing.T) {
+	service := newTestService(t)
+	handler := NewAccountHandler(service)
+
+	req := &proto.UpdateSpendingPolicyRequest{
+		UserId: 5,
+		Policy: &proto.SpendingPolicy{OverdraftLimit: 500, DailyLimit: 1200},
+	}
+
+	// Без аутентификации политику не может изменить никто.
+	_, err := handler.UpdateSpendingPolicy(context.Background(), req)
+	require.ErrorIs(t, err, domain.ErrUnauthenticated)
+
+	userCtx := auth.WithIdentity(context.Background(), auth.Identity{UserID: 5})
+	_, err = handler.UpdateSpendingPolicy(userCtx, req)
+	require.ErrorIs(t, err, domain.ErrPermissionDenied)
+
+	adminCtx := auth.WithIdentity(context.Background(), auth.Identity{UserID: 1, Admin: true})
+	_, err = handler.UpdateSpendingPolicy(adminCtx, req)
+	require.NoError(t, err)
+
+	policy, err := handler.GetSpendingPolicy(userCtx, &proto.GetSpendingPolicyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), policy.OverdraftLimit)
+	assert.Equal(t, int64(1200), policy.DailyLimit)
+
+	// Овердрафт позволяет оплатить заказ сверх баланса, но второй заказ
+	// превышает дневной лимит и отменяется.
+	payOrder(t, service, 1, 1100)
+	payOrder(t, service, 2, 200)
+
+	resp, err := handler.ListTransactions(userCtx, &proto.ListTransactionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Transactions, 2)
+	assert.Equal(t, int64(-100), resp.Transactions[0].Balance)
+}
+
+func TestUpdateAccountStatus(t *testing.T) {
+	service := newTestService(t)
+	handler := NewAccountHandler(service)
+
+	userCtx := auth.WithIdentity(context.Background(), auth.Identity{UserID: 5})
+	_, err := handler.UpdateAccountStatus(userCtx, &proto.UpdateAccountStatusRequest{
+		UserId: 5,
+		Status: proto.AccountStatus_ACTIVE,
+		Reason: "self unfreeze",
+	})
+	require.ErrorIs(t, err, domain.ErrPermissionDenied)
+
+	adminCtx := auth.WithIdentity(context.Background(), auth.Identity{UserID: 1, Admin: true})
+	resp, err := handler.UpdateAccountStatus(adminCtx, &proto.UpdateAccountStatusRequest{
+		UserId: 5,
+		Status: proto.AccountStatus_FROZEN,
+		Reason: "suspicious activity",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, proto.AccountStatus_FROZEN, resp.Status)
+
+	// Оплата с заблокированного счёта отменяется без списания.
+	payOrder(t, service, 1, 100)
+	transactions, err := handler.ListTransactions(userCtx, &proto.ListTransactionsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, transactions.Transactions, 1)
+
+	_, err = handler.UpdateAccountStatus(adminCtx, &proto.UpdateAccountStatusRequest{
+		UserId: 5,
+		Status: proto.AccountStatus_ACCOUNT_STATUS_UNSPECIFIED,
+		Reason: "unknown",
+	})
+	require.ErrorIs(t, err, domain.ErrInvalidStatus)
+
+	changes, err := handler.ListAccountStatusChanges(adminCtx, &proto.ListAccountStatusChangesRequest{UserId: 5})
+	require.NoError(t, err)
+	require.Len(t, changes.Changes, 1)
+	change := changes.Changes[0]
+	assert.Equal(t, proto.AccountStatus_ACTIVE, change.FromStatus)
+	assert.Equal(t, proto.AccountStatus_FROZEN, change.ToStatus)
+	assert.Equal(t, "user:1", change.Actor)
+	assert.Equal(t, "suspicious activity", change.Reason)
+
+	_, err = handler.ListAccountStatusChanges(userCtx, &proto.ListAccountStatusChangesRequest{UserId: 5})
+	assert.ErrorIs(t, err, domain.ErrPermissionDenied)
+}
+
+func TestUpdateAccountStatus_Unauthenticated(t *testing.T) {
+	handler := NewAccountHandler(newTestService(t))
+
+	// Без аутентификации администратора определить нельзя.
+	_, err := handler.UpdateAccountStatus(context.Background(), &proto.UpdateAccountStatusRequest{
+		UserId: 5,
+		Status: proto.AccountStatus_FROZEN,
+		Reason: "suspicious activity",
+	})
+	require.ErrorIs(t, err, domain.ErrUnauthenticated)
+
+	_, err = handler.ListAccountStatusChanges(context.Background(), &proto.ListAccountStatusChangesRequest{UserId: 5})
+	require.ErrorIs(t, err, domain.ErrUnauthenticated)
+}
@@ -2,33 +2,80 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 
 	"github.com/hickar/crtex_test_assignment/events"
 
 	"github.com/segmentio/kafka-go"
 
 	"github.com/hickar/crtex_test_assignment/account/internal/domain"
+	kconsumer "github.com/hickar/crtex_test_assignment/pkg/kafka/consumer"
 )
 
 type AccountHandler struct {
 	service domain.Service
+	codec   *events.Codec
 }
 
-func NewAccountHandler(service domain.Service) *AccountHandler {
-	return &AccountHandler{service: service}
+func NewAccountHandler(service domain.Service, codec *events.Codec) *AccountHandler {
+	return &AccountHandler{service: service, codec: codec}
 }
 
 func (h *AccountHandler) NewOrderEvent(ctx context.Context, message *kafka.Message) error {
-	var eventMsg OrderCreatedMessage
-	if err := json.Unmarshal(message.Value, &eventMsg); err != nil {
+	event, err := h.codec.DecodeOrderCreatedEvent(ctx, message)
+	if err != nil {
 		return err
 	}
 
-	err := h.service.ProcessNewOrder(ctx, eventMsg.Payload)
-	return err
+	return ackDuplicate(h.service.ProcessNewOrder(ctx, event))
 }
 
-type OrderCreatedMessage struct {
-	Payload events.OrderCreatedEvent `json:"payload"`
+// NewOrderEvents обрабатывает пачку событий о заказах в одной транзакции.
+// Ошибки отдельных сообщений возвращаются как kconsumer.BatchError.
+func (h *AccountHandler) NewOrderEvents(ctx context.Context, messages []*kafka.Message) error {
+	batchErr := kconsumer.BatchError{}
+
+	orderEvents := make([]events.OrderCreatedEvent, 0, len(messages))
+	indices := make([]int, 0, len(messages))
+	for i, message := range messages {
+		event, err := h.codec.DecodeOrderCreatedEvent(ctx, message)
+		if err != nil {
+			batchErr[i] = err
+			continue
+		}
+
+		orderEvents = append(orderEvents, event)
+		indices = append(indices, i)
+	}
+
+	for j, err := range h.service.ProcessNewOrders(ctx, orderEvents) {
+		if err = ackDuplicate(err); err != nil {
+			batchErr[indices[j]] = err
+		}
+	}
+
+	if len(batchErr) > 0 {
+		return batchErr
+	}
+
+	return nil
+}
+
+// UserEvent открывает и закрывает счета по событиям сервиса пользователей.
+func (h *AccountHandler) UserEvent(ctx context.Context, message *kafka.Message) error {
+	event, err := h.codec.DecodeUserEvent(ctx, message)
+	if err != nil {
+		return err
+	}
+
+	return ackDuplicate(h.service.ProcessUserEvent(ctx, event))
+}
+
+// ackDuplicate считает повторно доставленное событие обработанным.
+func ackDuplicate(err error) error {
+	if errors.Is(err, domain.ErrAlreadyProcessed) {
+		return nil
+	}
+
+	return err
 }
@@ -3,6 +3,8 @@ package domain
 import (
 	"context"
 	"errors"
+	"sort"
+	"time"
 
 	"github.com/hickar/crtex_test_assignment/events"
 )
@@ -11,17 +13,47 @@ type Account struct {
 	ID          int64
 	UserID      int64
 	AmountCents int64
+	Status      AccountStatus
+	Policy      SpendingPolicy
 }
 
 type Service interface {
 	ProcessNewOrder(context.Context, events.OrderCreatedEvent) error
+	ProcessNewOrders(context.Context, []events.OrderCreatedEvent) []error
+	ProcessUserEvent(context.Context, events.UserEvent) error
+	ChangeAccountStatus(ctx context.Context, userID int64, status AccountStatus, actor, reason string) (Account, error)
+	ListStatusChanges(ctx context.Context, userID int64) ([]StatusChange, error)
+	ListTransactions(ctx context.Context, userID int64, query TransactionQuery) (TransactionPage, error)
+	GetStatement(ctx context.Context, userID int64, from, to time.Time) (Statement, error)
+	GetSpendingPolicy(ctx context.Context, userID int64) (SpendingPolicy, error)
+	UpdateSpendingPolicy(ctx context.Context, userID int64, policy SpendingPolicy) error
 }
 
 type AccountRepository interface {
 	AccountEventWithOrderEventIDExists(context.Context, int64) (bool, error)
+	// CreateAccount создаёт счёт пользователя или возвращает ErrAccountExists,
+	// если у пользователя уже есть счёт.
+	CreateAccount(context.Context, Account) (Account, error)
+	// GetAccountByUserID в транзакции блокирует счёт до её завершения.
 	GetAccountByUserID(context.Context, int64) (Account, error)
+	// UpdateAccount обновляет баланс счёта.
 	UpdateAccount(context.Context, Account) error
+	UpdateAccountStatus(ctx context.Context, accountID int64, status AccountStatus) error
+	// CreateStatusChange записывает изменение статуса в журнал. ID и CreatedAt
+	// назначаются хранилищем.
+	CreateStatusChange(context.Context, StatusChange) error
+	ListStatusChanges(ctx context.Context, accountID int64) ([]StatusChange, error)
+	UpdateSpendingPolicy(ctx context.Context, accountID int64, policy SpendingPolicy) error
+	// SpentCents возвращает сумму оплат за вычетом возвратов по счёту начиная с since.
+	SpentCents(ctx context.Context, accountID int64, since time.Time) (int64, error)
 	CreateAccountEvent(context.Context, events.AccountOrderPaymentEvent) error
+	// CreateTransaction записывает операцию со счётом. ID и CreatedAt
+	// назначаются хранилищем.
+	CreateTransaction(context.Context, Transaction) error
+	ListTransactions(context.Context, TransactionFilter) ([]Transaction, error)
+	// WithinTransaction выполняет функцию в транзакции. Вложенный вызов при
+	// ошибке откатывает только свои изменения. При конфликте с параллельной
+	// транзакцией функция может быть выполнена повторно.
 	WithinTransaction(context.Context, func(context.Context) error) error
 }
 
@@ -53,22 +85,41 @@ func (s *AccountService) ProcessNewOrder(ctx context.Context, orderEvent events.
 		account, err := s.repo.GetAccountByUserID(tctx, orderEvent.UserID)
 		if err != nil {
 			if errors.Is(err, ErrNotFound) {
-				return s.cancelAccountPayment(tctx, orderEvent, account)
+				return s.cancelAccountPayment(tctx, orderEvent, account, events.CancelReasonAccountNotFound)
 			}
 
 			return err
 		}
 
-		newAmount := account.AmountCents - orderEvent.AmountCents
-		if newAmount < 0 {
-			return s.cancelAccountPayment(tctx, orderEvent, account)
+		if reason, ok := paymentCancelReasons[account.Status]; ok {
+			return s.cancelAccountPayment(tctx, orderEvent, account, reason)
 		}
 
+		reason, err := s.checkSpendingPolicy(tctx, account, orderEvent.AmountCents)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			return s.cancelAccountPayment(tctx, orderEvent, account, reason)
+		}
+
+		newAmount := account.AmountCents - orderEvent.AmountCents
+
 		account.AmountCents = newAmount
 		if err = s.repo.UpdateAccount(tctx, account); err != nil {
 			return err
 		}
 
+		if err = s.repo.CreateTransaction(tctx, Transaction{
+			AccountID:    account.ID,
+			Type:         TransactionPayment,
+			AmountCents:  -orderEvent.AmountCents,
+			BalanceCents: newAmount,
+			OrderID:      orderEvent.OrderID,
+		}); err != nil {
+			return err
+		}
+
 		if err = s.repo.CreateAccountEvent(tctx, events.AccountOrderPaymentEvent{
 			AccountID:    account.ID,
 			OrderID:      orderEvent.OrderID,
@@ -82,11 +133,58 @@ func (s *AccountService) ProcessNewOrder(ctx context.Context, orderEvent events.
 	})
 }
 
-func (s *AccountService) cancelAccountPayment(ctx context.Context, event events.OrderCreatedEvent, account Account) error {
+// ProcessNewOrders обрабатывает события заказов в одной транзакции и возвращает
+// ошибки обработки по индексам событий. Ошибка обработки события откатывает
+// только его изменения, а ошибка фиксации транзакции относится ко всем событиям.
+//
+// События обрабатываются в порядке идентификаторов пользователей, чтобы
+// параллельные транзакции блокировали счета в одном порядке. События одного
+// пользователя сохраняют исходный порядок. Конфликт с параллельной транзакцией
+// откатывает всю транзакцию, чтобы хранилище могло её повторить.
+func (s *AccountService) ProcessNewOrders(ctx context.Context, orderEvents []events.OrderCreatedEvent) []error {
+	errs := make([]error, len(orderEvents))
+
+	order := make([]int, len(orderEvents))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return orderEvents[order[a]].UserID < orderEvents[order[b]].UserID
+	})
+
+	err := s.repo.WithinTransaction(ctx, func(tctx context.Context) error {
+		clear(errs)
+		for _, i := range order {
+			errs[i] = s.ProcessNewOrder(tctx, orderEvents[i])
+			if errors.Is(errs[i], ErrTransactionConflict) {
+				return errs[i]
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+	}
+
+	return errs
+}
+
+func (s *AccountService) cancelAccountPayment(
+	ctx context.Context,
+	event events.OrderCreatedEvent,
+	account Account,
+	reason events.CancelReason,
+) error {
 	return s.repo.CreateAccountEvent(ctx, events.AccountOrderPaymentEvent{
 		AccountID:    account.ID,
 		OrderID:      event.OrderID,
 		OrderEventID: event.ID,
 		Status:       events.AccountOrderStatusCanceled,
+		Reason:       reason,
 	})
 }
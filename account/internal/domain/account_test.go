@@ -4,9 +4,12 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/hickar/crtex_test_assignment/events"
 )
@@ -144,12 +147,158 @@ func TestProcessNewOrderWithNegativeAmount(t *testing.T) {
 	assert.ErrorIs(t, err, ErrInvalidData)
 }
 
+func TestProcessNewOrders(t *testing.T) {
+	var (
+		paid    []int64
+		commits int
+	)
+	repo := newAccountRepoStub(
+		func(_ context.Context, eventID int64) (bool, error) {
+			return eventID == 2, nil
+		},
+		func(_ context.Context, userID int64) (Account, error) {
+			return Account{ID: userID, UserID: userID, AmountCents: 1000}, nil
+		},
+		nil,
+		func(_ context.Context, event events.AccountOrderPaymentEvent) error {
+			if event.Status == events.AccountOrderStatusPaid {
+				paid = append(paid, event.OrderEventID)
+			}
+			return nil
+		},
+		func(ctx context.Context, txfn func(context.Context) error) error {
+			commits++
+			return txfn(ctx)
+		},
+	)
+
+	errs := NewAccountService(repo).ProcessNewOrders(context.Background(), []events.OrderCreatedEvent{
+		{ID: 1, UserID: 1, AmountCents: 100},
+		{ID: 2, UserID: 1, AmountCents: 100},
+		{ID: 3, UserID: 1, AmountCents: -1},
+		{ID: 4, UserID: 2, AmountCents: 100},
+	})
+
+	require.Len(t, errs, 4)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], ErrAlreadyProcessed)
+	assert.ErrorIs(t, errs[2], ErrInvalidData)
+	assert.NoError(t, errs[3])
+	assert.Equal(t, []int64{1, 4}, paid)
+	assert.Equal(t, 4, commits, "events must be processed in one outer transaction with nested ones")
+}
+
+func TestProcessNewOrdersCommitFailure(t *testing.T) {
+	commitErr := errors.New("commit failed")
+	repo := newAccountRepoStub(
+		func(context.Context, int64) (bool, error) { return false, nil },
+		func(_ context.Context, userID int64) (Account, error) {
+			return Account{UserID: userID, AmountCents: 1000}, nil
+		},
+		nil,
+		nil,
+		nil,
+	)
+	// Фиксация вложенных транзакций успешна, внешней - нет.
+	depth := 0
+	repo.withinTxFn = func(ctx context.Context, txfn func(context.Context) error) error {
+		depth++
+		err := txfn(ctx)
+		depth--
+		if err == nil && depth == 0 {
+			return commitErr
+		}
+		return err
+	}
+
+	errs := NewAccountService(repo).ProcessNewOrders(context.Background(), []events.OrderCreatedEvent{
+		{ID: 1, AmountCents: 100},
+		{ID: 2, AmountCents: -1},
+	})
+
+	assert.ErrorIs(t, errs[0], commitErr)
+	assert.ErrorIs(t, errs[1], ErrInvalidData)
+}
+
+func TestProcessNewOrdersLockOrder(t *testing.T) {
+	var locked []int64
+	repo := newAccountRepoStub(
+		func(context.Context, int64) (bool, error) { return false, nil },
+		func(_ context.Context, userID int64) (Account, error) {
+			locked = append(locked, userID)
+			return Account{ID: userID, UserID: userID, AmountCents: 1000}, nil
+		},
+		nil,
+		nil,
+		nil,
+	)
+
+	errs := NewAccountService(repo).ProcessNewOrders(context.Background(), []events.OrderCreatedEvent{
+		{ID: 1, UserID: 3, AmountCents: 100},
+		{ID: 2, UserID: 1, AmountCents: 100},
+		{ID: 3, UserID: 3, AmountCents: -1},
+		{ID: 4, UserID: 2, AmountCents: 100},
+	})
+
+	// Счета блокируются по возрастанию пользователей, ошибки остаются
+	// на индексах исходных событий.
+	assert.Equal(t, []int64{1, 2, 3}, locked)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[2], ErrInvalidData)
+}
+
+func TestProcessNewOrdersConflict(t *testing.T) {
+	attempts := 0
+	repo := newAccountRepoStub(
+		func(context.Context, int64) (bool, error) { return false, nil },
+		func(_ context.Context, userID int64) (Account, error) {
+			if userID == 2 && attempts == 1 {
+				return Account{}, ErrTransactionConflict
+			}
+			return Account{ID: userID, UserID: userID, AmountCents: 1000}, nil
+		},
+		nil,
+		nil,
+		nil,
+	)
+	// Внешняя транзакция повторяется при конфликте, как в хранилище.
+	depth := 0
+	repo.withinTxFn = func(ctx context.Context, txfn func(context.Context) error) error {
+		depth++
+		defer func() { depth-- }()
+		if depth > 1 {
+			return txfn(ctx)
+		}
+
+		for {
+			attempts++
+			if err := txfn(ctx); !errors.Is(err, ErrTransactionConflict) {
+				return err
+			}
+		}
+	}
+
+	errs := NewAccountService(repo).ProcessNewOrders(context.Background(), []events.OrderCreatedEvent{
+		{ID: 1, UserID: 1, AmountCents: 100},
+		{ID: 2, UserID: 2, AmountCents: 100},
+	})
+
+	assert.Equal(t, 2, attempts, "conflict must abort the outer transaction")
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+}
+
 type accountRepoStub struct {
 	orderEventExistsFn   func(context.Context, int64) (bool, error)
 	getAccountByIDFn     func(context.Context, int64) (Account, error)
 	updateAccountFn      func(context.Context, Account) error
 	createAccountEventFn func(context.Context, events.AccountOrderPaymentEvent) error
 	withinTxFn           func(context.Context, func(context.Context) error) error
+	createTransactionFn  func(context.Context, Transaction) error
+	spentCentsFn         func(context.Context, int64, time.Time) (int64, error)
+	createAccountFn      func(context.Context, Account) (Account, error)
+	updateStatusFn       func(context.Context, int64, AccountStatus) error
+	createStatusChangeFn func(context.Context, StatusChange) error
 }
 
 func newAccountRepoStub(
@@ -199,3 +348,55 @@ func (r *accountRepoStub) WithinTransaction(ctx context.Context, txfn func(conte
 
 	return r.withinTxFn(ctx, txfn)
 }
+
+func (r *accountRepoStub) CreateTransaction(ctx context.Context, transaction Transaction) error {
+	if r.createTransactionFn == nil {
+		return nil
+	}
+
+	return r.createTransactionFn(ctx, transaction)
+}
+
+func (r *accountRepoStub) ListTransactions(context.Context, TransactionFilter) ([]Transaction, error) {
+	return nil, nil
+}
+
+func (r *accountRepoStub) UpdateSpendingPolicy(context.Context, int64, SpendingPolicy) error {
+	return nil
+}
+
+func (r *accountRepoStub) SpentCents(ctx context.Context, accountID int64, since time.Time) (int64, error) {
+	if r.spentCentsFn == nil {
+		return 0, nil
+	}
+
+	return r.spentCentsFn(ctx, accountID, since)
+}
+
+func (r *accountRepoStub) CreateAccount(ctx context.Context, account Account) (Account, error) {
+	if r.createAccountFn == nil {
+		return account, nil
+	}
+
+	return r.createAccountFn(ctx, account)
+}
+
+func (r *accountRepoStub) UpdateAccountStatus(ctx context.Context, accountID int64, status AccountStatus) error {
+	if r.updateStatusFn == nil {
+		return nil
+	}
+
+	return r.updateStatusFn(ctx, accountID, status)
+}
+
+func (r *accountRepoStub) CreateStatusChange(ctx context.Context, change StatusChange) error {
+	if r.createStatusChangeFn == nil {
+		return nil
+	}
+
+	return r.createStatusChangeFn(ctx, change)
+}
+
+func (r *accountRepoStub) ListStatusChanges(context.Context, int64) ([]StatusChange, error) {
+	return nil, nil
+}
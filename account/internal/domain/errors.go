@@ -1,9 +1,78 @@
 package domain
 
-import "errors"
+import "github.com/hickar/crtex_test_assignment/pkg/apperror"
 
-var ErrNotFound = errors.New("queried entity not found")
+// ErrorDomain - домен ошибок сервиса в деталях google.rpc.ErrorInfo.
+const ErrorDomain = "account.crtex"
 
-var ErrAlreadyProcessed = errors.New("incoming event was already processed")
+var ErrNotFound = apperror.New(apperror.CodeNotFound, "ACCOUNT_NOT_FOUND", "queried entity not found")
 
-var ErrInvalidData = errors.New("invalid input data")
+var ErrAlreadyProcessed = apperror.New(
+	apperror.CodeAlreadyExists,
+	"ORDER_EVENT_ALREADY_PROCESSED",
+	"incoming event was already processed",
+)
+
+var ErrInvalidData = apperror.New(apperror.CodeInvalidArgument, "INVALID_ORDER_EVENT", "invalid input data")
+
+var ErrInvalidUserEvent = apperror.New(apperror.CodeInvalidArgument, "INVALID_USER_EVENT", "invalid user event")
+
+var ErrAccountExists = apperror.New(apperror.CodeAlreadyExists, "ACCOUNT_ALREADY_EXISTS", "user already has an account")
+
+var ErrInvalidStatus = apperror.New(apperror.CodeInvalidArgument, "INVALID_ACCOUNT_STATUS", "unknown account status")
+
+var ErrInvalidStatusReason = apperror.New(
+	apperror.CodeInvalidArgument,
+	"INVALID_STATUS_CHANGE_REASON",
+	"status change reason must be between 1 and 256 characters",
+)
+
+var ErrInvalidStatusTransition = apperror.New(
+	apperror.CodeFailedPrecondition,
+	"INVALID_STATUS_TRANSITION",
+	"closed account status cannot be changed",
+)
+
+var ErrAccountHasBalance = apperror.New(
+	apperror.CodeFailedPrecondition,
+	"ACCOUNT_HAS_BALANCE",
+	"account with non-zero balance cannot be closed",
+)
+
+var ErrInvalidPeriod = apperror.New(apperror.CodeInvalidArgument, "INVALID_PERIOD", "period start must be before its end")
+
+var ErrInvalidPageSize = apperror.New(
+	apperror.CodeInvalidArgument,
+	"INVALID_PAGE_SIZE",
+	"page size must be between 0 and 500",
+)
+
+var ErrInvalidPageToken = apperror.New(apperror.CodeInvalidArgument, "INVALID_PAGE_TOKEN", "invalid page token")
+
+var ErrStatementTooLarge = apperror.New(
+	apperror.CodeFailedPrecondition,
+	"STATEMENT_TOO_LARGE",
+	"statement period contains too many transactions, narrow the period",
+)
+
+var ErrUnauthenticated = apperror.New(
+	apperror.CodeUnauthenticated,
+	"AUTHENTICATION_REQUIRED",
+	"account access requires authentication",
+)
+
+var ErrPermissionDenied = apperror.New(
+	apperror.CodePermissionDenied,
+	"ACCOUNT_ACCESS_DENIED",
+	"only the caller's account can be accessed",
+)
+
+var ErrInvalidPolicy = apperror.New(apperror.CodeInvalidArgument, "INVALID_SPENDING_POLICY", "invalid spending policy")
+
+// ErrTransactionConflict - транзакция откачена из-за конфликта с параллельной
+// транзакцией и может быть повторена.
+var ErrTransactionConflict = apperror.New(
+	apperror.CodeUnavailable,
+	"TRANSACTION_CONFLICT",
+	"concurrent update conflict, retry the request",
+)
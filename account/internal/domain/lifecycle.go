@@ -0,0 +1,219 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hickar/crtex_test_assignment/events"
+)
+
+type AccountStatus string
+
+// Оплаты со счетов в статусах, отличных от ACTIVE, отменяются. Заблокированный
+// счёт можно разблокировать, закрытый - нет. История операций доступна для
+// счетов в любом статусе.
+const (
+	AccountStatusActive AccountStatus = "ACTIVE"
+	AccountStatusFrozen AccountStatus = "FROZEN"
+	AccountStatusClosed AccountStatus = "CLOSED"
+)
+
+// ActorUserService - инициатор изменений статуса по событиям сервиса пользователей.
+const ActorUserService = "user-service"
+
+// MaxStatusChangeReasonLength - наибольшая длина причины изменения статуса в символах.
+const MaxStatusChangeReasonLength = 256
+
+// StatusChange - запись журнала изменений статуса счёта.
+type StatusChange struct {
+	ID         int64
+	AccountID  int64
+	FromStatus AccountStatus
+	ToStatus   AccountStatus
+	// Actor - инициатор изменения, например "user:1" или ActorUserService.
+	Actor     string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// paymentCancelReasons - причины отмены оплат для статусов, запрещающих оплату.
+var paymentCancelReasons = map[AccountStatus]events.CancelReason{
+	AccountStatusFrozen: events.CancelReasonAccountFrozen,
+	AccountStatusClosed: events.CancelReasonAccountClosed,
+}
+
+// OpenAccount создаёт счёт пользователя с начальным балансом balanceCents.
+func (s *AccountService) OpenAccount(ctx context.Context, userID, balanceCents int64) (Account, error) {
+	if userID <= 0 || balanceCents < 0 {
+		return Account{}, ErrInvalidUserEvent
+	}
+
+	var account Account
+	err := s.repo.WithinTransaction(ctx, func(tctx context.Context) error {
+		var err error
+		account, err = s.repo.CreateAccount(tctx, Account{
+			UserID:      userID,
+			AmountCents: balanceCents,
+			Status:      AccountStatusActive,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.repo.CreateTransaction(tctx, Transaction{
+			AccountID:    account.ID,
+			Type:         TransactionDeposit,
+			AmountCents:  balanceCents,
+			BalanceCents: balanceCents,
+		})
+	})
+
+	return account, err
+}
+
+// ChangeAccountStatus переводит счёт пользователя в статус status и записывает
+// изменение в журнал. Если счёт уже в этом статусе, журнал не меняется.
+func (s *AccountService) ChangeAccountStatus(
+	ctx context.Context,
+	userID int64,
+	status AccountStatus,
+	actor, reason string,
+) (Account, error) {
+	if _, ok := paymentCancelReasons[status]; !ok && status != AccountStatusActive {
+		return Account{}, ErrInvalidStatus
+	}
+	if reason == "" || utf8.RuneCountInString(reason) > MaxStatusChangeReasonLength {
+		return Account{}, ErrInvalidStatusReason
+	}
+
+	var account Account
+	err := s.repo.WithinTransaction(ctx, func(tctx context.Context) error {
+		var err error
+		account, err = s.repo.GetAccountByUserID(tctx, userID)
+		if err != nil {
+			return err
+		}
+		if account.Status == status {
+			return nil
+		}
+
+		return s.changeStatus(tctx, &account, status, actor, reason)
+	})
+
+	return account, err
+}
+
+// ListStatusChanges возвращает журнал изменений статуса счёта пользователя
+// от старых записей к новым.
+func (s *AccountService) ListStatusChanges(ctx context.Context, userID int64) ([]StatusChange, error) {
+	account, err := s.repo.GetAccountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListStatusChanges(ctx, account.ID)
+}
+
+// CloseAccount закрывает счёт удалённого пользователя. Закрытие закрытого
+// счёта возвращает ErrAlreadyProcessed, счёта с ненулевым балансом -
+// ErrAccountHasBalance.
+func (s *AccountService) CloseAccount(ctx context.Context, userID int64, reason string) error {
+	return s.repo.WithinTransaction(ctx, func(tctx context.Context) error {
+		account, err := s.repo.GetAccountByUserID(tctx, userID)
+		if err != nil {
+			return err
+		}
+		if account.Status == AccountStatusClosed {
+			return ErrAlreadyProcessed
+		}
+
+		return s.changeStatus(tctx, &account, AccountStatusClosed, ActorUserService, reason)
+	})
+}
+
+func (s *AccountService) changeStatus(ctx context.Context, account *Account, status AccountStatus, actor, reason string) error {
+	if account.Status == AccountStatusClosed {
+		return ErrInvalidStatusTransition.WithMetadata("status", string(account.Status))
+	}
+	// Средства закрытого счёта нельзя ни потратить, ни вернуть, поэтому
+	// баланс должен быть урегулирован до закрытия.
+	if status == AccountStatusClosed && account.AmountCents != 0 {
+		return ErrAccountHasBalance.WithMetadata("balance_cents", strconv.FormatInt(account.AmountCents, 10))
+	}
+
+	if err := s.repo.UpdateAccountStatus(ctx, account.ID, status); err != nil {
+		return err
+	}
+	if err := s.repo.CreateStatusChange(ctx, StatusChange{
+		AccountID:  account.ID,
+		FromStatus: account.Status,
+		ToStatus:   status,
+		Actor:      actor,
+		Reason:     reason,
+	}); err != nil {
+		return err
+	}
+
+	account.Status = status
+	return nil
+}
+
+// closeUnregisteredAccount создаёт закрытый счёт пользователя, удалённого
+// до регистрации: событие регистрации, доставленное после удаления,
+// не откроет ему активный счёт.
+func (s *AccountService) closeUnregisteredAccount(ctx context.Context, userID int64, reason string) error {
+	if userID <= 0 {
+		return ErrInvalidUserEvent
+	}
+
+	err := s.repo.WithinTransaction(ctx, func(tctx context.Context) error {
+		account, err := s.repo.CreateAccount(tctx, Account{UserID: userID, Status: AccountStatusClosed})
+		if err != nil {
+			return err
+		}
+
+		return s.repo.CreateStatusChange(tctx, StatusChange{
+			AccountID:  account.ID,
+			FromStatus: AccountStatusClosed,
+			ToStatus:   AccountStatusClosed,
+			Actor:      ActorUserService,
+			Reason:     reason,
+		})
+	})
+	// Счёт успели открыть параллельно обработанным событием регистрации.
+	if errors.Is(err, ErrAccountExists) {
+		return s.CloseAccount(ctx, userID, reason)
+	}
+
+	return err
+}
+
+// ProcessUserEvent открывает счёт зарегистрированного пользователя и закрывает
+// счёт удалённого. Повторно доставленные события возвращают ErrAlreadyProcessed.
+// События удаления и регистрации могут прийти в любом порядке: для пользователя,
+// удалённого до регистрации, создаётся закрытый счёт, и регистрация игнорируется.
+func (s *AccountService) ProcessUserEvent(ctx context.Context, event events.UserEvent) error {
+	switch event.Type {
+	case events.UserEventRegistered:
+		_, err := s.OpenAccount(ctx, event.UserID, event.InitialBalanceCents)
+		if errors.Is(err, ErrAccountExists) {
+			return ErrAlreadyProcessed
+		}
+
+		return err
+	case events.UserEventDeleted:
+		reason := fmt.Sprintf("user deleted (event %d)", event.ID)
+		err := s.CloseAccount(ctx, event.UserID, reason)
+		if errors.Is(err, ErrNotFound) {
+			return s.closeUnregisteredAccount(ctx, event.UserID, reason)
+		}
+
+		return err
+	default:
+		return ErrInvalidUserEvent
+	}
+}
@@ -0,0 +1,259 @@
+//go:build unit_test
+
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/events"
+)
+
+func TestProcessUserRegistered(t *testing.T) {
+	var (
+		created     Account
+		transaction Transaction
+	)
+
+	repo := newAccountRepoStub(nil, nil, nil, nil, nil)
+	repo.createAccountFn = func(_ context.Context, account Account) (Account, error) {
+		if created.ID != 0 {
+			return account, ErrAccountExists
+		}
+		account.ID = 3
+		created = account
+		return account, nil
+	}
+	repo.createTransactionFn = func(_ context.Context, t Transaction) error {
+		transaction = t
+		return nil
+	}
+	service := NewAccountService(repo)
+
+	event := events.UserEvent{ID: 1, UserID: 7, Type: events.UserEventRegistered, InitialBalanceCents: 5000}
+	require.NoError(t, service.ProcessUserEvent(context.Background(), event))
+
+	assert.Equal(t, Account{ID: 3, UserID: 7, AmountCents: 5000, Status: AccountStatusActive}, created)
+	assert.Equal(t, Transaction{AccountID: 3, Type: TransactionDeposit, AmountCents: 5000, BalanceCents: 5000}, transaction)
+
+	// Повторная доставка события не создаёт второй счёт.
+	assert.ErrorIs(t, service.ProcessUserEvent(context.Background(), event), ErrAlreadyProcessed)
+
+	event.InitialBalanceCents = -1
+	assert.ErrorIs(t, service.ProcessUserEvent(context.Background(), event), ErrInvalidUserEvent)
+}
+
+func TestProcessUserDeleted(t *testing.T) {
+	tests := []struct {
+		name    string
+		account Account
+		err     error
+		closed  bool
+	}{
+		{name: "Active", account: Account{ID: 3, Status: AccountStatusActive}, closed: true},
+		{name: "AlreadyClosed", account: Account{ID: 3, Status: AccountStatusClosed}, err: ErrAlreadyProcessed},
+		{name: "NonZeroBalance", account: Account{ID: 3, AmountCents: 100, Status: AccountStatusActive}, err: ErrAccountHasBalance},
+		{name: "Overdraft", account: Account{ID: 3, AmountCents: -100, Status: AccountStatusFrozen}, err: ErrAccountHasBalance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var closed bool
+
+			repo := newAccountRepoStub(
+				nil,
+				func(context.Context, int64) (Account, error) { return tt.account, nil },
+				nil,
+				nil,
+				nil,
+			)
+			repo.updateStatusFn = func(_ context.Context, accountID int64, status AccountStatus) error {
+				closed = accountID == tt.account.ID && status == AccountStatusClosed
+				return nil
+			}
+			repo.createStatusChangeFn = func(_ context.Context, change StatusChange) error {
+				assert.Equal(t, ActorUserService, change.Actor)
+				assert.Equal(t, "user deleted (event 2)", change.Reason)
+				return nil
+			}
+
+			err := NewAccountService(repo).ProcessUserEvent(context.Background(), events.UserEvent{
+				ID:     2,
+				UserID: 7,
+				Type:   events.UserEventDeleted,
+			})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.closed, closed)
+		})
+	}
+}
+
+func TestProcessUserDeletedBeforeRegistered(t *testing.T) {
+	accounts := make(map[int64]Account)
+	var changes []StatusChange
+
+	repo := newAccountRepoStub(
+		nil,
+		func(_ context.Context, userID int64) (Account, error) {
+			account, ok := accounts[userID]
+			if !ok {
+				return Account{}, ErrNotFound
+			}
+			return account, nil
+		},
+		nil,
+		nil,
+		nil,
+	)
+	repo.createAccountFn = func(_ context.Context, account Account) (Account, error) {
+		if _, ok := accounts[account.UserID]; ok {
+			return account, ErrAccountExists
+		}
+		account.ID = int64(len(accounts) + 1)
+		accounts[account.UserID] = account
+		return account, nil
+	}
+	repo.createStatusChangeFn = func(_ context.Context, change StatusChange) error {
+		changes = append(changes, change)
+		return nil
+	}
+	repo.createTransactionFn = func(context.Context, Transaction) error {
+		t.Fatal("deleted user must not be credited")
+		return nil
+	}
+	service := NewAccountService(repo)
+
+	deleted := events.UserEvent{ID: 2, UserID: 7, Type: events.UserEventDeleted}
+	require.NoError(t, service.ProcessUserEvent(context.Background(), deleted))
+
+	assert.Equal(t, Account{ID: 1, UserID: 7, Status: AccountStatusClosed}, accounts[7])
+	assert.Equal(t, []StatusChange{{
+		AccountID:  1,
+		FromStatus: AccountStatusClosed,
+		ToStatus:   AccountStatusClosed,
+		Actor:      ActorUserService,
+		Reason:     "user deleted (event 2)",
+	}}, changes)
+
+	// Регистрация, доставленная после удаления, не открывает счёт.
+	registered := events.UserEvent{ID: 1, UserID: 7, Type: events.UserEventRegistered, InitialBalanceCents: 5000}
+	assert.ErrorIs(t, service.ProcessUserEvent(context.Background(), registered), ErrAlreadyProcessed)
+	assert.Equal(t, AccountStatusClosed, accounts[7].Status)
+
+	assert.ErrorIs(t, service.ProcessUserEvent(context.Background(), deleted), ErrAlreadyProcessed)
+	assert.ErrorIs(t,
+		service.ProcessUserEvent(context.Background(), events.UserEvent{ID: 3, Type: events.UserEventDeleted}),
+		ErrInvalidUserEvent,
+	)
+}
+
+func TestProcessNewOrderInactiveAccount(t *testing.T) {
+	tests := []struct {
+		status AccountStatus
+		reason events.CancelReason
+	}{
+		{status: AccountStatusFrozen, reason: events.CancelReasonAccountFrozen},
+		{status: AccountStatusClosed, reason: events.CancelReasonAccountClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			var event events.AccountOrderPaymentEvent
+
+			repo := newAccountRepoStub(
+				func(context.Context, int64) (bool, error) { return false, nil },
+				func(_ context.Context, userID int64) (Account, error) {
+					return Account{ID: 1, UserID: userID, AmountCents: 1000, Status: tt.status}, nil
+				},
+				func(context.Context, Account) error {
+					t.Fatal("inactive account must not be charged")
+					return nil
+				},
+				func(_ context.Context, e events.AccountOrderPaymentEvent) error {
+					event = e
+					return nil
+				},
+				nil,
+			)
+
+			err := NewAccountService(repo).ProcessNewOrder(context.Background(), events.OrderCreatedEvent{
+				ID:          1,
+				UserID:      1,
+				AmountCents: 100,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, events.AccountOrderStatusCanceled, event.Status)
+			assert.Equal(t, tt.reason, event.Reason)
+		})
+	}
+}
+
+func TestChangeAccountStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    AccountStatus
+		balance int64
+		to      AccountStatus
+		reason  string
+		err     error
+		changed bool
+	}{
+		{name: "Freeze", from: AccountStatusActive, to: AccountStatusFrozen, reason: "fraud", changed: true},
+		{name: "Unfreeze", from: AccountStatusFrozen, to: AccountStatusActive, reason: "resolved", changed: true},
+		{name: "Close", from: AccountStatusFrozen, to: AccountStatusClosed, reason: "request", changed: true},
+		{name: "CloseWithBalance", from: AccountStatusActive, balance: 100, to: AccountStatusClosed, reason: "x", err: ErrAccountHasBalance},
+		{name: "Unchanged", from: AccountStatusFrozen, to: AccountStatusFrozen, reason: "again"},
+		{name: "ReopenClosed", from: AccountStatusClosed, to: AccountStatusActive, reason: "x", err: ErrInvalidStatusTransition},
+		{name: "UnknownStatus", from: AccountStatusActive, to: "DELETED", reason: "x", err: ErrInvalidStatus},
+		{name: "EmptyReason", from: AccountStatusActive, to: AccountStatusFrozen, err: ErrInvalidStatusReason},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changes []StatusChange
+
+			repo := newAccountRepoStub(
+				nil,
+				func(_ context.Context, userID int64) (Account, error) {
+					return Account{ID: 3, UserID: userID, AmountCents: tt.balance, Status: tt.from}, nil
+				},
+				nil,
+				nil,
+				nil,
+			)
+			repo.createStatusChangeFn = func(_ context.Context, change StatusChange) error {
+				changes = append(changes, change)
+				return nil
+			}
+
+			account, err := NewAccountService(repo).ChangeAccountStatus(context.Background(), 7, tt.to, "user:1", tt.reason)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Empty(t, changes)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.to, account.Status)
+
+			if !tt.changed {
+				assert.Empty(t, changes)
+				return
+			}
+			assert.Equal(t, []StatusChange{{
+				AccountID:  3,
+				FromStatus: tt.from,
+				ToStatus:   tt.to,
+				Actor:      "user:1",
+				Reason:     tt.reason,
+			}}, changes)
+		})
+	}
+}
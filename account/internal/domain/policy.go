@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/hickar/crtex_test_assignment/events"
+	"github.com/hickar/crtex_test_assignment/pkg/apperror"
+)
+
+// SpendingPolicy - ограничения списаний со счёта. Нулевые лимиты
+// не ограничивают списания, нулевой овердрафт запрещает отрицательный баланс.
+type SpendingPolicy struct {
+	// OverdraftLimitCents - на сколько баланс может уйти в минус.
+	OverdraftLimitCents int64
+	MaxOrderAmountCents int64
+	// DailyLimitCents и MonthlyLimitCents ограничивают сумму оплат за вычетом
+	// возвратов с начала текущих суток и месяца по UTC.
+	DailyLimitCents   int64
+	MonthlyLimitCents int64
+}
+
+func (p SpendingPolicy) validate() []apperror.FieldViolation {
+	limits := []struct {
+		field string
+		value int64
+	}{
+		{"overdraft_limit", p.OverdraftLimitCents},
+		{"max_order_amount", p.MaxOrderAmountCents},
+		{"daily_limit", p.DailyLimitCents},
+		{"monthly_limit", p.MonthlyLimitCents},
+	}
+
+	var violations []apperror.FieldViolation
+	for _, l := range limits {
+		if l.value < 0 {
+			violations = append(violations, apperror.FieldViolation{Field: l.field, Description: "must not be negative"})
+		}
+	}
+
+	return violations
+}
+
+func (s *AccountService) GetSpendingPolicy(ctx context.Context, userID int64) (SpendingPolicy, error) {
+	account, err := s.repo.GetAccountByUserID(ctx, userID)
+	if err != nil {
+		return SpendingPolicy{}, err
+	}
+
+	return account.Policy, nil
+}
+
+func (s *AccountService) UpdateSpendingPolicy(ctx context.Context, userID int64, policy SpendingPolicy) error {
+	if violations := policy.validate(); len(violations) > 0 {
+		return ErrInvalidPolicy.WithViolations(violations...)
+	}
+
+	account, err := s.repo.GetAccountByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdateSpendingPolicy(ctx, account.ID, policy)
+}
+
+// checkSpendingPolicy возвращает причину отказа в оплате заказа на сумму
+// amountCents или пустую строку, если политика счёта её допускает. Вызывается
+// в транзакции оплаты, чтобы суммы оплат не менялись до её завершения.
+func (s *AccountService) checkSpendingPolicy(ctx context.Context, account Account, amountCents int64) (events.CancelReason, error) {
+	policy := account.Policy
+
+	if policy.MaxOrderAmountCents > 0 && amountCents > policy.MaxOrderAmountCents {
+		return events.CancelReasonOrderLimitExceeded, nil
+	}
+	if account.AmountCents-amountCents < -policy.OverdraftLimitCents {
+		return events.CancelReasonInsufficientFunds, nil
+	}
+
+	now := time.Now().UTC()
+	limits := []struct {
+		limit  int64
+		since  time.Time
+		reason events.CancelReason
+	}{
+		{
+			limit:  policy.DailyLimitCents,
+			since:  time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+			reason: events.CancelReasonDailyLimitExceeded,
+		},
+		{
+			limit:  policy.MonthlyLimitCents,
+			since:  time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+			reason: events.CancelReasonMonthlyLimitExceeded,
+		},
+	}
+	for _, l := range limits {
+		if l.limit == 0 {
+			continue
+		}
+
+		spent, err := s.repo.SpentCents(ctx, account.ID, l.since)
+		if err != nil {
+			return "", err
+		}
+		if spent+amountCents > l.limit {
+			return l.reason, nil
+		}
+	}
+
+	return "", nil
+}
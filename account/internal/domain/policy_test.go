@@ -0,0 +1,117 @@
+//go:build unit_test
+
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/events"
+	"github.com/hickar/crtex_test_assignment/pkg/apperror"
+)
+
+func TestProcessNewOrderSpendingPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SpendingPolicy
+		amount int64
+		// spent - суммы оплат за проверяемые периоды: сутки, затем месяц.
+		spent  []int64
+		reason events.CancelReason
+	}{
+		{name: "WithinBalance", amount: 1000},
+		{name: "InsufficientFunds", amount: 1001, reason: events.CancelReasonInsufficientFunds},
+		{name: "WithinOverdraft", policy: SpendingPolicy{OverdraftLimitCents: 500}, amount: 1500},
+		{
+			name:   "OverdraftExceeded",
+			policy: SpendingPolicy{OverdraftLimitCents: 500},
+			amount: 1501,
+			reason: events.CancelReasonInsufficientFunds,
+		},
+		{
+			name:   "OrderLimitExceeded",
+			policy: SpendingPolicy{MaxOrderAmountCents: 100},
+			amount: 101,
+			reason: events.CancelReasonOrderLimitExceeded,
+		},
+		{
+			name:   "WithinDailyLimit",
+			policy: SpendingPolicy{DailyLimitCents: 300},
+			amount: 100,
+			spent:  []int64{200},
+		},
+		{
+			name:   "DailyLimitExceeded",
+			policy: SpendingPolicy{DailyLimitCents: 300, MonthlyLimitCents: 1000},
+			amount: 101,
+			spent:  []int64{200, 200},
+			reason: events.CancelReasonDailyLimitExceeded,
+		},
+		{
+			name:   "MonthlyLimitExceeded",
+			policy: SpendingPolicy{DailyLimitCents: 300, MonthlyLimitCents: 1000},
+			amount: 100,
+			spent:  []int64{0, 950},
+			reason: events.CancelReasonMonthlyLimitExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event events.AccountOrderPaymentEvent
+
+			repo := newAccountRepoStub(
+				func(context.Context, int64) (bool, error) { return false, nil },
+				func(_ context.Context, userID int64) (Account, error) {
+					return Account{ID: 1, UserID: userID, AmountCents: 1000, Policy: tt.policy}, nil
+				},
+				nil,
+				func(_ context.Context, e events.AccountOrderPaymentEvent) error {
+					event = e
+					return nil
+				},
+				nil,
+			)
+			spent := tt.spent
+			repo.spentCentsFn = func(context.Context, int64, time.Time) (int64, error) {
+				require.NotEmpty(t, spent, "unexpected spending query")
+				s := spent[0]
+				spent = spent[1:]
+				return s, nil
+			}
+
+			err := NewAccountService(repo).ProcessNewOrder(context.Background(), events.OrderCreatedEvent{
+				ID:          1,
+				UserID:      1,
+				AmountCents: tt.amount,
+			})
+			require.NoError(t, err)
+
+			if tt.reason == "" {
+				assert.Equal(t, events.AccountOrderStatusPaid, event.Status)
+			} else {
+				assert.Equal(t, events.AccountOrderStatusCanceled, event.Status)
+			}
+			assert.Equal(t, tt.reason, event.Reason)
+		})
+	}
+}
+
+func TestUpdateSpendingPolicyValidation(t *testing.T) {
+	err := NewAccountService(&accountRepoStub{}).UpdateSpendingPolicy(context.Background(), 1, SpendingPolicy{
+		OverdraftLimitCents: -1,
+		MonthlyLimitCents:   -1,
+	})
+	require.ErrorIs(t, err, ErrInvalidPolicy)
+
+	appErr, ok := apperror.From(err)
+	require.True(t, ok)
+	assert.Equal(t, []apperror.FieldViolation{
+		{Field: "overdraft_limit", Description: "must not be negative"},
+		{Field: "monthly_limit", Description: "must not be negative"},
+	}, appErr.Violations)
+}
@@ -0,0 +1,261 @@
+package domain
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+type TransactionType string
+
+const (
+	TransactionPayment TransactionType = "PAYMENT"
+	TransactionRefund  TransactionType = "REFUND"
+	TransactionDeposit TransactionType = "DEPOSIT"
+)
+
+// Transaction - операция, изменившая баланс счёта.
+type Transaction struct {
+	ID        int64
+	AccountID int64
+	Type      TransactionType
+	// AmountCents - изменение баланса, отрицательное для списаний.
+	AmountCents int64
+	// BalanceCents - баланс счёта после операции.
+	BalanceCents int64
+	// OrderID равен 0 для операций без заказа.
+	OrderID   int64
+	CreatedAt time.Time
+}
+
+// TransactionFilter отбирает операции счёта в порядке возрастания ID или,
+// с Descending, убывания. Нулевые значения полей означают отсутствие ограничения.
+type TransactionFilter struct {
+	AccountID int64
+	// From включительно, To не включительно.
+	From time.Time
+	To   time.Time
+	// AfterID - для постраничного чтения: операции после операции с этим ID
+	// в порядке сортировки.
+	AfterID    int64
+	Descending bool
+	Limit      int
+}
+
+// TransactionQuery - параметры постраничного чтения истории операций.
+type TransactionQuery struct {
+	From      time.Time
+	To        time.Time
+	PageSize  int
+	PageToken string
+}
+
+type TransactionPage struct {
+	Transactions []Transaction
+	// NextPageToken пуст на последней странице.
+	NextPageToken string
+}
+
+const (
+	DefaultTransactionPageSize = 50
+	MaxTransactionPageSize     = 500
+	// MaxStatementTransactions - наибольшее число операций в выписке.
+	MaxStatementTransactions = 10000
+	// DefaultStatementPeriod - период выписки, если его начало не задано.
+	DefaultStatementPeriod = 30 * 24 * time.Hour
+)
+
+// Statement - выписка по счёту за период [From, To).
+type Statement struct {
+	AccountID           int64
+	From                time.Time
+	To                  time.Time
+	OpeningBalanceCents int64
+	ClosingBalanceCents int64
+	CreditsCents        int64
+	// DebitsCents - сумма списаний, положительное число.
+	DebitsCents  int64
+	Transactions []Transaction
+}
+
+func (s *AccountService) ListTransactions(ctx context.Context, userID int64, query TransactionQuery) (TransactionPage, error) {
+	if err := validatePeriod(query.From, query.To); err != nil {
+		return TransactionPage{}, err
+	}
+
+	pageSize := query.PageSize
+	switch {
+	case pageSize == 0:
+		pageSize = DefaultTransactionPageSize
+	case pageSize < 0 || pageSize > MaxTransactionPageSize:
+		return TransactionPage{}, ErrInvalidPageSize
+	}
+
+	afterID, err := decodePageToken(query.PageToken)
+	if err != nil {
+		return TransactionPage{}, err
+	}
+
+	account, err := s.repo.GetAccountByUserID(ctx, userID)
+	if err != nil {
+		return TransactionPage{}, err
+	}
+
+	// Лишняя операция показывает, что есть следующая страница.
+	transactions, err := s.repo.ListTransactions(ctx, TransactionFilter{
+		AccountID:  account.ID,
+		From:       query.From,
+		To:         query.To,
+		AfterID:    afterID,
+		Descending: true,
+		Limit:      pageSize + 1,
+	})
+	if err != nil {
+		return TransactionPage{}, err
+	}
+
+	page := TransactionPage{Transactions: transactions}
+	if len(transactions) > pageSize {
+		page.Transactions = transactions[:pageSize]
+		page.NextPageToken = encodePageToken(page.Transactions[pageSize-1].ID)
+	}
+
+	return page, nil
+}
+
+// GetStatement формирует выписку за период [from, to). Без to период
+// заканчивается текущим моментом, без from - начинается за
+// DefaultStatementPeriod до to.
+func (s *AccountService) GetStatement(ctx context.Context, userID int64, from, to time.Time) (Statement, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-DefaultStatementPeriod)
+	}
+	if err := validatePeriod(from, to); err != nil {
+		return Statement{}, err
+	}
+
+	account, err := s.repo.GetAccountByUserID(ctx, userID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	statement := Statement{AccountID: account.ID, From: from, To: to}
+	err = s.repo.WithinTransaction(ctx, func(tctx context.Context) error {
+		// Баланс на начало периода - баланс после последней операции до него.
+		previous, err := s.repo.ListTransactions(tctx, TransactionFilter{
+			AccountID:  account.ID,
+			To:         from,
+			Descending: true,
+			Limit:      1,
+		})
+		if err != nil {
+			return err
+		}
+		if len(previous) > 0 {
+			statement.OpeningBalanceCents = previous[0].BalanceCents
+		}
+
+		statement.Transactions, err = s.repo.ListTransactions(tctx, TransactionFilter{
+			AccountID: account.ID,
+			From:      from,
+			To:        to,
+			Limit:     MaxStatementTransactions + 1,
+		})
+		return err
+	})
+	if err != nil {
+		return Statement{}, err
+	}
+	if len(statement.Transactions) > MaxStatementTransactions {
+		return Statement{}, ErrStatementTooLarge
+	}
+
+	statement.ClosingBalanceCents = statement.OpeningBalanceCents
+	for _, transaction := range statement.Transactions {
+		if transaction.AmountCents >= 0 {
+			statement.CreditsCents += transaction.AmountCents
+		} else {
+			statement.DebitsCents -= transaction.AmountCents
+		}
+		statement.ClosingBalanceCents = transaction.BalanceCents
+	}
+
+	return statement, nil
+}
+
+// WriteCSV записывает операции выписки в формате CSV с заголовком. Суммы
+// записываются в рублях с двумя знаками после запятой, время - в RFC 3339 UTC.
+func (s Statement) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"id", "created_at", "type", "order_id", "amount", "balance"})
+
+	for _, transaction := range s.Transactions {
+		orderID := ""
+		if transaction.OrderID != 0 {
+			orderID = strconv.FormatInt(transaction.OrderID, 10)
+		}
+
+		_ = cw.Write([]string{
+			strconv.FormatInt(transaction.ID, 10),
+			transaction.CreatedAt.UTC().Format(time.RFC3339),
+			string(transaction.Type),
+			orderID,
+			formatCents(transaction.AmountCents),
+			formatCents(transaction.BalanceCents),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	fraction := strconv.FormatInt(cents%100, 10)
+	if len(fraction) == 1 {
+		fraction = "0" + fraction
+	}
+
+	return sign + strconv.FormatInt(cents/100, 10) + "." + fraction
+}
+
+func validatePeriod(from, to time.Time) error {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return ErrInvalidPeriod
+	}
+
+	return nil
+}
+
+// Токен страницы - ID последней операции предыдущей страницы.
+func encodePageToken(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodePageToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidPageToken
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidPageToken
+	}
+
+	return id, nil
+}
@@ -0,0 +1,90 @@
+//go:build unit_test
+
+package domain
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/events"
+)
+
+func TestProcessNewOrderCreatesTransaction(t *testing.T) {
+	var actual Transaction
+
+	repo := newAccountRepoStub(
+		func(context.Context, int64) (bool, error) { return false, nil },
+		func(_ context.Context, userID int64) (Account, error) {
+			return Account{ID: 7, UserID: userID, AmountCents: 5000}, nil
+		},
+		nil,
+		nil,
+		nil,
+	)
+	repo.createTransactionFn = func(_ context.Context, transaction Transaction) error {
+		actual = transaction
+		return nil
+	}
+
+	err := NewAccountService(repo).ProcessNewOrder(context.Background(), events.OrderCreatedEvent{
+		ID:          1,
+		OrderID:     10,
+		UserID:      1,
+		AmountCents: 1200,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, Transaction{
+		AccountID:    7,
+		Type:         TransactionPayment,
+		AmountCents:  -1200,
+		BalanceCents: 3800,
+		OrderID:      10,
+	}, actual)
+}
+
+func TestListTransactionsValidation(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		query TransactionQuery
+		err   error
+	}{
+		{name: "InvalidPeriod", query: TransactionQuery{From: now, To: now}, err: ErrInvalidPeriod},
+		{name: "PageSizeTooLarge", query: TransactionQuery{PageSize: MaxTransactionPageSize + 1}, err: ErrInvalidPageSize},
+		{name: "InvalidPageToken", query: TransactionQuery{PageToken: "!"}, err: ErrInvalidPageToken},
+		{name: "NonNumericPageToken", query: TransactionQuery{PageToken: "YWJj"}, err: ErrInvalidPageToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAccountService(&accountRepoStub{}).ListTransactions(context.Background(), 1, tt.query)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestStatementWriteCSV(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	statement := Statement{
+		Transactions: []Transaction{
+			{ID: 1, Type: TransactionDeposit, AmountCents: 100000, BalanceCents: 100000, CreatedAt: createdAt},
+			{ID: 2, Type: TransactionPayment, AmountCents: -1205, BalanceCents: 98795, OrderID: 3, CreatedAt: createdAt},
+			{ID: 3, Type: TransactionRefund, AmountCents: 5, BalanceCents: 98800, OrderID: 3, CreatedAt: createdAt},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, statement.WriteCSV(&buf))
+
+	assert.Equal(t, "id,created_at,type,order_id,amount,balance\n"+
+		"1,2024-03-01T09:00:00Z,DEPOSIT,,1000.00,1000.00\n"+
+		"2,2024-03-01T09:00:00Z,PAYMENT,3,-12.05,987.95\n"+
+		"3,2024-03-01T09:00:00Z,REFUND,3,0.05,988.00\n", buf.String())
+}
@@ -3,12 +3,14 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/hickar/crtex_test_assignment/events"
+	"github.com/hickar/crtex_test_assignment/pkg/postgres"
 
 	"github.com/hickar/crtex_test_assignment/account/internal/domain"
 )
@@ -21,6 +23,11 @@ func NewAccountRepository(pool *pgxpool.Pool) *AccountRepository {
 	return &AccountRepository{db: pool}
 }
 
+// Ping проверяет доступность базы данных.
+func (r *AccountRepository) Ping(ctx context.Context) error {
+	return r.db.Ping(ctx)
+}
+
 func (r *AccountRepository) AccountEventWithOrderEventIDExists(ctx context.Context, orderEventID int64) (bool, error) {
 	tx := getTxFromContextOrDB(ctx, r.db)
 
@@ -32,16 +39,45 @@ func (r *AccountRepository) AccountEventWithOrderEventIDExists(ctx context.Conte
 	return exists, err
 }
 
+func (r *AccountRepository) CreateAccount(ctx context.Context, account domain.Account) (domain.Account, error) {
+	tx := getTxFromContextOrDB(ctx, r.db)
+
+	query := `INSERT INTO accounts (user_id, amount_cents, status) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING
+		RETURNING id;`
+
+	if err := tx.QueryRow(ctx, query, account.UserID, account.AmountCents, account.Status).Scan(&account.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return account, domain.ErrAccountExists
+		}
+
+		return account, err
+	}
+
+	return account, nil
+}
+
 func (r *AccountRepository) GetAccountByUserID(ctx context.Context, userID int64) (domain.Account, error) {
 	tx := getTxFromContextOrDB(ctx, r.db)
 
-	query := `SELECT id, user_id, amount_cents FROM accounts WHERE user_id = $1;`
+	query := `SELECT id, user_id, amount_cents, status,
+			overdraft_limit_cents, max_order_amount_cents, daily_limit_cents, monthly_limit_cents
+		FROM accounts WHERE user_id = $1`
+	// Блокировка не даёт параллельным оплатам превысить лимиты счёта.
+	if _, ok := tx.(pgx.Tx); ok {
+		query += ` FOR UPDATE`
+	}
 
 	var account domain.Account
 	if err := tx.QueryRow(ctx, query, userID).Scan(
 		&account.ID,
 		&account.UserID,
 		&account.AmountCents,
+		&account.Status,
+		&account.Policy.OverdraftLimitCents,
+		&account.Policy.MaxOrderAmountCents,
+		&account.Policy.DailyLimitCents,
+		&account.Policy.MonthlyLimitCents,
 	); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return account, domain.ErrNotFound
@@ -62,35 +98,233 @@ func (r *AccountRepository) UpdateAccount(ctx context.Context, account domain.Ac
 	return err
 }
 
+func (r *AccountRepository) UpdateAccountStatus(ctx context.Context, accountID int64, status domain.AccountStatus) error {
+	tx := getTxFromContextOrDB(ctx, r.db)
+
+	query := `UPDATE accounts SET status = $2 WHERE id = $1;`
+
+	_, err := tx.Exec(ctx, query, accountID, status)
+	return err
+}
+
+func (r *AccountRepository) CreateStatusChange(ctx context.Context, change domain.StatusChange) error {
+	tx := getTxFromContextOrDB(ctx, r.db)
+
+	query := `INSERT INTO account_status_changes (account_id, from_status, to_status, actor, reason)
+		VALUES ($1, $2, $3, $4, $5);`
+
+	_, err := tx.Exec(ctx, query, change.AccountID, change.FromStatus, change.ToStatus, change.Actor, change.Reason)
+	return err
+}
+
+func (r *AccountRepository) ListStatusChanges(ctx context.Context, accountID int64) ([]domain.StatusChange, error) {
+	tx := getTxFromContextOrDB(ctx, r.db)
+
+	query := `SELECT id, account_id, from_status, to_status, actor, reason, created_at
+		FROM account_status_changes
+		WHERE account_id = $1
+		ORDER BY id;`
+
+	rows, err := tx.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []domain.StatusChange
+	for rows.Next() {
+		var change domain.StatusChange
+		if err = rows.Scan(
+			&change.ID,
+			&change.AccountID,
+			&change.FromStatus,
+			&change.ToStatus,
+			&change.Actor,
+			&change.Reason,
+			&change.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func (r *AccountRepository) UpdateSpendingPolicy(ctx context.Context, accountID int64, policy domain.SpendingPolicy) error {
+	tx := getTxFromContextOrDB(ctx, r.db)
+
+	query := `UPDATE accounts
+		SET overdraft_limit_cents = $2, max_order_amount_cents = $3, daily_limit_cents = $4, monthly_limit_cents = $5
+		WHERE id = $1;`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		accountID,
+		policy.OverdraftLimitCents,
+		policy.MaxOrderAmountCents,
+		policy.DailyLimitCents,
+		policy.MonthlyLimitCents,
+	)
+	return err
+}
+
+func (r *AccountRepository) SpentCents(ctx context.Context, accountID int64, since time.Time) (int64, error) {
+	tx := getTxFromContextOrDB(ctx, r.db)
+
+	query := `SELECT COALESCE(-SUM(amount_cents), 0)::bigint
+		FROM account_transactions
+		WHERE account_id = $1 AND type IN ('PAYMENT', 'REFUND') AND created_at >= $2;`
+
+	var spent int64
+	err := tx.QueryRow(ctx, query, accountID, since).Scan(&spent)
+	return spent, err
+}
+
 func (r *AccountRepository) CreateAccountEvent(ctx context.Context, event events.AccountOrderPaymentEvent) error {
 	tx := getTxFromContextOrDB(ctx, r.db)
 
-	query := `INSERT INTO account_events (order_event_id, account_id, order_id, status) VALUES ($1, $2, $3, $4);`
+	query := `INSERT INTO account_events (order_event_id, account_id, order_id, status, reason)
+		VALUES ($1, $2, $3, $4, $5);`
 
 	var accountID *int64
 	if event.AccountID != 0 {
 		accountID = &event.AccountID
 	}
 
-	_, err := tx.Exec(ctx, query, event.OrderEventID, accountID, event.OrderID, event.Status)
+	_, err := tx.Exec(ctx, query, event.OrderEventID, accountID, event.OrderID, event.Status, event.Reason)
+	return err
+}
+
+func (r *AccountRepository) CreateTransaction(ctx context.Context, transaction domain.Transaction) error {
+	tx := getTxFromContextOrDB(ctx, r.db)
+
+	query := `INSERT INTO account_transactions (account_id, type, amount_cents, balance_cents, order_id)
+		VALUES ($1, $2, $3, $4, $5);`
+
+	var orderID *int64
+	if transaction.OrderID != 0 {
+		orderID = &transaction.OrderID
+	}
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		transaction.AccountID,
+		transaction.Type,
+		transaction.AmountCents,
+		transaction.BalanceCents,
+		orderID,
+	)
 	return err
 }
 
+func (r *AccountRepository) ListTransactions(ctx context.Context, filter domain.TransactionFilter) ([]domain.Transaction, error) {
+	tx := getTxFromContextOrDB(ctx, r.db)
+
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+
+	// Операции одного счёта записываются под блокировкой его строки,
+	// поэтому порядок ID совпадает с порядком изменения баланса.
+	order, cmp := "ASC", ">"
+	if filter.Descending {
+		order, cmp = "DESC", "<"
+	}
+	query := `SELECT id, account_id, type, amount_cents, balance_cents, COALESCE(order_id, 0), created_at
+		FROM account_transactions
+		WHERE account_id = $1
+			AND ($2::timestamptz IS NULL OR created_at >= $2)
+			AND ($3::timestamptz IS NULL OR created_at < $3)
+			AND ($4::bigint = 0 OR id ` + cmp + ` $4)
+		ORDER BY id ` + order + `
+		LIMIT NULLIF($5, 0);`
+
+	rows, err := tx.Query(ctx, query, filter.AccountID, from, to, filter.AfterID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []domain.Transaction
+	for rows.Next() {
+		var transaction domain.Transaction
+		if err = rows.Scan(
+			&transaction.ID,
+			&transaction.AccountID,
+			&transaction.Type,
+			&transaction.AmountCents,
+			&transaction.BalanceCents,
+			&transaction.OrderID,
+			&transaction.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
 type txContextKey string
 
 const transactionCtxKey txContextKey = "ctxtransaction"
 
+// maxTransactionAttempts ограничивает число попыток транзакции, откаченной
+// из-за конфликта с параллельной транзакцией.
+const maxTransactionAttempts = 5
+
+// WithinTransaction повторяет транзакцию, откаченную из-за ошибки сериализации
+// или взаимоблокировки. Вложенный вызов не повторяется, а возвращает такую
+// ошибку как domain.ErrTransactionConflict, чтобы её повторила внешняя транзакция.
 func (r *AccountRepository) WithinTransaction(ctx context.Context, txfn func(context.Context) error) error {
-	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{
-		IsoLevel: pgx.RepeatableRead,
-	})
+	if parent, ok := ctx.Value(transactionCtxKey).(pgx.Tx); ok {
+		err := runTransaction(ctx, func() (pgx.Tx, error) { return parent.Begin(ctx) }, txfn)
+		if postgres.IsSerializationFailure(err) {
+			return domain.ErrTransactionConflict.Wrap(err)
+		}
+
+		return err
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		err = runTransaction(ctx, func() (pgx.Tx, error) {
+			return r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+		}, txfn)
+		if !postgres.IsSerializationFailure(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * 10 * time.Millisecond):
+		}
+	}
+
+	return err
+}
+
+func runTransaction(ctx context.Context, begin func() (pgx.Tx, error), txfn func(context.Context) error) error {
+	tx, err := begin()
 	if err != nil {
 		return err
 	}
 
 	tctx := context.WithValue(ctx, transactionCtxKey, tx)
 	if err = txfn(tctx); err != nil {
-		return tx.Rollback(ctx)
+		if rerr := tx.Rollback(ctx); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+
+		return err
 	}
 
 	return tx.Commit(ctx)
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/hickar/crtex_test_assignment/events"
+
+	"github.com/hickar/crtex_test_assignment/account/internal/domain"
+)
+
+// MemoryAccountRepository - in-memory реализация domain.AccountRepository.
+// Транзакции выполняются последовательно, при ошибке состояние
+// откатывается к снимку, сделанному перед началом транзакции.
+type MemoryAccountRepository struct {
+	txMu sync.Mutex
+
+	mu           sync.RWMutex
+	accounts     map[int64]domain.Account
+	outbox       []events.AccountOrderPaymentEvent
+	transactions []domain.Transaction
+	changes      []domain.StatusChange
+}
+
+func NewMemoryAccountRepository() *MemoryAccountRepository {
+	return &MemoryAccountRepository{
+		accounts: make(map[int64]domain.Account),
+	}
+}
+
+func (r *MemoryAccountRepository) CreateAccount(_ context.Context, account domain.Account) (domain.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[account.UserID]; ok {
+		return account, domain.ErrAccountExists
+	}
+
+	account.ID = int64(len(r.accounts)) + 1
+	if account.Status == "" {
+		account.Status = domain.AccountStatusActive
+	}
+	r.accounts[account.UserID] = account
+
+	return account, nil
+}
+
+func (r *MemoryAccountRepository) AccountEventWithOrderEventIDExists(_ context.Context, orderEventID int64) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, event := range r.outbox {
+		if event.OrderEventID == orderEventID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *MemoryAccountRepository) GetAccountByUserID(_ context.Context, userID int64) (domain.Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, ok := r.accounts[userID]
+	if !ok {
+		return domain.Account{}, domain.ErrNotFound
+	}
+
+	return account, nil
+}
+
+func (r *MemoryAccountRepository) UpdateAccount(_ context.Context, account domain.Account) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for userID, existing := range r.accounts {
+		if existing.ID == account.ID {
+			existing.AmountCents = account.AmountCents
+			r.accounts[userID] = existing
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryAccountRepository) UpdateAccountStatus(_ context.Context, accountID int64, status domain.AccountStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for userID, existing := range r.accounts {
+		if existing.ID == accountID {
+			existing.Status = status
+			r.accounts[userID] = existing
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryAccountRepository) CreateStatusChange(_ context.Context, change domain.StatusChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change.ID = int64(len(r.changes)) + 1
+	change.CreatedAt = time.Now()
+	r.changes = append(r.changes, change)
+
+	return nil
+}
+
+func (r *MemoryAccountRepository) ListStatusChanges(_ context.Context, accountID int64) ([]domain.StatusChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var changes []domain.StatusChange
+	for _, change := range r.changes {
+		if change.AccountID == accountID {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+func (r *MemoryAccountRepository) UpdateSpendingPolicy(_ context.Context, accountID int64, policy domain.SpendingPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for userID, existing := range r.accounts {
+		if existing.ID == accountID {
+			existing.Policy = policy
+			r.accounts[userID] = existing
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryAccountRepository) SpentCents(_ context.Context, accountID int64, since time.Time) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var spent int64
+	for _, transaction := range r.transactions {
+		if transaction.AccountID != accountID || transaction.CreatedAt.Before(since) {
+			continue
+		}
+		if transaction.Type == domain.TransactionPayment || transaction.Type == domain.TransactionRefund {
+			spent -= transaction.AmountCents
+		}
+	}
+
+	return spent, nil
+}
+
+func (r *MemoryAccountRepository) CreateAccountEvent(_ context.Context, event events.AccountOrderPaymentEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.ID = int64(len(r.outbox)) + 1
+	r.outbox = append(r.outbox, event)
+
+	return nil
+}
+
+func (r *MemoryAccountRepository) CreateTransaction(_ context.Context, transaction domain.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transaction.ID = int64(len(r.transactions)) + 1
+	transaction.CreatedAt = time.Now()
+	r.transactions = append(r.transactions, transaction)
+
+	return nil
+}
+
+func (r *MemoryAccountRepository) ListTransactions(_ context.Context, filter domain.TransactionFilter) ([]domain.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var transactions []domain.Transaction
+	for i := range r.transactions {
+		// Операции хранятся в порядке возрастания ID.
+		transaction := r.transactions[i]
+		if filter.Descending {
+			transaction = r.transactions[len(r.transactions)-1-i]
+		}
+
+		switch {
+		case transaction.AccountID != filter.AccountID:
+			continue
+		case !filter.From.IsZero() && transaction.CreatedAt.Before(filter.From):
+			continue
+		case !filter.To.IsZero() && !transaction.CreatedAt.Before(filter.To):
+			continue
+		case filter.AfterID != 0 && !filter.Descending && transaction.ID <= filter.AfterID:
+			continue
+		case filter.AfterID != 0 && filter.Descending && transaction.ID >= filter.AfterID:
+			continue
+		}
+
+		transactions = append(transactions, transaction)
+		if filter.Limit > 0 && len(transactions) == filter.Limit {
+			break
+		}
+	}
+
+	return transactions, nil
+}
+
+type memoryTxContextKey struct{}
+
+func (r *MemoryAccountRepository) WithinTransaction(ctx context.Context, txfn func(context.Context) error) error {
+	// Вложенная транзакция выполняется под блокировкой внешней
+	// и при ошибке откатывает только свои изменения.
+	if ctx.Value(memoryTxContextKey{}) == nil {
+		r.txMu.Lock()
+		defer r.txMu.Unlock()
+
+		ctx = context.WithValue(ctx, memoryTxContextKey{}, struct{}{})
+	}
+
+	r.mu.RLock()
+	accounts := maps.Clone(r.accounts)
+	outboxLen := len(r.outbox)
+	transactionsLen := len(r.transactions)
+	changesLen := len(r.changes)
+	r.mu.RUnlock()
+
+	if err := txfn(ctx); err != nil {
+		r.mu.Lock()
+		r.accounts = accounts
+		r.outbox = r.outbox[:outboxLen]
+		r.transactions = r.transactions[:transactionsLen]
+		r.changes = r.changes[:changesLen]
+		r.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// OutboxEvents возвращает события outbox'а с идентификатором больше afterID.
+// События незавершённой транзакции не возвращаются.
+func (r *MemoryAccountRepository) OutboxEvents(afterID int64) []events.AccountOrderPaymentEvent {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if afterID < 0 || afterID >= int64(len(r.outbox)) {
+		return nil
+	}
+
+	return append([]events.AccountOrderPaymentEvent(nil), r.outbox[afterID:]...)
+}
@@ -1,9 +1,17 @@
 CREATE TYPE account_order_event_status AS ENUM ('PAID', 'CANCELED');
 
+CREATE TYPE account_status AS ENUM ('ACTIVE', 'FROZEN', 'CLOSED');
+
 CREATE TABLE IF NOT EXISTS accounts (
   id BIGSERIAL PRIMARY KEY,
   user_id BIGINT UNIQUE NOT NULL,
-  amount_cents BIGINT NOT NULL
+  amount_cents BIGINT NOT NULL,
+  status ACCOUNT_STATUS NOT NULL DEFAULT 'ACTIVE',
+  -- Политика списаний: нулевые лимиты не ограничивают списания.
+  overdraft_limit_cents BIGINT NOT NULL DEFAULT 0 CHECK (overdraft_limit_cents >= 0),
+  max_order_amount_cents BIGINT NOT NULL DEFAULT 0 CHECK (max_order_amount_cents >= 0),
+  daily_limit_cents BIGINT NOT NULL DEFAULT 0 CHECK (daily_limit_cents >= 0),
+  monthly_limit_cents BIGINT NOT NULL DEFAULT 0 CHECK (monthly_limit_cents >= 0)
 );
 
 CREATE INDEX account_user_id_idx ON accounts (user_id);
@@ -15,12 +23,45 @@ INSERT INTO accounts (user_id, amount_cents)
     (4, 400000),
     (5, 500000);
 
+-- Журнал изменений статусов счетов.
+CREATE TABLE IF NOT EXISTS account_status_changes (
+  id BIGSERIAL PRIMARY KEY,
+  account_id BIGINT NOT NULL REFERENCES accounts ON DELETE CASCADE,
+  from_status ACCOUNT_STATUS NOT NULL,
+  to_status ACCOUNT_STATUS NOT NULL,
+  actor TEXT NOT NULL,
+  reason TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX account_status_changes_account_id_idx ON account_status_changes (account_id, id);
+
+CREATE TYPE account_transaction_type AS ENUM ('PAYMENT', 'REFUND', 'DEPOSIT');
+
+CREATE TABLE IF NOT EXISTS account_transactions (
+  id BIGSERIAL PRIMARY KEY,
+  account_id BIGINT NOT NULL REFERENCES accounts ON DELETE CASCADE,
+  type ACCOUNT_TRANSACTION_TYPE NOT NULL,
+  amount_cents BIGINT NOT NULL,
+  balance_cents BIGINT NOT NULL,
+  order_id BIGINT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX account_transactions_account_id_idx ON account_transactions (account_id, id);
+CREATE INDEX account_transactions_created_at_idx ON account_transactions (account_id, created_at);
+
+INSERT INTO account_transactions (account_id, type, amount_cents, balance_cents)
+  SELECT id, 'DEPOSIT', amount_cents, amount_cents FROM accounts;
+
 CREATE TABLE IF NOT EXISTS account_events (
   id BIGSERIAL PRIMARY KEY,
   account_id BIGINT REFERENCES accounts ON DELETE SET NULL,
   order_id BIGINT,
   order_event_id BIGINT UNIQUE NOT NULL,
-  status ACCOUNT_ORDER_EVENT_STATUS NOT NULL
+  status ACCOUNT_ORDER_EVENT_STATUS NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
 ALTER TABLE account_events REPLICA IDENTITY FULL;
@@ -0,0 +1,303 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v4.25.3
+// source: proto/account.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AccountClient is the client API for Account service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AccountClient interface {
+	// ListTransactions возвращает операции по счёту от новых к старым.
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	// GetStatement возвращает выписку по счёту за период.
+	GetStatement(ctx context.Context, in *GetStatementRequest, opts ...grpc.CallOption) (*GetStatementResponse, error)
+	// GetSpendingPolicy возвращает ограничения списаний со счёта.
+	GetSpendingPolicy(ctx context.Context, in *GetSpendingPolicyRequest, opts ...grpc.CallOption) (*SpendingPolicy, error)
+	// UpdateSpendingPolicy заменяет ограничения списаний со счёта. Доступен
+	// только администраторам.
+	UpdateSpendingPolicy(ctx context.Context, in *UpdateSpendingPolicyRequest, opts ...grpc.CallOption) (*SpendingPolicy, error)
+	// UpdateAccountStatus блокирует, разблокирует или закрывает счёт. Доступен
+	// только администраторам.
+	UpdateAccountStatus(ctx context.Context, in *UpdateAccountStatusRequest, opts ...grpc.CallOption) (*UpdateAccountStatusResponse, error)
+	// ListAccountStatusChanges возвращает журнал изменений статуса счёта. Доступен
+	// только администраторам.
+	ListAccountStatusChanges(ctx context.Context, in *ListAccountStatusChangesRequest, opts ...grpc.CallOption) (*ListAccountStatusChangesResponse, error)
+}
+
+type accountClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAccountClient(cc grpc.ClientConnInterface) AccountClient {
+	return &accountClient{cc}
+}
+
+func (c *accountClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, "/account.Account/ListTransactions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountClient) GetStatement(ctx context.Context, in *GetStatementRequest, opts ...grpc.CallOption) (*GetStatementResponse, error) {
+	out := new(GetStatementResponse)
+	err := c.cc.Invoke(ctx, "/account.Account/GetStatement", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountClient) GetSpendingPolicy(ctx context.Context, in *GetSpendingPolicyRequest, opts ...grpc.CallOption) (*SpendingPolicy, error) {
+	out := new(SpendingPolicy)
+	err := c.cc.Invoke(ctx, "/account.Account/GetSpendingPolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountClient) UpdateSpendingPolicy(ctx context.Context, in *UpdateSpendingPolicyRequest, opts ...grpc.CallOption) (*SpendingPolicy, error) {
+	out := new(SpendingPolicy)
+	err := c.cc.Invoke(ctx, "/account.Account/UpdateSpendingPolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountClient) UpdateAccountStatus(ctx context.Context, in *UpdateAccountStatusRequest, opts ...grpc.CallOption) (*UpdateAccountStatusResponse, error) {
+	out := new(UpdateAccountStatusResponse)
+	err := c.cc.Invoke(ctx, "/account.Account/UpdateAccountStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountClient) ListAccountStatusChanges(ctx context.Context, in *ListAccountStatusChangesRequest, opts ...grpc.CallOption) (*ListAccountStatusChangesResponse, error) {
+	out := new(ListAccountStatusChangesResponse)
+	err := c.cc.Invoke(ctx, "/account.Account/ListAccountStatusChanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AccountServer is the server API for Account service.
+// All implementations must embed UnimplementedAccountServer
+// for forward compatibility
+type AccountServer interface {
+	// ListTransactions возвращает операции по счёту от новых к старым.
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	// GetStatement возвращает выписку по счёту за период.
+	GetStatement(context.Context, *GetStatementRequest) (*GetStatementResponse, error)
+	// GetSpendingPolicy возвращает ограничения списаний со счёта.
+	GetSpendingPolicy(context.Context, *GetSpendingPolicyRequest) (*SpendingPolicy, error)
+	// UpdateSpendingPolicy заменяет ограничения списаний со счёта. Доступен
+	// только администраторам.
+	UpdateSpendingPolicy(context.Context, *UpdateSpendingPolicyRequest) (*SpendingPolicy, error)
+	// UpdateAccountStatus блокирует, разблокирует или закрывает счёт. Доступен
+	// только администраторам.
+	UpdateAccountStatus(context.Context, *UpdateAccountStatusRequest) (*UpdateAccountStatusResponse, error)
+	// ListAccountStatusChanges возвращает журнал изменений статуса счёта. Доступен
+	// только администраторам.
+	ListAccountStatusChanges(context.Context, *ListAccountStatusChangesRequest) (*ListAccountStatusChangesResponse, error)
+	mustEmbedUnimplementedAccountServer()
+}
+
+// UnimplementedAccountServer must be embedded to have forward compatible implementations.
+type UnimplementedAccountServer struct {
+}
+
+func (UnimplementedAccountServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedAccountServer) GetStatement(context.Context, *GetStatementRequest) (*GetStatementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatement not implemented")
+}
+func (UnimplementedAccountServer) GetSpendingPolicy(context.Context, *GetSpendingPolicyRequest) (*SpendingPolicy, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSpendingPolicy not implemented")
+}
+func (UnimplementedAccountServer) UpdateSpendingPolicy(context.Context, *UpdateSpendingPolicyRequest) (*SpendingPolicy, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSpendingPolicy not implemented")
+}
+func (UnimplementedAccountServer) UpdateAccountStatus(context.Context, *UpdateAccountStatusRequest) (*UpdateAccountStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAccountStatus not implemented")
+}
+func (UnimplementedAccountServer) ListAccountStatusChanges(context.Context, *ListAccountStatusChangesRequest) (*ListAccountStatusChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccountStatusChanges not implemented")
+}
+func (UnimplementedAccountServer) mustEmbedUnimplementedAccountServer() {}
+
+// UnsafeAccountServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AccountServer will
+// result in compilation errors.
+type UnsafeAccountServer interface {
+	mustEmbedUnimplementedAccountServer()
+}
+
+func RegisterAccountServer(s grpc.ServiceRegistrar, srv AccountServer) {
+	s.RegisterService(&Account_ServiceDesc, srv)
+}
+
+func _Account_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.Account/ListTransactions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Account_GetStatement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServer).GetStatement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.Account/GetStatement",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServer).GetStatement(ctx, req.(*GetStatementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Account_GetSpendingPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSpendingPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServer).GetSpendingPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.Account/GetSpendingPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServer).GetSpendingPolicy(ctx, req.(*GetSpendingPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Account_UpdateSpendingPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSpendingPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServer).UpdateSpendingPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.Account/UpdateSpendingPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServer).UpdateSpendingPolicy(ctx, req.(*UpdateSpendingPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Account_UpdateAccountStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAccountStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServer).UpdateAccountStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.Account/UpdateAccountStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServer).UpdateAccountStatus(ctx, req.(*UpdateAccountStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Account_ListAccountStatusChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAccountStatusChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServer).ListAccountStatusChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/account.Account/ListAccountStatusChanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServer).ListAccountStatusChanges(ctx, req.(*ListAccountStatusChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Account_ServiceDesc is the grpc.ServiceDesc for Account service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Account_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "account.Account",
+	HandlerType: (*AccountServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTransactions",
+			Handler:    _Account_ListTransactions_Handler,
+		},
+		{
+			MethodName: "GetStatement",
+			Handler:    _Account_GetStatement_Handler,
+		},
+		{
+			MethodName: "GetSpendingPolicy",
+			Handler:    _Account_GetSpendingPolicy_Handler,
+		},
+		{
+			MethodName: "UpdateSpendingPolicy",
+			Handler:    _Account_UpdateSpendingPolicy_Handler,
+		},
+		{
+			MethodName: "UpdateAccountStatus",
+			Handler:    _Account_UpdateAccountStatus_Handler,
+		},
+		{
+			MethodName: "ListAccountStatusChanges",
+			Handler:    _Account_ListAccountStatusChanges_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/account.proto",
+}
@@ -0,0 +1,202 @@
+// Команда dev запускает сервисы заказов и счетов в одном процессе поверх
+// in-memory хранилищ и брокера. Внешние зависимости (Postgres, Kafka,
+// Kafka Connect) не требуются.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc"
+
+	accountinmem "github.com/hickar/crtex_test_assignment/account/inmemory"
+	"github.com/hickar/crtex_test_assignment/events"
+	orderinmem "github.com/hickar/crtex_test_assignment/order/inmemory"
+	"github.com/hickar/crtex_test_assignment/pkg/interceptors"
+	kconsumer "github.com/hickar/crtex_test_assignment/pkg/kafka/consumer"
+	"github.com/hickar/crtex_test_assignment/pkg/kafka/membroker"
+	"github.com/hickar/crtex_test_assignment/pkg/requestid"
+)
+
+const (
+	orderEventsTopic   = "orders.public.order_create_events"
+	accountEventsTopic = "accounts.public.account_events"
+)
+
+var (
+	port        = flag.Int("port", 8000, "Port of Order GRPC server. Defaults to 8000")
+	logLevel    = flag.String("log-level", "INFO", "Logging level. Defaults to INFO")
+	eventFormat = flag.String("event-format", events.ContentTypeJSON, "Content type of published events. Defaults to 'application/json'")
+	cloudEvents = flag.String("cloudevents", "", "CloudEvents binding of published events: 'binary', 'structured' or empty for legacy envelope")
+)
+
+func main() {
+	flag.Parse()
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid log level: %s\n", err)
+		os.Exit(1)
+	}
+
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: level,
+	})))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGABRT)
+	defer cancel()
+
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to open tcp connection on port %d: %s", *port, err))
+		os.Exit(1)
+	}
+
+	logger.Info(fmt.Sprintf("launching dev environment on port %d", *port))
+	if err = run(ctx, ln, *eventFormat, events.CloudEventMode(*cloudEvents), logger); err != nil && !errors.Is(err, context.Canceled) {
+		logger.Error(fmt.Sprintf("application stopped with error: %s", err))
+		os.Exit(1)
+	}
+
+	logger.Info("gracefully shutting down server")
+}
+
+func run(
+	ctx context.Context,
+	ln net.Listener,
+	eventFormat string,
+	ceMode events.CloudEventMode,
+	logger *slog.Logger,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	broker := membroker.NewBroker()
+	orders := orderinmem.NewOrderService()
+	accounts := accountinmem.NewAccountService()
+
+	// Те же счета, что создаются в account/migrations/init.sql.
+	for userID := int64(1); userID <= 5; userID++ {
+		if err := accounts.CreateAccount(ctx, userID, userID*100000); err != nil {
+			return fmt.Errorf("failed to seed accounts: %w", err)
+		}
+	}
+
+	// Реестр схем в dev-окружении не используется: события разбираются по заголовкам.
+	codec, err := events.NewCodec(nil, "")
+	if err != nil {
+		return err
+	}
+
+	orderRouter := kconsumer.NewTopicRouter()
+	orderRouterLogger := logger.With(slog.String("module", "order_kafka_router"))
+	orderRouter.Use(
+		kconsumer.RecoveryMiddleware(orderRouterLogger),
+		kconsumer.LoggerMiddleware(orderRouterLogger),
+		kconsumer.RequestIDMiddleware(),
+	)
+	orders.HandleAccountEvents(orderRouter, accountEventsTopic, codec)
+
+	accountRouter := kconsumer.NewTopicRouter()
+	accountRouterLogger := logger.With(slog.String("module", "account_kafka_router"))
+	accountRouter.Use(
+		kconsumer.RecoveryMiddleware(accountRouterLogger),
+		kconsumer.LoggerMiddleware(accountRouterLogger),
+		kconsumer.RequestIDMiddleware(),
+	)
+	accounts.HandleOrderEvents(accountRouter, orderEventsTopic, codec)
+
+	grpcServer := grpc.NewServer(interceptors.ServerOptions(interceptors.ChainConfiguration{
+		Logger:      logger.With(slog.String("module", "grpc_server")),
+		ErrorDomain: orderinmem.ErrorDomain,
+	})...)
+	orders.RegisterGRPC(grpcServer)
+
+	tasks := []func(context.Context) error{
+		(&outboxRelay[events.OrderCreatedEvent]{
+			broker:   broker,
+			topic:    orderEventsTopic,
+			interval: 50 * time.Millisecond,
+			fetch:    orders.OutboxEvents,
+			eventID:  func(e events.OrderCreatedEvent) int64 { return e.ID },
+			encode: func(e events.OrderCreatedEvent) ([]byte, []kafka.Header, error) {
+				if ceMode != "" {
+					return events.EncodeOrderCreatedCloudEvent(e, time.Now(), eventFormat, ceMode)
+				}
+				return events.EncodeOrderCreatedEvent(e, eventFormat)
+			},
+		}).Run,
+		(&outboxRelay[events.AccountOrderPaymentEvent]{
+			broker:   broker,
+			topic:    accountEventsTopic,
+			interval: 50 * time.Millisecond,
+			fetch:    accounts.OutboxEvents,
+			eventID:  func(e events.AccountOrderPaymentEvent) int64 { return e.ID },
+			encode: func(e events.AccountOrderPaymentEvent) ([]byte, []kafka.Header, error) {
+				if ceMode != "" {
+					return events.EncodeAccountOrderPaymentCloudEvent(e, time.Now(), eventFormat, ceMode)
+				}
+				return events.EncodeAccountOrderPaymentEvent(e, eventFormat)
+			},
+		}).Run,
+		newConsumer(broker, "account-service", orderEventsTopic, accountRouter, logger).Run,
+		newConsumer(broker, "order-service", accountEventsTopic, orderRouter, logger).Run,
+		func(context.Context) error {
+			return grpcServer.Serve(ln)
+		},
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(tasks))
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task func(context.Context) error) {
+			defer wg.Done()
+			errCh <- task(ctx)
+		}(task)
+	}
+
+	var stopErr error
+	select {
+	case <-ctx.Done():
+		stopErr = ctx.Err()
+	case stopErr = <-errCh:
+	}
+
+	cancel()
+	grpcServer.GracefulStop()
+	wg.Wait()
+
+	return stopErr
+}
+
+func newConsumer(
+	broker *membroker.Broker,
+	groupID, topic string,
+	router kconsumer.MessageRouter,
+	logger *slog.Logger,
+) *kconsumer.Consumer {
+	source := broker.NewReader(membroker.ReaderConfig{
+		GroupID:     groupID,
+		Topic:       topic,
+		StartOffset: kafka.FirstOffset,
+	})
+
+	return kconsumer.NewConsumerWithSource(
+		kconsumer.Configuration{
+			Logger: logger.With(slog.String("module", "kafka_consumer"), slog.String("group", groupID)),
+		},
+		source,
+		router,
+	)
+}
@@ -0,0 +1,111 @@
+//go:build unit_test
+
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/hickar/crtex_test_assignment/events"
+	"github.com/hickar/crtex_test_assignment/order/proto"
+)
+
+func TestOrderSaga(t *testing.T) {
+	encodings := []struct {
+		name   string
+		format string
+		ceMode events.CloudEventMode
+	}{
+		{name: "JSON", format: events.ContentTypeJSON},
+		{name: "Protobuf", format: events.ContentTypeProtobuf},
+		{name: "CloudEventsBinaryJSON", format: events.ContentTypeJSON, ceMode: events.CloudEventModeBinary},
+		{name: "CloudEventsStructuredProtobuf", format: events.ContentTypeProtobuf, ceMode: events.CloudEventModeStructured},
+	}
+
+	for _, enc := range encodings {
+		t.Run(enc.name, func(t *testing.T) {
+			testOrderSaga(t, startDevEnvironment(t, enc.format, enc.ceMode))
+		})
+	}
+}
+
+func testOrderSaga(t *testing.T, client proto.OrderClient) {
+
+	tests := []struct {
+		name     string
+		request  *proto.CreateOrderRequest
+		expected proto.Status
+	}{
+		{
+			name:     "Paid",
+			request:  &proto.CreateOrderRequest{UserId: 1, Amount: 10000},
+			expected: proto.Status_PAID,
+		},
+		{
+			name:     "Canceled_NonExistentUser",
+			request:  &proto.CreateOrderRequest{UserId: 100, Amount: 10000},
+			expected: proto.Status_CANCELED,
+		},
+		{
+			name:     "Canceled_Overdraft",
+			request:  &proto.CreateOrderRequest{UserId: 2, Amount: 10000000},
+			expected: proto.Status_CANCELED,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			createResp, err := client.CreateOrder(ctx, tt.request)
+			require.NoError(t, err)
+			require.NotZero(t, createResp.TransactionId)
+
+			var order *proto.GetOrderResponse
+			require.Eventually(t, func() bool {
+				order, err = client.GetOrder(ctx, &proto.GetOrderRequest{TransactionId: createResp.TransactionId})
+				return err == nil && order.Status != proto.Status_CREATED
+			}, 5*time.Second, 20*time.Millisecond)
+
+			assert.Equal(t, tt.expected, order.Status)
+			assert.Equal(t, tt.request.Amount, order.Amount)
+			assert.Equal(t, tt.request.UserId, order.ClientId)
+		})
+	}
+}
+
+func startDevEnvironment(t *testing.T, eventFormat string, ceMode events.CloudEventMode) proto.OrderClient {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, ln, eventFormat, ceMode, logger)
+	}()
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	})
+
+	return proto.NewOrderClient(conn)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/hickar/crtex_test_assignment/pkg/kafka/membroker"
+)
+
+// outboxRelay заменяет Debezium: периодически вычитывает новые записи outbox'а
+// и публикует их в брокер.
+type outboxRelay[T any] struct {
+	broker   *membroker.Broker
+	topic    string
+	interval time.Duration
+	fetch    func(afterID int64) []T
+	eventID  func(T) int64
+	encode   func(T) ([]byte, []kafka.Header, error)
+}
+
+func (r *outboxRelay[T]) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	var lastID int64
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		for _, event := range r.fetch(lastID) {
+			value, headers, err := r.encode(event)
+			if err != nil {
+				return err
+			}
+
+			id := r.eventID(event)
+			err = r.broker.Produce(ctx, kafka.Message{
+				Topic:   r.topic,
+				Key:     []byte(strconv.FormatInt(id, 10)),
+				Value:   value,
+				Headers: headers,
+			})
+			if err != nil {
+				return err
+			}
+
+			lastID = id
+		}
+	}
+}
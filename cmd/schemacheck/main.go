@@ -0,0 +1,96 @@
+// Команда schemacheck проверяет совместимость схем событий с последними
+// версиями, зарегистрированными в реестре, и при необходимости регистрирует их.
+// Завершается с кодом 1, если хотя бы одна схема несовместима.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/hickar/crtex_test_assignment/events"
+	"github.com/hickar/crtex_test_assignment/pkg/schemaregistry"
+)
+
+var (
+	registryURL = flag.String("registry", os.Getenv("SCHEMA_REGISTRY_URL"), "Schema registry URL. Defaults to $SCHEMA_REGISTRY_URL")
+	schemaType  = flag.String("type", string(schemaregistry.SchemaTypeProtobuf), "Schema type: PROTOBUF or JSON. Defaults to PROTOBUF")
+	register    = flag.Bool("register", false, "Register schemas after successful compatibility check")
+)
+
+func main() {
+	flag.Parse()
+
+	if *registryURL == "" {
+		fmt.Fprintln(os.Stderr, "schema registry URL is not set")
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	registry := schemaregistry.NewClient(schemaregistry.Configuration{
+		URL:      *registryURL,
+		Username: os.Getenv("SCHEMA_REGISTRY_USERNAME"),
+		Password: os.Getenv("SCHEMA_REGISTRY_PASSWORD"),
+	})
+
+	compatible, err := run(ctx, registry, schemaregistry.SchemaType(*schemaType), *register)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if !compatible {
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, registry *schemaregistry.Client, schemaType schemaregistry.SchemaType, register bool) (bool, error) {
+	codec, err := events.NewCodec(registry, schemaType)
+	if err != nil {
+		return false, err
+	}
+
+	schemas := codec.Schemas()
+	subjects := make([]string, 0, len(schemas))
+	for subject := range schemas {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+
+	allCompatible := true
+	for _, subject := range subjects {
+		compatible, messages, err := registry.CheckCompatibility(ctx, subject, schemas[subject])
+		if err != nil {
+			return false, err
+		}
+
+		if !compatible {
+			allCompatible = false
+			fmt.Printf("%s: INCOMPATIBLE\n", subject)
+			for _, message := range messages {
+				fmt.Printf("  %s\n", message)
+			}
+			continue
+		}
+		fmt.Printf("%s: OK\n", subject)
+	}
+
+	if !allCompatible || !register {
+		return allCompatible, nil
+	}
+
+	for _, subject := range subjects {
+		id, err := registry.Register(ctx, subject, schemas[subject])
+		if err != nil {
+			return false, err
+		}
+		fmt.Printf("%s: registered with id %d\n", subject, id)
+	}
+
+	return true, nil
+}
@@ -33,21 +33,46 @@ curl -i -X POST \
     "slot.name": "order_events_replication",
     "publication.name": "order_events_publication",
     "publication.autocreate.mode": "filtered",
-    "transforms": "unwrap,PartitionRouting",
+    "transforms": "unwrap,ceAttributes,ceSpecVersion,ceSource,ceType,ceContentType,PartitionRouting",
     "transforms.unwrap.type": "io.debezium.transforms.ExtractNewRecordState",
     "transforms.unwrap.add.fields": "op,table,lsn,source.ts_ms",
     "transforms.unwrap.delete.handling.mode": "rewrite",
     "transforms.unwrap.drop.tombstones": "true",
+    "transforms.ceAttributes.type": "org.apache.kafka.connect.transforms.HeaderFrom$Value",
+    "transforms.ceAttributes.fields": "id,order_id,created_at",
+    "transforms.ceAttributes.headers": "ce_id,ce_subject,ce_time",
+    "transforms.ceAttributes.operation": "copy",
+    "transforms.ceAttributes.predicate": "eventsTable",
+    "transforms.ceSpecVersion.type": "org.apache.kafka.connect.transforms.InsertHeader",
+    "transforms.ceSpecVersion.header": "ce_specversion",
+    "transforms.ceSpecVersion.value.literal": "1.0",
+    "transforms.ceSpecVersion.predicate": "eventsTable",
+    "transforms.ceSource.type": "org.apache.kafka.connect.transforms.InsertHeader",
+    "transforms.ceSource.header": "ce_source",
+    "transforms.ceSource.value.literal": "/order-service",
+    "transforms.ceSource.predicate": "eventsTable",
+    "transforms.ceType.type": "org.apache.kafka.connect.transforms.InsertHeader",
+    "transforms.ceType.header": "ce_type",
+    "transforms.ceType.value.literal": "crtex.order.created",
+    "transforms.ceType.predicate": "eventsTable",
+    "transforms.ceContentType.type": "org.apache.kafka.connect.transforms.InsertHeader",
+    "transforms.ceContentType.header": "content-type",
+    "transforms.ceContentType.value.literal": "application/json",
+    "transforms.ceContentType.predicate": "eventsTable",
     "transforms.PartitionRouting.type": "io.debezium.transforms.partitions.PartitionRouting",
     "transforms.PartitionRouting.partition.payload.fields": "change.id",
     "transforms.PartitionRouting.partition.topic.num": 1,
     "message.key.columns": "public.order_create_events:id",
     "transforms.PartitionRouting.predicate": "allTopic",
-    "predicates": "allTopic",
+    "predicates": "allTopic,eventsTable",
     "predicates.allTopic.type": "org.apache.kafka.connect.transforms.predicates.TopicNameMatches",
     "predicates.allTopic.pattern": ".*-events",
+    "predicates.eventsTable.type": "org.apache.kafka.connect.transforms.predicates.TopicNameMatches",
+    "predicates.eventsTable.pattern": ".*\\.order_create_events",
     "key.converter" : "org.apache.kafka.connect.storage.StringConverter",
     "key.converter.schemas.enable": false,
+    "value.converter": "org.apache.kafka.connect.json.JsonConverter",
+    "value.converter.schemas.enable": false,
     "tombstones.on.delete": false,
     "schema.history.internal.kafka.topic": "order_events_history",
     "null.handling.mode": "keep"
@@ -78,21 +103,46 @@ curl -i -X POST \
     "slot.name": "account_events_replication",
     "publication.name": "account_events_publication",
     "publication.autocreate.mode": "filtered",
-    "transforms": "unwrap,PartitionRouting",
+    "transforms": "unwrap,ceAttributes,ceSpecVersion,ceSource,ceType,ceContentType,PartitionRouting",
     "transforms.unwrap.type": "io.debezium.transforms.ExtractNewRecordState",
     "transforms.unwrap.add.fields": "op,table,lsn,source.ts_ms",
     "transforms.unwrap.delete.handling.mode": "rewrite",
     "transforms.unwrap.drop.tombstones": "true",
+    "transforms.ceAttributes.type": "org.apache.kafka.connect.transforms.HeaderFrom$Value",
+    "transforms.ceAttributes.fields": "id,order_id,created_at",
+    "transforms.ceAttributes.headers": "ce_id,ce_subject,ce_time",
+    "transforms.ceAttributes.operation": "copy",
+    "transforms.ceAttributes.predicate": "eventsTable",
+    "transforms.ceSpecVersion.type": "org.apache.kafka.connect.transforms.InsertHeader",
+    "transforms.ceSpecVersion.header": "ce_specversion",
+    "transforms.ceSpecVersion.value.literal": "1.0",
+    "transforms.ceSpecVersion.predicate": "eventsTable",
+    "transforms.ceSource.type": "org.apache.kafka.connect.transforms.InsertHeader",
+    "transforms.ceSource.header": "ce_source",
+    "transforms.ceSource.value.literal": "/account-service",
+    "transforms.ceSource.predicate": "eventsTable",
+    "transforms.ceType.type": "org.apache.kafka.connect.transforms.InsertHeader",
+    "transforms.ceType.header": "ce_type",
+    "transforms.ceType.value.literal": "crtex.account.order_payment",
+    "transforms.ceType.predicate": "eventsTable",
+    "transforms.ceContentType.type": "org.apache.kafka.connect.transforms.InsertHeader",
+    "transforms.ceContentType.header": "content-type",
+    "transforms.ceContentType.value.literal": "application/json",
+    "transforms.ceContentType.predicate": "eventsTable",
     "transforms.PartitionRouting.type": "io.debezium.transforms.partitions.PartitionRouting",
     "transforms.PartitionRouting.partition.payload.fields": "change.id",
     "transforms.PartitionRouting.partition.topic.num": 1,
     "message.key.columns": "public.account_events:id",
     "transforms.PartitionRouting.predicate": "allTopic",
-    "predicates": "allTopic",
+    "predicates": "allTopic,eventsTable",
     "predicates.allTopic.type": "org.apache.kafka.connect.transforms.predicates.TopicNameMatches",
     "predicates.allTopic.pattern": ".*-events",
+    "predicates.eventsTable.type": "org.apache.kafka.connect.transforms.predicates.TopicNameMatches",
+    "predicates.eventsTable.pattern": ".*\\.account_events",
     "key.converter" : "org.apache.kafka.connect.storage.StringConverter",
     "key.converter.schemas.enable": false,
+    "value.converter": "org.apache.kafka.connect.json.JsonConverter",
+    "value.converter.schemas.enable": false,
     "tombstones.on.delete": false,
     "schema.history.internal.kafka.topic": "account_events_history",
     "null.handling.mode": "keep"
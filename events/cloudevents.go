@@ -0,0 +1,254 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Атрибуты CloudEvents 1.0 и их представление в Kafka Protocol Binding.
+const (
+	CloudEventsSpecVersion = "1.0"
+
+	// ContentTypeCloudEventsJSON - content-type сообщения в structured режиме:
+	// атрибуты и данные события передаются одним JSON-документом.
+	ContentTypeCloudEventsJSON = "application/cloudevents+json"
+
+	headerCloudEventPrefix = "ce_"
+
+	SourceOrderService   = "/order-service"
+	SourceAccountService = "/account-service"
+	SourceUserService    = "/user-service"
+
+	EventTypeOrderCreated        = "crtex.order.created"
+	EventTypeAccountOrderPayment = "crtex.account.order_payment"
+	EventTypeUser                = "crtex.user.lifecycle"
+)
+
+var (
+	ErrInvalidCloudEvent         = errors.New("invalid cloud event")
+	ErrUnexpectedEventType       = errors.New("unexpected cloud event type")
+	ErrUnsupportedCloudEventMode = errors.New("unsupported cloud event mode")
+)
+
+// CloudEventMode определяет способ передачи события в Kafka.
+type CloudEventMode string
+
+const (
+	// CloudEventModeBinary - атрибуты передаются в заголовках ce_*, а значение
+	// сообщения содержит только данные события.
+	CloudEventModeBinary     CloudEventMode = "binary"
+	CloudEventModeStructured CloudEventMode = "structured"
+)
+
+// CloudEvent - контекстные атрибуты события.
+type CloudEvent struct {
+	ID      string
+	Source  string
+	Type    string
+	Subject string
+	Time    time.Time
+}
+
+type structuredCloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            *time.Time      `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// EncodeOrderCreatedCloudEvent кодирует событие в формате CloudEvents.
+// Данные события кодируются согласно contentType без legacy-конверта.
+func EncodeOrderCreatedCloudEvent(
+	event OrderCreatedEvent,
+	eventTime time.Time,
+	contentType string,
+	mode CloudEventMode,
+) ([]byte, []kafka.Header, error) {
+	ce := CloudEvent{
+		ID:      strconv.FormatInt(event.ID, 10),
+		Source:  SourceOrderService,
+		Type:    EventTypeOrderCreated,
+		Subject: strconv.FormatInt(event.OrderID, 10),
+		Time:    eventTime,
+	}
+
+	return encodeCloudEvent(ce, event, orderCreatedEventToProto(event), contentType, mode)
+}
+
+// EncodeAccountOrderPaymentCloudEvent кодирует событие в формате CloudEvents.
+// Данные события кодируются согласно contentType без legacy-конверта.
+func EncodeAccountOrderPaymentCloudEvent(
+	event AccountOrderPaymentEvent,
+	eventTime time.Time,
+	contentType string,
+	mode CloudEventMode,
+) ([]byte, []kafka.Header, error) {
+	ce := CloudEvent{
+		ID:      strconv.FormatInt(event.ID, 10),
+		Source:  SourceAccountService,
+		Type:    EventTypeAccountOrderPayment,
+		Subject: strconv.FormatInt(event.OrderID, 10),
+		Time:    eventTime,
+	}
+
+	return encodeCloudEvent(ce, event, accountOrderPaymentEventToProto(event), contentType, mode)
+}
+
+// ParseCloudEvent возвращает атрибуты события, если сообщение передано
+// в формате CloudEvents в любом из режимов.
+func ParseCloudEvent(message *kafka.Message) (CloudEvent, bool, error) {
+	ce, _, _, ok, err := parseCloudEvent(message)
+	return ce, ok, err
+}
+
+func encodeCloudEvent[T any](
+	ce CloudEvent,
+	event T,
+	pb proto.Message,
+	contentType string,
+	mode CloudEventMode,
+) ([]byte, []kafka.Header, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch contentType {
+	case ContentTypeJSON:
+		data, err = json.Marshal(event)
+	case ContentTypeProtobuf:
+		data, err = proto.Marshal(pb)
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schemaVersion := kafka.Header{Key: HeaderSchemaVersion, Value: []byte(strconv.Itoa(SchemaVersion))}
+
+	switch mode {
+	case CloudEventModeBinary:
+		headers := []kafka.Header{
+			{Key: HeaderContentType, Value: []byte(contentType)},
+			schemaVersion,
+			{Key: headerCloudEventPrefix + "specversion", Value: []byte(CloudEventsSpecVersion)},
+			{Key: headerCloudEventPrefix + "id", Value: []byte(ce.ID)},
+			{Key: headerCloudEventPrefix + "source", Value: []byte(ce.Source)},
+			{Key: headerCloudEventPrefix + "type", Value: []byte(ce.Type)},
+		}
+		if ce.Subject != "" {
+			headers = append(headers, kafka.Header{Key: headerCloudEventPrefix + "subject", Value: []byte(ce.Subject)})
+		}
+		if !ce.Time.IsZero() {
+			headers = append(headers, kafka.Header{
+				Key:   headerCloudEventPrefix + "time",
+				Value: []byte(ce.Time.UTC().Format(time.RFC3339Nano)),
+			})
+		}
+
+		return data, headers, nil
+	case CloudEventModeStructured:
+		structured := structuredCloudEvent{
+			SpecVersion:     CloudEventsSpecVersion,
+			ID:              ce.ID,
+			Source:          ce.Source,
+			Type:            ce.Type,
+			Subject:         ce.Subject,
+			DataContentType: contentType,
+		}
+		if !ce.Time.IsZero() {
+			eventTime := ce.Time.UTC()
+			structured.Time = &eventTime
+		}
+		// Бинарные данные в JSON-формате CloudEvents передаются в base64.
+		if contentType == ContentTypeJSON {
+			structured.Data = data
+		} else {
+			structured.DataBase64 = data
+		}
+
+		value, err := json.Marshal(structured)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		headers := []kafka.Header{
+			{Key: HeaderContentType, Value: []byte(ContentTypeCloudEventsJSON)},
+			schemaVersion,
+		}
+
+		return value, headers, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedCloudEventMode, mode)
+	}
+}
+
+// parseCloudEvent возвращает атрибуты, данные события и их content-type.
+// ok равен false, если сообщение не является CloudEvent'ом.
+func parseCloudEvent(message *kafka.Message) (ce CloudEvent, data []byte, contentType string, ok bool, err error) {
+	if specVersion := headerValue(message, headerCloudEventPrefix+"specversion"); specVersion != "" {
+		ce = CloudEvent{
+			ID:      headerValue(message, headerCloudEventPrefix+"id"),
+			Source:  headerValue(message, headerCloudEventPrefix+"source"),
+			Type:    headerValue(message, headerCloudEventPrefix+"type"),
+			Subject: headerValue(message, headerCloudEventPrefix+"subject"),
+		}
+		if rawTime := headerValue(message, headerCloudEventPrefix+"time"); rawTime != "" {
+			if ce.Time, err = time.Parse(time.RFC3339Nano, rawTime); err != nil {
+				return ce, nil, "", true, fmt.Errorf("%w: invalid time %q", ErrInvalidCloudEvent, rawTime)
+			}
+		}
+
+		return ce, message.Value, headerValue(message, HeaderContentType), true, validateCloudEvent(specVersion, ce)
+	}
+
+	if !strings.HasPrefix(headerValue(message, HeaderContentType), ContentTypeCloudEventsJSON) {
+		return ce, nil, "", false, nil
+	}
+
+	var structured structuredCloudEvent
+	if err = json.Unmarshal(message.Value, &structured); err != nil {
+		return ce, nil, "", true, fmt.Errorf("%w: %w", ErrInvalidCloudEvent, err)
+	}
+
+	ce = CloudEvent{
+		ID:      structured.ID,
+		Source:  structured.Source,
+		Type:    structured.Type,
+		Subject: structured.Subject,
+	}
+	if structured.Time != nil {
+		ce.Time = *structured.Time
+	}
+
+	data = structured.Data
+	if len(structured.DataBase64) > 0 {
+		data = structured.DataBase64
+	}
+
+	return ce, data, structured.DataContentType, true, validateCloudEvent(structured.SpecVersion, ce)
+}
+
+func validateCloudEvent(specVersion string, ce CloudEvent) error {
+	if specVersion != CloudEventsSpecVersion {
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidCloudEvent, specVersion)
+	}
+	if ce.ID == "" || ce.Source == "" || ce.Type == "" {
+		return fmt.Errorf("%w: id, source and type are required", ErrInvalidCloudEvent)
+	}
+
+	return nil
+}
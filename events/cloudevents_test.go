@@ -0,0 +1,161 @@
+//go:build unit_test
+
+package events
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var goldenEventTime = time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+func TestEncodeCloudEventBinary(t *testing.T) {
+	value, headers, err := EncodeOrderCreatedCloudEvent(goldenOrderCreatedEvent, goldenEventTime, ContentTypeProtobuf, CloudEventModeBinary)
+	require.NoError(t, err)
+	assert.Equal(t, goldenOrderCreatedProto, hex.EncodeToString(value))
+	assert.Equal(t, []kafka.Header{
+		{Key: HeaderContentType, Value: []byte(ContentTypeProtobuf)},
+		{Key: HeaderSchemaVersion, Value: []byte("1")},
+		{Key: "ce_specversion", Value: []byte("1.0")},
+		{Key: "ce_id", Value: []byte("1")},
+		{Key: "ce_source", Value: []byte(SourceOrderService)},
+		{Key: "ce_type", Value: []byte(EventTypeOrderCreated)},
+		{Key: "ce_subject", Value: []byte("42")},
+		{Key: "ce_time", Value: []byte("2024-05-01T12:30:00Z")},
+	}, headers)
+
+	value, _, err = EncodeOrderCreatedCloudEvent(goldenOrderCreatedEvent, goldenEventTime, ContentTypeJSON, CloudEventModeBinary)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"order_id":42,"user_id":7,"status":"CREATED","amount_cents":10000}`, string(value))
+}
+
+func TestEncodeCloudEventStructured(t *testing.T) {
+	value, headers, err := EncodeAccountOrderPaymentCloudEvent(goldenPaymentEvent, goldenEventTime, ContentTypeJSON, CloudEventModeStructured)
+	require.NoError(t, err)
+	assert.Equal(t, ContentTypeCloudEventsJSON, headerValue(&kafka.Message{Headers: headers}, HeaderContentType))
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "3",
+		"source": "/account-service",
+		"type": "crtex.account.order_payment",
+		"subject": "42",
+		"time": "2024-05-01T12:30:00Z",
+		"datacontenttype": "application/json",
+		"data": {"id":3,"order_event_id":1,"account_id":5,"order_id":42,"status":"PAID"}
+	}`, string(value))
+
+	_, _, err = EncodeAccountOrderPaymentCloudEvent(goldenPaymentEvent, goldenEventTime, ContentTypeJSON, "batch")
+	assert.ErrorIs(t, err, ErrUnsupportedCloudEventMode)
+}
+
+func TestDecodeCloudEvent(t *testing.T) {
+	encodings := []struct {
+		name        string
+		contentType string
+		mode        CloudEventMode
+	}{
+		{name: "BinaryJSON", contentType: ContentTypeJSON, mode: CloudEventModeBinary},
+		{name: "BinaryProtobuf", contentType: ContentTypeProtobuf, mode: CloudEventModeBinary},
+		{name: "StructuredJSON", contentType: ContentTypeJSON, mode: CloudEventModeStructured},
+		{name: "StructuredProtobuf", contentType: ContentTypeProtobuf, mode: CloudEventModeStructured},
+	}
+
+	for _, enc := range encodings {
+		t.Run(enc.name, func(t *testing.T) {
+			value, headers, err := EncodeOrderCreatedCloudEvent(goldenOrderCreatedEvent, goldenEventTime, enc.contentType, enc.mode)
+			require.NoError(t, err)
+			message := &kafka.Message{Value: value, Headers: headers}
+
+			event, err := DecodeOrderCreatedEvent(message)
+			require.NoError(t, err)
+			assert.Equal(t, goldenOrderCreatedEvent, event)
+
+			ce, ok, err := ParseCloudEvent(message)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, CloudEvent{
+				ID:      "1",
+				Source:  SourceOrderService,
+				Type:    EventTypeOrderCreated,
+				Subject: "42",
+				Time:    goldenEventTime,
+			}, ce)
+
+			_, err = DecodeAccountOrderPaymentEvent(message)
+			assert.ErrorIs(t, err, ErrUnexpectedEventType)
+		})
+	}
+}
+
+func TestDecodeCloudEvent_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		message kafka.Message
+	}{
+		{
+			name: "UnsupportedSpecVersion",
+			message: kafka.Message{
+				Value: []byte(`{"id":1}`),
+				Headers: []kafka.Header{
+					{Key: "ce_specversion", Value: []byte("0.3")},
+					{Key: "ce_id", Value: []byte("1")},
+					{Key: "ce_source", Value: []byte(SourceOrderService)},
+					{Key: "ce_type", Value: []byte(EventTypeOrderCreated)},
+				},
+			},
+		},
+		{
+			name: "MissingID",
+			message: kafka.Message{
+				Value:   []byte(`{"specversion":"1.0","source":"/order-service","type":"crtex.order.created","data":{}}`),
+				Headers: []kafka.Header{{Key: HeaderContentType, Value: []byte(ContentTypeCloudEventsJSON)}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeOrderCreatedEvent(&tt.message)
+			assert.ErrorIs(t, err, ErrInvalidCloudEvent)
+		})
+	}
+}
+
+// Kafka Connect публикует строку outbox'а без схемы, а атрибуты события
+// копирует в заголовки, см. deploy/debezium/load_connector_config.sh.
+func TestDecodeCloudEvent_Debezium(t *testing.T) {
+	message := kafka.Message{
+		Value: []byte(`{"id":1,"order_id":42,"user_id":7,"amount_cents":10000,` +
+			`"created_at":"2024-05-01T12:30:00.123456Z","__op":"c","__table":"order_create_events","__deleted":"false"}`),
+		Headers: []kafka.Header{
+			{Key: "ce_id", Value: []byte("1")},
+			{Key: "ce_subject", Value: []byte("42")},
+			{Key: "ce_time", Value: []byte("2024-05-01T12:30:00.123456Z")},
+			{Key: "ce_specversion", Value: []byte("1.0")},
+			{Key: "ce_source", Value: []byte(SourceOrderService)},
+			{Key: "ce_type", Value: []byte(EventTypeOrderCreated)},
+			{Key: HeaderContentType, Value: []byte(ContentTypeJSON)},
+		},
+	}
+
+	ce, ok, err := ParseCloudEvent(&message)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "42", ce.Subject)
+	assert.Equal(t, goldenEventTime.Add(123456*time.Microsecond), ce.Time)
+
+	event, err := DecodeOrderCreatedEvent(&message)
+	require.NoError(t, err)
+	assert.Equal(t, OrderCreatedEvent{ID: 1, OrderID: 42, UserID: 7, AmountCents: 10000}, event)
+}
+
+func TestParseCloudEvent_Legacy(t *testing.T) {
+	_, ok, err := ParseCloudEvent(&kafka.Message{Value: []byte(goldenOrderCreatedJSON)})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,197 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+
+	eventspb "github.com/hickar/crtex_test_assignment/events/proto"
+	"github.com/hickar/crtex_test_assignment/pkg/schemaregistry"
+)
+
+const (
+	HeaderContentType   = "content-type"
+	HeaderSchemaVersion = "schema-version"
+
+	// ContentTypeJSON - JSON-формат события. Вне CloudEvents событие вложено
+	// в поле payload, как в прежнем формате Debezium.
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+
+	// SchemaVersion - текущая версия схем событий из proto/events.proto.
+	SchemaVersion = 1
+)
+
+var (
+	ErrUnsupportedContentType   = errors.New("unsupported event content type")
+	ErrUnsupportedSchemaVersion = errors.New("unsupported event schema version")
+)
+
+type legacyEnvelope[T any] struct {
+	Payload T `json:"payload"`
+}
+
+func EncodeOrderCreatedEvent(event OrderCreatedEvent, contentType string) ([]byte, []kafka.Header, error) {
+	return encode(event, orderCreatedEventToProto(event), contentType)
+}
+
+// DecodeOrderCreatedEvent декодирует событие в любом из поддерживаемых форматов,
+// включая CloudEvents в binary и structured режимах. Сообщения без заголовка
+// content-type считаются legacy JSON-конвертом.
+func DecodeOrderCreatedEvent(message *kafka.Message) (OrderCreatedEvent, error) {
+	var pb eventspb.OrderCreatedEvent
+
+	event, isProto, err := decode[OrderCreatedEvent](message, EventTypeOrderCreated, &pb)
+	if err != nil || !isProto {
+		return event, err
+	}
+
+	return orderCreatedEventFromProto(&pb), nil
+}
+
+func EncodeAccountOrderPaymentEvent(event AccountOrderPaymentEvent, contentType string) ([]byte, []kafka.Header, error) {
+	return encode(event, accountOrderPaymentEventToProto(event), contentType)
+}
+
+// DecodeAccountOrderPaymentEvent декодирует событие в любом из поддерживаемых форматов,
+// включая CloudEvents в binary и structured режимах. Сообщения без заголовка
+// content-type считаются legacy JSON-конвертом.
+func DecodeAccountOrderPaymentEvent(message *kafka.Message) (AccountOrderPaymentEvent, error) {
+	var pb eventspb.AccountOrderPaymentEvent
+
+	event, isProto, err := decode[AccountOrderPaymentEvent](message, EventTypeAccountOrderPayment, &pb)
+	if err != nil || !isProto {
+		return event, err
+	}
+
+	return accountOrderPaymentEventFromProto(&pb), nil
+}
+
+func EncodeUserEvent(event UserEvent, contentType string) ([]byte, []kafka.Header, error) {
+	return encode(event, userEventToProto(event), contentType)
+}
+
+// DecodeUserEvent декодирует событие в любом из поддерживаемых форматов,
+// включая CloudEvents в binary и structured режимах. Сообщения без заголовка
+// content-type считаются legacy JSON-конвертом.
+func DecodeUserEvent(message *kafka.Message) (UserEvent, error) {
+	var pb eventspb.UserEvent
+
+	event, isProto, err := decode[UserEvent](message, EventTypeUser, &pb)
+	if err != nil || !isProto {
+		return event, err
+	}
+
+	return userEventFromProto(&pb), nil
+}
+
+func encode[T any](event T, pb proto.Message, contentType string) ([]byte, []kafka.Header, error) {
+	var (
+		value []byte
+		err   error
+	)
+
+	switch contentType {
+	case ContentTypeJSON:
+		value, err = json.Marshal(legacyEnvelope[T]{Payload: event})
+	case ContentTypeProtobuf:
+		value, err = proto.Marshal(pb)
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headers := []kafka.Header{
+		{Key: HeaderContentType, Value: []byte(contentType)},
+		{Key: HeaderSchemaVersion, Value: []byte(strconv.Itoa(SchemaVersion))},
+	}
+
+	return value, headers, nil
+}
+
+// decode разбирает JSON самостоятельно, а protobuf - в переданное сообщение pb,
+// сообщая об этом через isProto. Тип CloudEvent'а должен совпадать с eventType.
+func decode[T any](message *kafka.Message, eventType string, pb proto.Message) (event T, isProto bool, err error) {
+	if schemaregistry.IsFramed(message.Value) {
+		return event, false, ErrSchemaRegistryRequired
+	}
+
+	if err = checkSchemaVersion(message); err != nil {
+		return event, false, err
+	}
+
+	ce, data, dataContentType, ok, err := parseCloudEvent(message)
+	if err != nil {
+		return event, false, err
+	}
+	if ok {
+		if ce.Type != eventType {
+			return event, false, fmt.Errorf("%w: %q", ErrUnexpectedEventType, ce.Type)
+		}
+
+		return decodeData[T](data, dataContentType, pb)
+	}
+
+	switch contentType := headerValue(message, HeaderContentType); contentType {
+	case "", ContentTypeJSON:
+		var envelope legacyEnvelope[T]
+		if err = json.Unmarshal(message.Value, &envelope); err != nil {
+			return event, false, fmt.Errorf("failed to decode json event: %w", err)
+		}
+
+		return envelope.Payload, false, nil
+	default:
+		return decodeData[T](message.Value, contentType, pb)
+	}
+}
+
+// decodeData разбирает данные события без конверта. Отсутствие content-type
+// по спецификации CloudEvents означает JSON.
+func decodeData[T any](data []byte, contentType string, pb proto.Message) (event T, isProto bool, err error) {
+	switch contentType {
+	case "", ContentTypeJSON:
+		if err = json.Unmarshal(data, &event); err != nil {
+			return event, false, fmt.Errorf("failed to decode json event: %w", err)
+		}
+
+		return event, false, nil
+	case ContentTypeProtobuf:
+		if err = proto.Unmarshal(data, pb); err != nil {
+			return event, true, fmt.Errorf("failed to decode protobuf event: %w", err)
+		}
+
+		return event, true, nil
+	default:
+		return event, false, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+}
+
+func checkSchemaVersion(message *kafka.Message) error {
+	rawVersion := headerValue(message, HeaderSchemaVersion)
+	if rawVersion == "" {
+		return nil
+	}
+
+	version, err := strconv.Atoi(rawVersion)
+	if err != nil || version < 1 || version > SchemaVersion {
+		return fmt.Errorf("%w: %q", ErrUnsupportedSchemaVersion, rawVersion)
+	}
+
+	return nil
+}
+
+func headerValue(message *kafka.Message, key string) string {
+	for _, header := range message.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
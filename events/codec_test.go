@@ -0,0 +1,171 @@
+//go:build unit_test
+
+package events
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Эталонные сообщения фиксируют формат событий на проводе. Если тест упал
+// после изменения схемы, изменение ломает совместимость между сервисами.
+var (
+	goldenOrderCreatedEvent = OrderCreatedEvent{
+		ID:          1,
+		OrderID:     42,
+		UserID:      7,
+		Status:      OrderStatusCreated,
+		AmountCents: 10000,
+	}
+	goldenOrderCreatedProto = "0801102a1807200128904e"
+	goldenOrderCreatedJSON  = `{"payload":{"id":1,"order_id":42,"user_id":7,"status":"CREATED","amount_cents":10000}}`
+
+	goldenPaymentEvent = AccountOrderPaymentEvent{
+		ID:           3,
+		OrderEventID: 1,
+		AccountID:    5,
+		OrderID:      42,
+		Status:       AccountOrderStatusPaid,
+	}
+	goldenPaymentProto = "080310011805202a2801"
+
+	goldenUserEvent = UserEvent{
+		ID:                  2,
+		UserID:              7,
+		Type:                UserEventRegistered,
+		InitialBalanceCents: 5000,
+	}
+	goldenUserProto = "080210071801208827"
+)
+
+func TestEncodeWireFormat(t *testing.T) {
+	value, headers, err := EncodeOrderCreatedEvent(goldenOrderCreatedEvent, ContentTypeProtobuf)
+	require.NoError(t, err)
+	assert.Equal(t, goldenOrderCreatedProto, hex.EncodeToString(value))
+	assert.Equal(t, []kafka.Header{
+		{Key: HeaderContentType, Value: []byte(ContentTypeProtobuf)},
+		{Key: HeaderSchemaVersion, Value: []byte("1")},
+	}, headers)
+
+	value, _, err = EncodeOrderCreatedEvent(goldenOrderCreatedEvent, ContentTypeJSON)
+	require.NoError(t, err)
+	assert.JSONEq(t, goldenOrderCreatedJSON, string(value))
+
+	value, _, err = EncodeAccountOrderPaymentEvent(goldenPaymentEvent, ContentTypeProtobuf)
+	require.NoError(t, err)
+	assert.Equal(t, goldenPaymentProto, hex.EncodeToString(value))
+
+	value, _, err = EncodeUserEvent(goldenUserEvent, ContentTypeProtobuf)
+	require.NoError(t, err)
+	assert.Equal(t, goldenUserProto, hex.EncodeToString(value))
+
+	_, _, err = EncodeOrderCreatedEvent(goldenOrderCreatedEvent, "text/plain")
+	assert.ErrorIs(t, err, ErrUnsupportedContentType)
+}
+
+func TestDecodeOrderCreatedEvent(t *testing.T) {
+	protoValue, err := hex.DecodeString(goldenOrderCreatedProto)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		message kafka.Message
+		err     error
+	}{
+		{
+			name:    "LegacyDebeziumJSON",
+			message: kafka.Message{Value: []byte(`{"schema":{},"payload":{"id":1,"order_id":42,"user_id":7,"status":"CREATED","amount_cents":10000,"__op":"c","__table":"order_create_events"}}`)},
+		},
+		{
+			name: "JSONWithHeaders",
+			message: kafka.Message{
+				Value:   []byte(goldenOrderCreatedJSON),
+				Headers: newHeaders(ContentTypeJSON, "1"),
+			},
+		},
+		{
+			name: "Protobuf",
+			message: kafka.Message{
+				Value:   protoValue,
+				Headers: newHeaders(ContentTypeProtobuf, "1"),
+			},
+		},
+		{
+			name: "Invalid_FutureSchemaVersion",
+			message: kafka.Message{
+				Value:   protoValue,
+				Headers: newHeaders(ContentTypeProtobuf, "2"),
+			},
+			err: ErrUnsupportedSchemaVersion,
+		},
+		{
+			name: "Invalid_UnknownContentType",
+			message: kafka.Message{
+				Value:   protoValue,
+				Headers: newHeaders("application/avro", "1"),
+			},
+			err: ErrUnsupportedContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := DecodeOrderCreatedEvent(&tt.message)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, goldenOrderCreatedEvent, event)
+		})
+	}
+}
+
+func TestDecodeAccountOrderPaymentEvent(t *testing.T) {
+	protoValue, err := hex.DecodeString(goldenPaymentProto)
+	require.NoError(t, err)
+
+	event, err := DecodeAccountOrderPaymentEvent(&kafka.Message{
+		Value:   protoValue,
+		Headers: newHeaders(ContentTypeProtobuf, "1"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, goldenPaymentEvent, event)
+
+	event, err = DecodeAccountOrderPaymentEvent(&kafka.Message{
+		Value: []byte(`{"payload":{"id":3,"order_event_id":1,"account_id":5,"order_id":42,"status":"PAID"}}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, goldenPaymentEvent, event)
+}
+
+func TestDecodeUserEvent(t *testing.T) {
+	protoValue, err := hex.DecodeString(goldenUserProto)
+	require.NoError(t, err)
+
+	event, err := DecodeUserEvent(&kafka.Message{
+		Value:   protoValue,
+		Headers: newHeaders(ContentTypeProtobuf, "1"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, goldenUserEvent, event)
+
+	event, err = DecodeUserEvent(&kafka.Message{
+		Value:   []byte(`{"payload":{"id":2,"user_id":7,"type":"REGISTERED","initial_balance_cents":5000}}`),
+		Headers: newHeaders(ContentTypeJSON, "1"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, goldenUserEvent, event)
+}
+
+func newHeaders(contentType, schemaVersion string) []kafka.Header {
+	return []kafka.Header{
+		{Key: HeaderContentType, Value: []byte(contentType)},
+		{Key: HeaderSchemaVersion, Value: []byte(schemaVersion)},
+	}
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	eventspb "github.com/hickar/crtex_test_assignment/events/proto"
+)
+
+var orderStatusToProto = map[OrderStatus]eventspb.OrderStatus{
+	OrderStatusCreated:  eventspb.OrderStatus_ORDER_STATUS_CREATED,
+	OrderStatusPaid:     eventspb.OrderStatus_ORDER_STATUS_PAID,
+	OrderStatusCanceled: eventspb.OrderStatus_ORDER_STATUS_CANCELED,
+}
+
+var paymentStatusToProto = map[AccountOrderPaymentStatus]eventspb.AccountOrderPaymentStatus{
+	AccountOrderStatusPaid:     eventspb.AccountOrderPaymentStatus_ACCOUNT_ORDER_PAYMENT_STATUS_PAID,
+	AccountOrderStatusCanceled: eventspb.AccountOrderPaymentStatus_ACCOUNT_ORDER_PAYMENT_STATUS_CANCELED,
+}
+
+var userEventTypeToProto = map[UserEventType]eventspb.UserEventType{
+	UserEventRegistered: eventspb.UserEventType_USER_EVENT_TYPE_REGISTERED,
+	UserEventDeleted:    eventspb.UserEventType_USER_EVENT_TYPE_DELETED,
+}
+
+func orderCreatedEventToProto(event OrderCreatedEvent) *eventspb.OrderCreatedEvent {
+	return &eventspb.OrderCreatedEvent{
+		Id:          event.ID,
+		OrderId:     event.OrderID,
+		UserId:      event.UserID,
+		Status:      orderStatusToProto[event.Status],
+		AmountCents: event.AmountCents,
+	}
+}
+
+func orderCreatedEventFromProto(pb *eventspb.OrderCreatedEvent) OrderCreatedEvent {
+	event := OrderCreatedEvent{
+		ID:          pb.GetId(),
+		OrderID:     pb.GetOrderId(),
+		UserID:      pb.GetUserId(),
+		AmountCents: pb.GetAmountCents(),
+	}
+
+	for status, pbStatus := range orderStatusToProto {
+		if pbStatus == pb.GetStatus() {
+			event.Status = status
+		}
+	}
+
+	return event
+}
+
+func accountOrderPaymentEventToProto(event AccountOrderPaymentEvent) *eventspb.AccountOrderPaymentEvent {
+	return &eventspb.AccountOrderPaymentEvent{
+		Id:           event.ID,
+		OrderEventId: event.OrderEventID,
+		AccountId:    event.AccountID,
+		OrderId:      event.OrderID,
+		Status:       paymentStatusToProto[event.Status],
+		Reason:       string(event.Reason),
+	}
+}
+
+func accountOrderPaymentEventFromProto(pb *eventspb.AccountOrderPaymentEvent) AccountOrderPaymentEvent {
+	event := AccountOrderPaymentEvent{
+		ID:           pb.GetId(),
+		OrderEventID: pb.GetOrderEventId(),
+		AccountID:    pb.GetAccountId(),
+		OrderID:      pb.GetOrderId(),
+		Reason:       CancelReason(pb.GetReason()),
+	}
+
+	for status, pbStatus := range paymentStatusToProto {
+		if pbStatus == pb.GetStatus() {
+			event.Status = status
+		}
+	}
+
+	return event
+}
+
+func userEventToProto(event UserEvent) *eventspb.UserEvent {
+	return &eventspb.UserEvent{
+		Id:                  event.ID,
+		UserId:              event.UserID,
+		Type:                userEventTypeToProto[event.Type],
+		InitialBalanceCents: event.InitialBalanceCents,
+	}
+}
+
+func userEventFromProto(pb *eventspb.UserEvent) UserEvent {
+	event := UserEvent{
+		ID:                  pb.GetId(),
+		UserID:              pb.GetUserId(),
+		InitialBalanceCents: pb.GetInitialBalanceCents(),
+	}
+
+	for eventType, pbType := range userEventTypeToProto {
+		if pbType == pb.GetType() {
+			event.Type = eventType
+		}
+	}
+
+	return event
+}
@@ -23,10 +23,43 @@ const (
 	AccountOrderStatusPaid     AccountOrderPaymentStatus = "PAID"
 )
 
+// CancelReason - причина отмены оплаты заказа.
+type CancelReason string
+
+const (
+	CancelReasonAccountNotFound CancelReason = "ACCOUNT_NOT_FOUND"
+	CancelReasonAccountFrozen   CancelReason = "ACCOUNT_FROZEN"
+	CancelReasonAccountClosed   CancelReason = "ACCOUNT_CLOSED"
+	// CancelReasonInsufficientFunds - сумма заказа превышает баланс счёта
+	// с учётом допустимого овердрафта.
+	CancelReasonInsufficientFunds    CancelReason = "INSUFFICIENT_FUNDS"
+	CancelReasonOrderLimitExceeded   CancelReason = "ORDER_LIMIT_EXCEEDED"
+	CancelReasonDailyLimitExceeded   CancelReason = "DAILY_LIMIT_EXCEEDED"
+	CancelReasonMonthlyLimitExceeded CancelReason = "MONTHLY_LIMIT_EXCEEDED"
+)
+
 type AccountOrderPaymentEvent struct {
 	ID           int64                     `json:"id"`
 	OrderEventID int64                     `json:"order_event_id"`
 	AccountID    int64                     `json:"account_id"`
 	OrderID      int64                     `json:"order_id"`
 	Status       AccountOrderPaymentStatus `json:"status"`
+	// Reason заполняется только для отменённых оплат.
+	Reason CancelReason `json:"reason,omitempty"`
+}
+
+type UserEventType string
+
+const (
+	UserEventRegistered UserEventType = "REGISTERED"
+	UserEventDeleted    UserEventType = "DELETED"
+)
+
+// UserEvent - событие сервиса пользователей о регистрации или удалении пользователя.
+type UserEvent struct {
+	ID     int64         `json:"id"`
+	UserID int64         `json:"user_id"`
+	Type   UserEventType `json:"type"`
+	// InitialBalanceCents - начальный баланс счёта зарегистрированного пользователя.
+	InitialBalanceCents int64 `json:"initial_balance_cents,omitempty"`
 }
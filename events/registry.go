@@ -0,0 +1,247 @@
+package events
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+
+	eventspb "github.com/hickar/crtex_test_assignment/events/proto"
+	"github.com/hickar/crtex_test_assignment/pkg/schemaregistry"
+)
+
+var (
+	ErrSchemaRegistryRequired = errors.New("event is encoded with schema registry, but registry is not configured")
+	ErrSchemaMismatch         = errors.New("event schema does not match expected event type")
+)
+
+var (
+	//go:embed proto/events.proto
+	protoSchema string
+
+	//go:embed schemas/order_created_event.json
+	orderCreatedJSONSchema string
+
+	//go:embed schemas/account_order_payment_event.json
+	accountOrderPaymentJSONSchema string
+
+	//go:embed schemas/user_event.json
+	userJSONSchema string
+)
+
+// eventSchema описывает регистрацию события в реестре. Subject'ы именуются
+// по полному имени сообщения (RecordNameStrategy), поэтому не зависят от топика.
+type eventSchema struct {
+	subject    string
+	protoIndex int
+	jsonSchema string
+}
+
+var (
+	orderCreatedSchema = eventSchema{
+		subject:    "events.OrderCreatedEvent",
+		protoIndex: 0,
+		jsonSchema: orderCreatedJSONSchema,
+	}
+	accountOrderPaymentSchema = eventSchema{
+		subject:    "events.AccountOrderPaymentEvent",
+		protoIndex: 1,
+		jsonSchema: accountOrderPaymentJSONSchema,
+	}
+	userSchema = eventSchema{
+		subject:    "events.UserEvent",
+		protoIndex: 2,
+		jsonSchema: userJSONSchema,
+	}
+)
+
+// Codec кодирует события в формате Confluent Schema Registry: идентификатор
+// схемы передаётся в префиксе значения. При декодировании сообщения без
+// префикса разбираются так же, как DecodeOrderCreatedEvent,
+// DecodeAccountOrderPaymentEvent и DecodeUserEvent. Без реестра Codec работает
+// только с ними.
+type Codec struct {
+	registry   *schemaregistry.Client
+	schemaType schemaregistry.SchemaType
+}
+
+// NewCodec создаёт Codec. Поддерживаются схемы Protobuf (по умолчанию) и JSON Schema.
+func NewCodec(registry *schemaregistry.Client, schemaType schemaregistry.SchemaType) (*Codec, error) {
+	switch schemaType {
+	case "":
+		schemaType = schemaregistry.SchemaTypeProtobuf
+	case schemaregistry.SchemaTypeProtobuf, schemaregistry.SchemaTypeJSON:
+	default:
+		return nil, fmt.Errorf("unsupported schema type %q", schemaType)
+	}
+
+	return &Codec{registry: registry, schemaType: schemaType}, nil
+}
+
+// Schemas возвращает subject'ы и схемы событий для регистрации в реестре.
+func (c *Codec) Schemas() map[string]schemaregistry.Schema {
+	return map[string]schemaregistry.Schema{
+		orderCreatedSchema.subject:        c.schemaFor(orderCreatedSchema),
+		accountOrderPaymentSchema.subject: c.schemaFor(accountOrderPaymentSchema),
+		userSchema.subject:                c.schemaFor(userSchema),
+	}
+}
+
+func (c *Codec) EncodeOrderCreatedEvent(ctx context.Context, event OrderCreatedEvent) ([]byte, []kafka.Header, error) {
+	return encodeFramed(ctx, c, orderCreatedSchema, event, orderCreatedEventToProto(event))
+}
+
+func (c *Codec) DecodeOrderCreatedEvent(ctx context.Context, message *kafka.Message) (OrderCreatedEvent, error) {
+	if !schemaregistry.IsFramed(message.Value) {
+		return DecodeOrderCreatedEvent(message)
+	}
+
+	var pb eventspb.OrderCreatedEvent
+
+	event, isProto, err := decodeFramed[OrderCreatedEvent](ctx, c, orderCreatedSchema, message, &pb)
+	if err != nil || !isProto {
+		return event, err
+	}
+
+	return orderCreatedEventFromProto(&pb), nil
+}
+
+func (c *Codec) EncodeAccountOrderPaymentEvent(ctx context.Context, event AccountOrderPaymentEvent) ([]byte, []kafka.Header, error) {
+	return encodeFramed(ctx, c, accountOrderPaymentSchema, event, accountOrderPaymentEventToProto(event))
+}
+
+func (c *Codec) DecodeAccountOrderPaymentEvent(ctx context.Context, message *kafka.Message) (AccountOrderPaymentEvent, error) {
+	if !schemaregistry.IsFramed(message.Value) {
+		return DecodeAccountOrderPaymentEvent(message)
+	}
+
+	var pb eventspb.AccountOrderPaymentEvent
+
+	event, isProto, err := decodeFramed[AccountOrderPaymentEvent](ctx, c, accountOrderPaymentSchema, message, &pb)
+	if err != nil || !isProto {
+		return event, err
+	}
+
+	return accountOrderPaymentEventFromProto(&pb), nil
+}
+
+func (c *Codec) EncodeUserEvent(ctx context.Context, event UserEvent) ([]byte, []kafka.Header, error) {
+	return encodeFramed(ctx, c, userSchema, event, userEventToProto(event))
+}
+
+func (c *Codec) DecodeUserEvent(ctx context.Context, message *kafka.Message) (UserEvent, error) {
+	if !schemaregistry.IsFramed(message.Value) {
+		return DecodeUserEvent(message)
+	}
+
+	var pb eventspb.UserEvent
+
+	event, isProto, err := decodeFramed[UserEvent](ctx, c, userSchema, message, &pb)
+	if err != nil || !isProto {
+		return event, err
+	}
+
+	return userEventFromProto(&pb), nil
+}
+
+func (c *Codec) schemaFor(es eventSchema) schemaregistry.Schema {
+	if c.schemaType == schemaregistry.SchemaTypeJSON {
+		return schemaregistry.Schema{Schema: es.jsonSchema, SchemaType: schemaregistry.SchemaTypeJSON}
+	}
+
+	return schemaregistry.Schema{Schema: protoSchema, SchemaType: schemaregistry.SchemaTypeProtobuf}
+}
+
+func encodeFramed[T any](
+	ctx context.Context,
+	c *Codec,
+	es eventSchema,
+	event T,
+	pb proto.Message,
+) ([]byte, []kafka.Header, error) {
+	contentType := ContentTypeProtobuf
+	if c.schemaType == schemaregistry.SchemaTypeJSON {
+		contentType = ContentTypeJSON
+	}
+
+	if c.registry == nil {
+		return encode(event, pb, contentType)
+	}
+
+	schemaID, err := c.registry.Register(ctx, es.subject, c.schemaFor(es))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value := schemaregistry.AppendHeader(nil, schemaID)
+
+	var payload []byte
+	if c.schemaType == schemaregistry.SchemaTypeJSON {
+		payload, err = json.Marshal(event)
+	} else {
+		value = schemaregistry.AppendMessageIndexes(value, []int{es.protoIndex})
+		payload, err = proto.Marshal(pb)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headers := []kafka.Header{
+		{Key: HeaderContentType, Value: []byte(contentType)},
+		{Key: HeaderSchemaVersion, Value: []byte(strconv.Itoa(SchemaVersion))},
+	}
+
+	return append(value, payload...), headers, nil
+}
+
+func decodeFramed[T any](
+	ctx context.Context,
+	c *Codec,
+	es eventSchema,
+	message *kafka.Message,
+	pb proto.Message,
+) (event T, isProto bool, err error) {
+	if c.registry == nil {
+		return event, false, ErrSchemaRegistryRequired
+	}
+
+	schemaID, payload, err := schemaregistry.ParseHeader(message.Value)
+	if err != nil {
+		return event, false, err
+	}
+
+	schema, err := c.registry.SchemaByID(ctx, schemaID)
+	if err != nil {
+		return event, false, err
+	}
+
+	switch schema.SchemaType {
+	case schemaregistry.SchemaTypeProtobuf:
+		indexes, data, err := schemaregistry.ParseMessageIndexes(payload)
+		if err != nil {
+			return event, true, err
+		}
+		if len(indexes) != 1 || indexes[0] != es.protoIndex {
+			return event, true, fmt.Errorf("%w: message indexes %v in schema %d", ErrSchemaMismatch, indexes, schemaID)
+		}
+
+		if err = proto.Unmarshal(data, pb); err != nil {
+			return event, true, fmt.Errorf("failed to decode protobuf event: %w", err)
+		}
+
+		return event, true, nil
+	case schemaregistry.SchemaTypeJSON:
+		if err = json.Unmarshal(payload, &event); err != nil {
+			return event, false, fmt.Errorf("failed to decode json event: %w", err)
+		}
+
+		return event, false, nil
+	default:
+		return event, false, fmt.Errorf("%w: schema type %q", ErrUnsupportedContentType, schema.SchemaType)
+	}
+}
@@ -0,0 +1,100 @@
+//go:build unit_test
+
+package events
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/pkg/schemaregistry"
+	"github.com/hickar/crtex_test_assignment/pkg/schemaregistry/registrytest"
+)
+
+func TestCodecProtobufWireFormat(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	codec := newTestCodec(t, server, schemaregistry.SchemaTypeProtobuf)
+
+	value, headers, err := codec.EncodeOrderCreatedEvent(context.Background(), goldenOrderCreatedEvent)
+	require.NoError(t, err)
+	assert.Equal(t, "0000000001"+"00"+goldenOrderCreatedProto, hex.EncodeToString(value))
+
+	event, err := newTestCodec(t, server, "").DecodeOrderCreatedEvent(context.Background(), &kafka.Message{Value: value, Headers: headers})
+	require.NoError(t, err)
+	assert.Equal(t, goldenOrderCreatedEvent, event)
+
+	// Оба события описаны в одном .proto файле, поэтому схема у них общая,
+	// а различаются они индексом сообщения.
+	value, headers, err = codec.EncodeAccountOrderPaymentEvent(context.Background(), goldenPaymentEvent)
+	require.NoError(t, err)
+	assert.Equal(t, "0000000001"+"0202"+goldenPaymentProto, hex.EncodeToString(value))
+
+	payment, err := codec.DecodeAccountOrderPaymentEvent(context.Background(), &kafka.Message{Value: value, Headers: headers})
+	require.NoError(t, err)
+	assert.Equal(t, goldenPaymentEvent, payment)
+
+	_, err = codec.DecodeOrderCreatedEvent(context.Background(), &kafka.Message{Value: value})
+	assert.ErrorIs(t, err, ErrSchemaMismatch)
+}
+
+func TestCodecJSONSchema(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	codec := newTestCodec(t, server, schemaregistry.SchemaTypeJSON)
+
+	value, headers, err := codec.EncodeOrderCreatedEvent(context.Background(), goldenOrderCreatedEvent)
+	require.NoError(t, err)
+
+	id, payload, err := schemaregistry.ParseHeader(value)
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+	assert.JSONEq(t, `{"id":1,"order_id":42,"user_id":7,"status":"CREATED","amount_cents":10000}`, string(payload))
+
+	event, err := codec.DecodeOrderCreatedEvent(context.Background(), &kafka.Message{Value: value, Headers: headers})
+	require.NoError(t, err)
+	assert.Equal(t, goldenOrderCreatedEvent, event)
+}
+
+func TestCodecFallback(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	codec := newTestCodec(t, server, schemaregistry.SchemaTypeProtobuf)
+
+	event, err := codec.DecodeOrderCreatedEvent(context.Background(), &kafka.Message{Value: []byte(goldenOrderCreatedJSON)})
+	require.NoError(t, err)
+	assert.Equal(t, goldenOrderCreatedEvent, event)
+	assert.Zero(t, server.Requests())
+
+	value, _, err := codec.EncodeOrderCreatedEvent(context.Background(), goldenOrderCreatedEvent)
+	require.NoError(t, err)
+
+	_, err = DecodeOrderCreatedEvent(&kafka.Message{Value: value})
+	assert.ErrorIs(t, err, ErrSchemaRegistryRequired)
+
+	withoutRegistry, err := NewCodec(nil, schemaregistry.SchemaTypeProtobuf)
+	require.NoError(t, err)
+
+	_, err = withoutRegistry.DecodeOrderCreatedEvent(context.Background(), &kafka.Message{Value: value})
+	assert.ErrorIs(t, err, ErrSchemaRegistryRequired)
+
+	value, _, err = withoutRegistry.EncodeOrderCreatedEvent(context.Background(), goldenOrderCreatedEvent)
+	require.NoError(t, err)
+	assert.Equal(t, goldenOrderCreatedProto, hex.EncodeToString(value))
+}
+
+func newTestCodec(t *testing.T, server *registrytest.Server, schemaType schemaregistry.SchemaType) *Codec {
+	t.Helper()
+
+	codec, err := NewCodec(schemaregistry.NewClient(schemaregistry.Configuration{URL: server.URL}), schemaType)
+	require.NoError(t, err)
+
+	return codec
+}
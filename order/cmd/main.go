@@ -2,28 +2,43 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 
+	"github.com/hickar/crtex_test_assignment/events"
 	"github.com/hickar/crtex_test_assignment/order/internal/config"
+	"github.com/hickar/crtex_test_assignment/order/internal/controllers/gateway"
 	grpcHandler "github.com/hickar/crtex_test_assignment/order/internal/controllers/grpc"
 	"github.com/hickar/crtex_test_assignment/order/internal/controllers/kafka"
 	"github.com/hickar/crtex_test_assignment/order/internal/domain"
 	"github.com/hickar/crtex_test_assignment/order/internal/repository"
 	"github.com/hickar/crtex_test_assignment/order/proto"
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
 	"github.com/hickar/crtex_test_assignment/pkg/interceptors"
 	kconsumer "github.com/hickar/crtex_test_assignment/pkg/kafka/consumer"
 	"github.com/hickar/crtex_test_assignment/pkg/postgres"
+	"github.com/hickar/crtex_test_assignment/pkg/ratelimit"
+	"github.com/hickar/crtex_test_assignment/pkg/requestid"
+	"github.com/hickar/crtex_test_assignment/pkg/schemaregistry"
+	"github.com/hickar/crtex_test_assignment/pkg/tlsconfig"
 )
 
 var configPath = flag.String("config", "./config.yaml", "Path to configuration file. Defaults to './config.yaml'")
@@ -38,16 +53,95 @@ func main() {
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGABRT)
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	// В режимах replay, reset-offsets и dlq в stdout выводятся результаты команд.
+	logOutput := os.Stdout
+	switch flag.Arg(0) {
+	case kconsumer.ReplayCommand, kconsumer.ResetOffsetsCommand, kconsumer.DeadLetterCommand:
+		logOutput = os.Stderr
+	}
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(logOutput, &slog.HandlerOptions{
 		Level: cfg.Logger.Level,
-	}))
+	})))
+
+	kafkaCfg, err := kafkaConsumerConfiguration(cfg.KafkaConsumer, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to initialize kafka consumer: %s", err))
+		os.Exit(1)
+	}
+
+	if flag.Arg(0) == kconsumer.ResetOffsetsCommand {
+		err = kconsumer.RunResetOffsetsCommand(ctx, flag.Args()[1:], kafkaCfg, os.Stdout)
+		cancel()
+		if err != nil {
+			logger.Error(fmt.Sprintf("offsets reset failed: %s", err))
+			os.Exit(1)
+		}
+		return
+	}
 
-	repo, err := initOrderRepo(ctx, cfg.DB)
+	pgdb, err := initDB(ctx, cfg.DB)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to initialize order repository: %s", err))
 		os.Exit(1)
 	}
-	service := domain.NewOrderService(repo)
+	service := domain.NewOrderService(repository.NewOrderRepository(pgdb))
+
+	// Настройка хэндлеров для сообщений Kafka
+	codec, err := initEventCodec(cfg.SchemaRegistry)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to initialize event codec: %s", err))
+		os.Exit(1)
+	}
+
+	// Утилиты повторной обработки вызывают обработчики напрямую: ошибки выводятся
+	// пользователю, а не отправляются в DLQ, и не размыкают предохранитель.
+	toolRouter := initKafkaRouter(cfg.KafkaConsumer, service, codec, nil, nil, logger)
+
+	if flag.Arg(0) == kconsumer.ReplayCommand {
+		err = kconsumer.RunReplayCommand(ctx, flag.Args()[1:], kafkaCfg, toolRouter, os.Stdout)
+		cancel()
+		if err != nil {
+			logger.Error(fmt.Sprintf("replay failed: %s", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == kconsumer.DeadLetterCommand {
+		decode := kconsumer.DeadLetterDecoderFor(codec.DecodeAccountOrderPaymentEvent)
+		err = kconsumer.RunDeadLetterCommand(ctx, flag.Args()[1:], kafkaCfg, cfg.KafkaConsumer.DLQTopic, toolRouter, decode, os.Stdout)
+		cancel()
+		if err != nil {
+			logger.Error(fmt.Sprintf("dead letter command failed: %s", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	var dlqWriter kconsumer.MessageWriter
+	if cfg.KafkaConsumer.DLQTopic != "" {
+		writer := kconsumer.NewKafkaWriter(kafkaCfg)
+		defer writer.Close()
+		dlqWriter = writer
+	}
+	var breaker *kconsumer.CircuitBreaker
+	if cfg.KafkaConsumer.CircuitBreaker.Enabled {
+		breaker = kconsumer.NewCircuitBreaker(kconsumer.CircuitBreakerConfiguration{
+			FailureThreshold: cfg.KafkaConsumer.CircuitBreaker.FailureThreshold,
+			IsFailure:        postgres.IsUnavailable,
+			Probe:            pgdb.Ping,
+			ProbeInterval:    cfg.KafkaConsumer.CircuitBreaker.ProbeInterval,
+			RetryBackoff:     cfg.KafkaConsumer.CircuitBreaker.RetryBackoff,
+			Logger:           logger.With(slog.String("module", "circuit_breaker")),
+		})
+	}
+	kafkaRouter := initKafkaRouter(cfg.KafkaConsumer, service, codec, dlqWriter, breaker, logger)
+
+	rateLimiter, err := initRateLimiter(cfg.RateLimit, pgdb)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to initialize rate limiter: %s", err))
+		os.Exit(1)
+	}
 
 	// Настройка сервера GRPC
 	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCServer.Port))
@@ -55,14 +149,38 @@ func main() {
 		logger.Error(fmt.Sprintf("failed to open tcp connection on port %d: %s", cfg.GRPCServer.Port, err))
 		os.Exit(1)
 	}
-	grpcServer := initGRPCServer(cfg.GRPCServer, service, logger)
+	grpcServer, err := initGRPCServer(ctx, cfg.GRPCServer, cfg.Auth, rateLimiter, service, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to initialize grpc server: %s", err))
+		os.Exit(1)
+	}
 
-	// Настройка хэндлеров для сообщений Kafka
-	kafkaConsumer, err := initKafkaConsumer(cfg.KafkaConsumer, service, logger)
+	// Настройка REST/JSON шлюза к GRPC API
+	var (
+		httpServer  *http.Server
+		gatewayConn *grpc.ClientConn
+	)
+	if cfg.HTTPServer.Port != 0 {
+		httpServer, gatewayConn, err = initHTTPGateway(ctx, cfg.HTTPServer, cfg.GRPCServer)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to initialize http gateway: %s", err))
+			os.Exit(1)
+		}
+	}
+
+	kafkaCfg.Metrics, err = kconsumer.NewMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to register kafka consumer metrics: %s", err))
+		os.Exit(1)
+	}
+	kafkaConsumer, err := kconsumer.NewConsumer(kafkaCfg, kafkaRouter)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to initialize kafka consumer: %s", err))
 		os.Exit(1)
 	}
+	if breaker != nil {
+		breaker.Attach(kafkaConsumer)
+	}
 
 	errCh := make(chan error)
 	go func() {
@@ -72,6 +190,26 @@ func main() {
 		}
 	}()
 
+	if httpServer != nil {
+		go func() {
+			logger.Info(fmt.Sprintf("launching http gateway on port %d", cfg.HTTPServer.Port))
+			if cerr := httpServer.ListenAndServe(); cerr != nil && !errors.Is(cerr, http.ErrServerClosed) {
+				errCh <- cerr
+			}
+		}()
+	}
+
+	var adminServer *http.Server
+	if cfg.Admin.Port != 0 {
+		adminServer = initAdminServer(cfg.Admin, kafkaCfg)
+		go func() {
+			logger.Info(fmt.Sprintf("launching admin server on port %d", cfg.Admin.Port))
+			if cerr := adminServer.ListenAndServe(); cerr != nil && !errors.Is(cerr, http.ErrServerClosed) {
+				errCh <- cerr
+			}
+		}()
+	}
+
 	go func() {
 		logger.Info("launching kafka consumer")
 		if cerr := kafkaConsumer.Run(ctx); cerr != nil {
@@ -85,7 +223,7 @@ func main() {
 		stopErr = ctx.Err()
 	case stopErr = <-errCh:
 	}
-	if stopErr != nil && !errors.Is(err, context.Canceled) {
+	if stopErr != nil && !errors.Is(stopErr, context.Canceled) {
 		logger.Error(fmt.Sprintf("application stopped with error: %s", stopErr))
 		cancel()
 		os.Exit(1)
@@ -93,77 +231,280 @@ func main() {
 
 	logger.Info("gracefully shutting down server")
 
+	if httpServer != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+		if err = httpServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error(fmt.Sprintf("failed to shutdown http gateway: %s", err))
+		}
+		shutdownCancel()
+		gatewayConn.Close()
+	}
+
+	if adminServer != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Admin.ShutdownTimeout)
+		if err = adminServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error(fmt.Sprintf("failed to shutdown admin server: %s", err))
+		}
+		shutdownCancel()
+	}
+
 	cancel()
 	grpcServer.GracefulStop()
 }
 
-func initOrderRepo(ctx context.Context, cfg config.DatabaseConfiguration) (*repository.OrderRepository, error) {
-	pgdb, err := postgres.New(ctx, postgres.Configuration{
+func initDB(ctx context.Context, cfg config.DatabaseConfiguration) (*pgxpool.Pool, error) {
+	return postgres.New(ctx, postgres.Configuration{
 		Host:                    cfg.Host,
 		Port:                    cfg.Port,
 		User:                    cfg.User,
 		Password:                cfg.Password,
 		Name:                    cfg.Name,
+		SSLMode:                 cfg.SSLMode,
+		SSLRootCert:             cfg.SSLRootCert,
+		SSLCert:                 cfg.SSLCert,
+		SSLKey:                  cfg.SSLKey,
 		MaxOpenConns:            cfg.MaxConns,
 		MaxConnLifetime:         cfg.MaxConnLifetime,
 		MaxIdleConnLifetime:     cfg.MaxIdleConnLifetime,
 		ConnectionRetries:       cfg.ConnectionRetries,
 		ConnectionRetryInterval: cfg.ConnectionRetryInterval,
 	})
-	if err != nil {
-		return nil, err
+}
+
+// initRateLimiter возвращает nil, если ограничение запросов выключено.
+func initRateLimiter(cfg config.RateLimitConfiguration, pgdb *pgxpool.Pool) (*ratelimit.Limiter, error) {
+	if !cfg.Enabled {
+		return nil, nil
 	}
 
-	return repository.NewOrderRepository(pgdb), nil
+	var store ratelimit.Store
+	switch cfg.Store {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "postgres":
+		store = ratelimit.NewPostgresStore(pgdb)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+
+	rules := make([]ratelimit.Rule, 0, len(cfg.Methods))
+	for _, method := range cfg.Methods {
+		rule := ratelimit.Rule{Method: method.Method, MaxConcurrent: method.MaxConcurrent}
+		if method.Requests > 0 && method.Period > 0 {
+			rule.Limit = ratelimit.Limit{
+				Rate:  float64(method.Requests) / method.Period.Seconds(),
+				Burst: max(method.Burst, 1),
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	return ratelimit.NewLimiter(store, rules), nil
+}
+
+func initEventCodec(cfg config.SchemaRegistryConfiguration) (*events.Codec, error) {
+	var registry *schemaregistry.Client
+	if cfg.URL != "" {
+		registry = schemaregistry.NewClient(schemaregistry.Configuration{
+			URL:      cfg.URL,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			Timeout:  cfg.Timeout,
+		})
+	}
+
+	return events.NewCodec(registry, schemaregistry.SchemaType(cfg.SchemaType))
 }
 
-func initKafkaConsumer(
+func initKafkaRouter(
 	cfg config.KafkaConsumerConfiguration,
 	orderService domain.Service,
+	codec *events.Codec,
+	dlqWriter kconsumer.MessageWriter,
+	breaker *kconsumer.CircuitBreaker,
 	logger *slog.Logger,
-) (*kconsumer.Consumer, error) {
-	kafkaOrderHandler := kafka.NewOrderHandler(orderService)
+) *kconsumer.TopicRouter {
+	kafkaOrderHandler := kafka.NewOrderHandler(orderService, codec)
 	kafkaRouter := kconsumer.NewTopicRouter()
-	kafkaRouter.Handle(
-		cfg.Topic,
-		kafkaOrderHandler.NewAccountOrderEvent,
-		kconsumer.LoggerMiddleware(logger.With(
-			slog.String("module", "kafka_router")),
-		),
+	routerLogger := logger.With(slog.String("module", "kafka_router"))
+	// Middleware, зарегистрированный последним, выполняется первым.
+	kafkaRouter.Use(kconsumer.RecoveryMiddleware(routerLogger))
+	if breaker != nil {
+		// Регистрируется до DLQ, чтобы сообщения не попадали туда во время сбоя БД.
+		kafkaRouter.Use(breaker.Middleware())
+	}
+	if dlqWriter != nil {
+		// Паники, перехваченные RecoveryMiddleware, тоже попадают в DLQ.
+		kafkaRouter.Use(kconsumer.DeadLetterMiddleware(dlqWriter, cfg.DLQTopic))
+	}
+	kafkaRouter.Use(
+		kconsumer.LoggerMiddleware(routerLogger),
+		kconsumer.RequestIDMiddleware(),
 	)
+	kafkaRouter.Handle(cfg.Topic, kafkaOrderHandler.NewAccountOrderEvent)
+
+	return kafkaRouter
+}
+
+func kafkaConsumerConfiguration(
+	cfg config.KafkaConsumerConfiguration,
+	logger *slog.Logger,
+) (kconsumer.Configuration, error) {
+	var tlsCfg *tls.Config
+	if cfg.TLS.Enabled {
+		var err error
+		tlsCfg, err = tlsconfig.NewClientConfig(tlsConfiguration(cfg.TLS))
+		if err != nil {
+			return kconsumer.Configuration{}, fmt.Errorf("failed to initialize kafka tls: %w", err)
+		}
+	}
+
+	saslMechanism, err := kconsumer.NewSASLMechanism(cfg.SASL.Mechanism, cfg.SASL.Username, cfg.SASL.Password)
+	if err != nil {
+		return kconsumer.Configuration{}, err
+	}
+
+	var startTime time.Time
+	if cfg.StartTime != "" {
+		if startTime, err = time.Parse(time.RFC3339, cfg.StartTime); err != nil {
+			return kconsumer.Configuration{}, fmt.Errorf("invalid kafka start time: %w", err)
+		}
+	}
+
+	return kconsumer.Configuration{
+		BrokerURLs:        cfg.BrokerURLs,
+		GroupID:           cfg.GroupID,
+		GroupTopics:       cfg.GroupTopics,
+		Topic:             cfg.Topic,
+		SessionTimeout:    cfg.SessionTimeout,
+		HeartbeatInterval: cfg.HeartbeatInterval,
+		WorkerCount:       cfg.WorkerCount,
+		HandlerTimeout:    cfg.HandlerTimeout,
+		StatsInterval:     cfg.StatsInterval,
+		StartOffset:       kconsumer.StartOffset(cfg.StartOffset),
+		StartTime:         startTime,
+		TLS:               tlsCfg,
+		SASL:              saslMechanism,
+		Logger:            logger.With(slog.String("module", "kafka_consumer")),
+	}, nil
+}
+
+func initHTTPGateway(
+	ctx context.Context,
+	cfg config.HTTPConfiguration,
+	grpcCfg config.GRPCConfiguration,
+) (*http.Server, *grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if grpcCfg.TLS.Enabled {
+		tlsCfg, err := tlsconfig.NewClientConfig(tlsConfiguration(cfg.GRPCClientTLS))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize grpc client tls: %w", err)
+		}
+		creds = credentials.NewTLS(tlsCfg)
+	}
 
-	return kconsumer.NewConsumer(
-		kconsumer.Configuration{
-			BrokerURLs:        cfg.BrokerURLs,
-			GroupID:           cfg.GroupID,
-			GroupTopics:       cfg.GroupTopics,
-			Topic:             cfg.Topic,
-			SessionTimeout:    cfg.SessionTimeout,
-			HeartbeatInterval: cfg.HeartbeatInterval,
-			WorkerCount:       cfg.WorkerCount,
-			HandlerTimeout:    cfg.HandlerTimeout,
-		},
-		kafkaRouter,
+	conn, err := grpc.DialContext(
+		ctx,
+		fmt.Sprintf("localhost:%d", grpcCfg.Port),
+		grpc.WithTransportCredentials(creds),
 	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	handler, err := gateway.NewHandler(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+	}, conn, nil
+}
+
+// initAdminServer создаёт служебный HTTP-сервер с метриками Prometheus
+// и состоянием группы консьюмеров.
+func initAdminServer(cfg config.AdminConfiguration, kafkaCfg kconsumer.Configuration) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/kafka/group", kconsumer.GroupStateHandler(kconsumer.NewAdmin(kafkaCfg), kafkaCfg))
+
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           mux,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+	}
 }
 
 func initGRPCServer(
+	ctx context.Context,
 	cfg config.GRPCConfiguration,
+	authCfg config.AuthConfiguration,
+	rateLimiter *ratelimit.Limiter,
 	orderService domain.Service,
 	logger *slog.Logger,
-) *grpc.Server {
+) (*grpc.Server, error) {
+	metrics, err := interceptors.NewMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register grpc metrics: %w", err)
+	}
+
+	chainCfg := interceptors.ChainConfiguration{
+		Logger:      logger.With(slog.String("module", "grpc_server")),
+		ErrorDomain: domain.ErrorDomain,
+		Metrics:     metrics,
+		RateLimiter: rateLimiter,
+	}
+
+	if authCfg.Enabled {
+		chainCfg.Verifier, err = auth.NewVerifier(ctx, auth.Configuration{
+			HMACSecret:          authCfg.HMACSecret,
+			JWKSFile:            authCfg.JWKSFile,
+			JWKSURL:             authCfg.JWKSURL,
+			JWKSRefreshInterval: authCfg.JWKSRefreshInterval,
+			Issuer:              authCfg.Issuer,
+			Audience:            authCfg.Audience,
+			AdminScope:          authCfg.AdminScope,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize token verifier: %w", err)
+		}
+	}
+
 	grpcOrderHandler := grpcHandler.NewOrderHandler(orderService)
-	grpcServer := grpc.NewServer(
+	opts := []grpc.ServerOption{
 		grpc.KeepaliveParams(keepalive.ServerParameters{
 			MaxConnectionIdle: cfg.MaxIdleConnLifetime,
 			MaxConnectionAge:  cfg.MaxConnectionAge,
 			Timeout:           cfg.Timeout,
 		}),
-		grpc.UnaryInterceptor(interceptors.LoggerInterceptor(
-			logger.With(slog.String("module", "grpc_server")),
-		)),
-	)
+	}
+	opts = append(opts, interceptors.ServerOptions(chainCfg)...)
+
+	if cfg.TLS.Enabled {
+		tlsCfg, err := tlsconfig.NewServerConfig(tlsConfiguration(cfg.TLS))
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize grpc tls: %w", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	grpcServer := grpc.NewServer(opts...)
 	proto.RegisterOrderServer(grpcServer, grpcOrderHandler)
 
-	return grpcServer
+	return grpcServer, nil
+}
+
+func tlsConfiguration(cfg config.TLSConfiguration) tlsconfig.Configuration {
+	return tlsconfig.Configuration{
+		CertFile:           cfg.CertFile,
+		KeyFile:            cfg.KeyFile,
+		CAFile:             cfg.CAFile,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		ReloadInterval:     cfg.ReloadInterval,
+	}
 }
@@ -0,0 +1,45 @@
+// Package inmemory собирает сервис заказов поверх in-memory хранилища, чтобы
+// его можно было запускать в одном процессе с другими сервисами.
+package inmemory
+
+import (
+	"google.golang.org/grpc"
+
+	"github.com/hickar/crtex_test_assignment/events"
+	grpcHandler "github.com/hickar/crtex_test_assignment/order/internal/controllers/grpc"
+	"github.com/hickar/crtex_test_assignment/order/internal/controllers/kafka"
+	"github.com/hickar/crtex_test_assignment/order/internal/domain"
+	"github.com/hickar/crtex_test_assignment/order/internal/repository"
+	"github.com/hickar/crtex_test_assignment/order/proto"
+	kconsumer "github.com/hickar/crtex_test_assignment/pkg/kafka/consumer"
+)
+
+// ErrorDomain - домен ошибок сервиса заказов для interceptors.ChainConfiguration.
+const ErrorDomain = domain.ErrorDomain
+
+type OrderService struct {
+	repo    *repository.MemoryOrderRepository
+	service *domain.OrderService
+}
+
+func NewOrderService() *OrderService {
+	repo := repository.NewMemoryOrderRepository()
+
+	return &OrderService{
+		repo:    repo,
+		service: domain.NewOrderService(repo),
+	}
+}
+
+func (s *OrderService) RegisterGRPC(registrar grpc.ServiceRegistrar) {
+	proto.RegisterOrderServer(registrar, grpcHandler.NewOrderHandler(s.service))
+}
+
+// HandleAccountEvents регистрирует обработчик событий сервиса счетов.
+func (s *OrderService) HandleAccountEvents(router *kconsumer.TopicRouter, topic string, codec *events.Codec) {
+	router.Handle(topic, kafka.NewOrderHandler(s.service, codec).NewAccountOrderEvent)
+}
+
+func (s *OrderService) OutboxEvents(afterID int64) []events.OrderCreatedEvent {
+	return s.repo.OutboxEvents(afterID)
+}
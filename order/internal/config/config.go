@@ -9,17 +9,42 @@ import (
 )
 
 type Configuration struct {
-	GRPCServer    GRPCConfiguration          `yaml:"grpc"`
-	DB            DatabaseConfiguration      `yaml:"db"`
-	Logger        LoggerConfiguration        `yaml:"logger"`
-	KafkaConsumer KafkaConsumerConfiguration `yaml:"kafka_consumer"`
+	GRPCServer     GRPCConfiguration           `yaml:"grpc"`
+	HTTPServer     HTTPConfiguration           `yaml:"http"`
+	DB             DatabaseConfiguration       `yaml:"db"`
+	Logger         LoggerConfiguration         `yaml:"logger"`
+	KafkaConsumer  KafkaConsumerConfiguration  `yaml:"kafka_consumer"`
+	SchemaRegistry SchemaRegistryConfiguration `yaml:"schema_registry"`
+	Auth           AuthConfiguration           `yaml:"auth"`
+	RateLimit      RateLimitConfiguration      `yaml:"rate_limit"`
+	Admin          AdminConfiguration          `yaml:"admin"`
 }
 
 type GRPCConfiguration struct {
-	Port                int           `yaml:"port"`
-	MaxIdleConnLifetime time.Duration `yaml:"max_idle_connection_lifetime"`
-	MaxConnectionAge    time.Duration `yaml:"max_connection_age"`
-	Timeout             time.Duration `yaml:"timeout"`
+	Port                int              `yaml:"port"`
+	MaxIdleConnLifetime time.Duration    `yaml:"max_idle_connection_lifetime"`
+	MaxConnectionAge    time.Duration    `yaml:"max_connection_age"`
+	Timeout             time.Duration    `yaml:"timeout"`
+	TLS                 TLSConfiguration `yaml:"tls" env-prefix:"GRPC_"`
+}
+
+// HTTPConfiguration - настройки REST/JSON шлюза к GRPC API.
+// Если порт не задан, шлюз не запускается.
+type HTTPConfiguration struct {
+	Port              int           `yaml:"port"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env-default:"10s"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+	// GRPCClientTLS используется шлюзом для подключения к GRPC-серверу,
+	// если на нём включён TLS.
+	GRPCClientTLS TLSConfiguration `yaml:"grpc_client_tls" env-prefix:"HTTP_GRPC_CLIENT_"`
+}
+
+// AdminConfiguration - служебный HTTP-сервер с метриками и состоянием группы
+// консьюмеров. Если порт не задан, сервер не запускается.
+type AdminConfiguration struct {
+	Port              int           `yaml:"port" env:"ADMIN_PORT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env-default:"10s"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
 }
 
 type DatabaseConfiguration struct {
@@ -28,6 +53,10 @@ type DatabaseConfiguration struct {
 	User                    string        `yaml:"user" env:"DATABASE_USER"`
 	Password                string        `yaml:"password" env:"DATABASE_PASSWORD"`
 	Name                    string        `yaml:"name" env:"DATABASE_NAME"`
+	SSLMode                 string        `yaml:"ssl_mode" env:"DATABASE_SSL_MODE"`
+	SSLRootCert             string        `yaml:"ssl_root_cert" env:"DATABASE_SSL_ROOT_CERT"`
+	SSLCert                 string        `yaml:"ssl_cert" env:"DATABASE_SSL_CERT"`
+	SSLKey                  string        `yaml:"ssl_key" env:"DATABASE_SSL_KEY"`
 	MaxConns                int           `yaml:"max_connections"`
 	MaxConnLifetime         time.Duration `yaml:"max_connection_lifetime"`
 	MaxIdleConnLifetime     time.Duration `yaml:"max_idle_connection_lifetime"`
@@ -36,14 +65,101 @@ type DatabaseConfiguration struct {
 }
 
 type KafkaConsumerConfiguration struct {
-	BrokerURLs        []string      `yaml:"broker_urls"`
-	GroupID           string        `yaml:"group_id"`
-	GroupTopics       []string      `yaml:"group_topics"`
-	Topic             string        `yaml:"topic"`
-	SessionTimeout    time.Duration `yaml:"session_timeout"`
-	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
-	HandlerTimeout    time.Duration `yaml:"handler_timeout"`
-	WorkerCount       int           `yaml:"worker_count"`
+	BrokerURLs        []string               `yaml:"broker_urls"`
+	GroupID           string                 `yaml:"group_id"`
+	GroupTopics       []string               `yaml:"group_topics"`
+	Topic             string                 `yaml:"topic"`
+	SessionTimeout    time.Duration          `yaml:"session_timeout"`
+	HeartbeatInterval time.Duration          `yaml:"heartbeat_interval"`
+	HandlerTimeout    time.Duration          `yaml:"handler_timeout"`
+	WorkerCount       int                    `yaml:"worker_count"`
+	TLS               TLSConfiguration       `yaml:"tls" env-prefix:"KAFKA_"`
+	SASL              KafkaSASLConfiguration `yaml:"sasl"`
+	// StartOffset - позиция чтения партиций без смещений группы:
+	// earliest, latest или timestamp (со StartTime в RFC 3339).
+	StartOffset string `yaml:"start_offset" env:"KAFKA_START_OFFSET" env-default:"latest"`
+	StartTime   string `yaml:"start_time" env:"KAFKA_START_TIME"`
+	// DLQTopic - топик для сообщений, обработка которых завершилась ошибкой.
+	// Пустое значение отключает DLQ.
+	DLQTopic       string                      `yaml:"dlq_topic" env:"KAFKA_DLQ_TOPIC"`
+	CircuitBreaker CircuitBreakerConfiguration `yaml:"circuit_breaker"`
+	// StatsInterval - период проверки отставания группы.
+	StatsInterval time.Duration `yaml:"stats_interval" env:"KAFKA_STATS_INTERVAL"`
+}
+
+// CircuitBreakerConfiguration - приостановка чтения сообщений, пока база
+// данных недоступна. Нулевые значения заменяются значениями по умолчанию.
+type CircuitBreakerConfiguration struct {
+	Enabled bool `yaml:"enabled" env:"KAFKA_CIRCUIT_BREAKER_ENABLED"`
+	// FailureThreshold - число ошибок БД подряд до приостановки чтения.
+	FailureThreshold int           `yaml:"failure_threshold" env:"KAFKA_CIRCUIT_BREAKER_FAILURE_THRESHOLD"`
+	ProbeInterval    time.Duration `yaml:"probe_interval" env:"KAFKA_CIRCUIT_BREAKER_PROBE_INTERVAL"`
+	// RetryBackoff - начальная пауза перед повтором сообщения, пока порог не достигнут.
+	RetryBackoff time.Duration `yaml:"retry_backoff" env:"KAFKA_CIRCUIT_BREAKER_RETRY_BACKOFF"`
+}
+
+// KafkaSASLConfiguration - аутентификация в Kafka. Поддерживаются механизмы
+// PLAIN, SCRAM-SHA-256 и SCRAM-SHA-512; пустой механизм отключает SASL.
+type KafkaSASLConfiguration struct {
+	Mechanism string `yaml:"mechanism" env:"KAFKA_SASL_MECHANISM"`
+	Username  string `yaml:"username" env:"KAFKA_SASL_USERNAME"`
+	Password  string `yaml:"password" env:"KAFKA_SASL_PASSWORD"`
+}
+
+// TLSConfiguration - настройки TLS соединения. Для сервера CAFile задаёт
+// CA клиентских сертификатов и включает mTLS, для клиента - CA сервера.
+// Изменённые файлы сертификатов перечитываются не чаще ReloadInterval.
+type TLSConfiguration struct {
+	Enabled            bool          `yaml:"enabled" env:"TLS_ENABLED"`
+	CertFile           string        `yaml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile            string        `yaml:"key_file" env:"TLS_KEY_FILE"`
+	CAFile             string        `yaml:"ca_file" env:"TLS_CA_FILE"`
+	ServerName         string        `yaml:"server_name" env:"TLS_SERVER_NAME"`
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
+	ReloadInterval     time.Duration `yaml:"reload_interval" env-default:"30s"`
+}
+
+// SchemaRegistryConfiguration - настройки реестра схем событий.
+// Если URL не задан, события кодируются без идентификатора схемы.
+type SchemaRegistryConfiguration struct {
+	URL        string        `yaml:"url" env:"SCHEMA_REGISTRY_URL"`
+	Username   string        `yaml:"username" env:"SCHEMA_REGISTRY_USERNAME"`
+	Password   string        `yaml:"password" env:"SCHEMA_REGISTRY_PASSWORD"`
+	SchemaType string        `yaml:"schema_type" env-default:"PROTOBUF"`
+	Timeout    time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+// AuthConfiguration - настройки проверки JWT. Если проверка выключена,
+// вызывающие могут работать с заказами любых пользователей.
+type AuthConfiguration struct {
+	Enabled             bool          `yaml:"enabled" env:"AUTH_ENABLED"`
+	HMACSecret          string        `yaml:"hmac_secret" env:"AUTH_HMAC_SECRET"`
+	JWKSFile            string        `yaml:"jwks_file" env:"AUTH_JWKS_FILE"`
+	JWKSURL             string        `yaml:"jwks_url" env:"AUTH_JWKS_URL"`
+	JWKSRefreshInterval time.Duration `yaml:"jwks_refresh_interval" env-default:"1m"`
+	Issuer              string        `yaml:"issuer" env:"AUTH_ISSUER"`
+	Audience            string        `yaml:"audience" env:"AUTH_AUDIENCE"`
+	AdminScope          string        `yaml:"admin_scope" env-default:"orders:admin"`
+}
+
+// RateLimitConfiguration - ограничения запросов к GRPC API по пользователю
+// и методу. Store "memory" хранит состояние в памяти процесса, "postgres" -
+// в базе сервиса, так что лимиты общие для всех реплик.
+type RateLimitConfiguration struct {
+	Enabled bool                           `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Store   string                         `yaml:"store" env:"RATE_LIMIT_STORE" env-default:"memory"`
+	Methods []RateLimitMethodConfiguration `yaml:"methods"`
+}
+
+// RateLimitMethodConfiguration - не больше Requests запросов за Period
+// с кратковременным превышением до Burst и не больше MaxConcurrent
+// одновременных запросов одного пользователя.
+type RateLimitMethodConfiguration struct {
+	Method        string        `yaml:"method"`
+	Requests      int           `yaml:"requests"`
+	Period        time.Duration `yaml:"period"`
+	Burst         int           `yaml:"burst"`
+	MaxConcurrent int           `yaml:"max_concurrent"`
 }
 
 type LoggerConfiguration struct {
@@ -0,0 +1,64 @@
+// Package gateway транслирует REST/JSON запросы в вызовы GRPC API сервиса заказов.
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+
+	"github.com/hickar/crtex_test_assignment/order/proto"
+	"github.com/hickar/crtex_test_assignment/pkg/interceptors"
+	"github.com/hickar/crtex_test_assignment/pkg/requestid"
+)
+
+// NewHandler возвращает HTTP-обработчик API. Запросы передаются GRPC-серверу
+// через conn, поэтому проходят через те же интерсепторы, что и запросы
+// GRPC-клиентов, а коды ошибок GRPC преобразуются в HTTP-статусы.
+func NewHandler(ctx context.Context, conn *grpc.ClientConn) (http.Handler, error) {
+	gwMux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+	)
+	if err := proto.RegisterOrderHandler(ctx, gwMux, conn); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", gwMux)
+	mux.HandleFunc("/openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(proto.OpenAPI)
+	})
+
+	return mux, nil
+}
+
+// incomingHeaderMatcher и outgoingHeaderMatcher передают заголовок X-Request-Id
+// в GRPC-метаданные и обратно, а Retry-After - из GRPC-метаданных ответа,
+// без префикса Grpc-Metadata-.
+func incomingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, requestid.MetadataKey) {
+		return requestid.MetadataKey, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+func outgoingHeaderMatcher(key string) (string, bool) {
+	switch key {
+	case requestid.MetadataKey:
+		return "X-Request-Id", true
+	case interceptors.RetryAfterKey:
+		return "Retry-After", true
+	}
+
+	return runtime.MetadataHeaderPrefix + key, true
+}
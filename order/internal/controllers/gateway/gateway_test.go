@@ -0,0 +1,295 @@
+//go:build unit_test
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	grpcHandler "github.com/hickar/crtex_test_assignment/order/internal/controllers/grpc"
+	"github.com/hickar/crtex_test_assignment/order/internal/domain"
+	"github.com/hickar/crtex_test_assignment/order/internal/repository"
+	"github.com/hickar/crtex_test_assignment/order/proto"
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
+	"github.com/hickar/crtex_test_assignment/pkg/interceptors"
+	"github.com/hickar/crtex_test_assignment/pkg/ratelimit"
+)
+
+func TestGateway(t *testing.T) {
+	var calls atomic.Int64
+	server := startGateway(t, func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		calls.Add(1)
+		return handler(ctx, req)
+	})
+
+	resp, body := doRequest(t, http.MethodPost, server.URL+"/v1/orders", `{"userId":"1","amount":"10000"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+
+	var created struct {
+		TransactionID string `json:"transactionId"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &created))
+	require.NotEmpty(t, created.TransactionID)
+
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/v1/orders/"+created.TransactionID, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.JSONEq(t, `{"id":"`+created.TransactionID+`","clientId":"1","amount":"10000","status":"CREATED","items":[]}`, body)
+
+	assert.EqualValues(t, 2, calls.Load(), "requests must pass through grpc server interceptors")
+}
+
+func TestGatewayErrors(t *testing.T) {
+	server := startGateway(t, nil)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{
+			name:   "NotFound",
+			method: http.MethodGet,
+			path:   "/v1/orders/100",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "InvalidData",
+			method: http.MethodPost,
+			path:   "/v1/orders",
+			body:   `{"userId":"1","amount":"-5"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "MalformedBody",
+			method: http.MethodPost,
+			path:   "/v1/orders",
+			body:   `{"userId":`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "UnknownRoute",
+			method: http.MethodGet,
+			path:   "/v1/accounts",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := doRequest(t, tt.method, server.URL+tt.path, tt.body)
+			assert.Equal(t, tt.status, resp.StatusCode, body)
+		})
+	}
+}
+
+func TestGatewayOrderItems(t *testing.T) {
+	server := startGateway(t, nil)
+
+	resp, body := doRequest(t, http.MethodPost, server.URL+"/v1/orders", `{"userId":"1","items":[
+		{"sku":"BOOK-1","name":"Book","quantity":"2","unitPrice":"1500"},
+		{"sku":"PEN-7","name":"Pen","quantity":"1","unitPrice":"250"}
+	]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+
+	var created struct {
+		TransactionID string `json:"transactionId"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &created))
+
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/v1/orders/"+created.TransactionID, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.JSONEq(t, `{"id":"`+created.TransactionID+`","clientId":"1","amount":"3250","status":"CREATED","items":[
+		{"sku":"BOOK-1","name":"Book","quantity":"2","unitPrice":"1500"},
+		{"sku":"PEN-7","name":"Pen","quantity":"1","unitPrice":"250"}
+	]}`, body)
+
+	resp, body = doRequest(t, http.MethodPost, server.URL+"/v1/orders", `{"userId":"1","amount":"100","items":[
+		{"sku":"BOOK-1","name":"Book","quantity":"2","unitPrice":"1500"}
+	]}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	assert.Contains(t, body, "must be equal to the sum of items (3000)")
+}
+
+func TestGatewayErrorDetails(t *testing.T) {
+	server := startGateway(t, nil)
+
+	resp, body := doRequest(t, http.MethodGet, server.URL+"/v1/orders/100", "")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode, body)
+	assert.JSONEq(t, `{
+		"code": 5,
+		"message": "queried order is not found",
+		"details": [{
+			"@type": "type.googleapis.com/google.rpc.ErrorInfo",
+			"reason": "ORDER_NOT_FOUND",
+			"domain": "order.crtex",
+			"metadata": {"order_id": "100"}
+		}]
+	}`, body)
+}
+
+func TestGatewayAuth(t *testing.T) {
+	verifier := verifierFunc(func(_ context.Context, token string) (auth.Identity, error) {
+		if token != "user-1" {
+			return auth.Identity{}, errors.New("invalid token")
+		}
+		return auth.Identity{UserID: 1}, nil
+	})
+	server := startGateway(t, interceptors.AuthInterceptor(verifier, slog.Default()))
+
+	resp, body := doRequest(t, http.MethodPost, server.URL+"/v1/orders", `{"amount":"100"}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, body)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/orders", strings.NewReader(`{"userId":"1","amount":"100"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer user-1")
+
+	authResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	authResp.Body.Close()
+	assert.Equal(t, http.StatusOK, authResp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodPost, server.URL+"/v1/orders", strings.NewReader(`{"userId":"2","amount":"100"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer user-1")
+
+	authResp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	authResp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, authResp.StatusCode)
+}
+
+func TestGatewayRequestID(t *testing.T) {
+	server := startGateway(t, interceptors.RequestIDInterceptor())
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v1/orders/100", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Request-Id", "client-request-1")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "client-request-1", resp.Header.Get("X-Request-Id"))
+
+	resp, _ = doRequest(t, http.MethodGet, server.URL+"/v1/orders/100", "")
+	assert.NotEmpty(t, resp.Header.Get("X-Request-Id"), "request id must be generated when absent")
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), []ratelimit.Rule{
+		{Method: "/order.Order/CreateOrder", Limit: ratelimit.Limit{Rate: 0.1, Burst: 1}},
+	})
+	server := startGateway(t, interceptors.RateLimitInterceptor(limiter))
+
+	resp, body := doRequest(t, http.MethodPost, server.URL+"/v1/orders", `{"userId":"1","amount":"100"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+
+	resp, body = doRequest(t, http.MethodPost, server.URL+"/v1/orders", `{"userId":"1","amount":"100"}`)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, body)
+	assert.Equal(t, "10", resp.Header.Get("Retry-After"))
+}
+
+func TestGatewayValidation(t *testing.T) {
+	server := startGateway(t, interceptors.ValidationInterceptor())
+
+	resp, body := doRequest(t, http.MethodPost, server.URL+"/v1/orders", `{"userId":"-1","amount":"-1"}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+
+	var st struct {
+		Code    int `json:"code"`
+		Details []struct {
+			Type            string `json:"@type"`
+			FieldViolations []struct {
+				Field       string `json:"field"`
+				Description string `json:"description"`
+			} `json:"fieldViolations"`
+		} `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &st))
+	require.Len(t, st.Details, 1)
+	assert.Equal(t, "type.googleapis.com/google.rpc.BadRequest", st.Details[0].Type)
+	require.Len(t, st.Details[0].FieldViolations, 2)
+	assert.Equal(t, "user_id", st.Details[0].FieldViolations[0].Field)
+	assert.Equal(t, "amount", st.Details[0].FieldViolations[1].Field)
+
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/v1/orders/0", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+}
+
+type verifierFunc func(ctx context.Context, token string) (auth.Identity, error)
+
+func (f verifierFunc) Verify(ctx context.Context, token string) (auth.Identity, error) {
+	return f(ctx, token)
+}
+
+func TestGatewayOpenAPI(t *testing.T) {
+	server := startGateway(t, nil)
+
+	resp, body := doRequest(t, http.MethodGet, server.URL+"/openapi.yaml", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, "/v1/orders/{transactionId}")
+}
+
+func startGateway(t *testing.T, interceptor grpc.UnaryServerInterceptor) *httptest.Server {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	chain := []grpc.UnaryServerInterceptor{interceptors.ErrorInterceptor(slog.Default(), domain.ErrorDomain)}
+	if interceptor != nil {
+		chain = append(chain, interceptor)
+	}
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(chain...))
+	service := domain.NewOrderService(repository.NewMemoryOrderRepository())
+	proto.RegisterOrderServer(grpcServer, grpcHandler.NewOrderHandler(service))
+	go func() {
+		_ = grpcServer.Serve(ln)
+	}()
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	handler, err := NewHandler(context.Background(), conn)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		server.Close()
+		conn.Close()
+		grpcServer.Stop()
+	})
+
+	return server
+}
+
+func doRequest(t *testing.T, method, url, body string) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, string(data)
+}
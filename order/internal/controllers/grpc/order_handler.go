@@ -2,12 +2,12 @@ package grpc
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"strconv"
 
 	"github.com/hickar/crtex_test_assignment/order/internal/domain"
 	"github.com/hickar/crtex_test_assignment/order/proto"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
 )
 
 type GRPCOrderHandler struct {
@@ -20,13 +20,29 @@ func NewOrderHandler(service domain.Service) *GRPCOrderHandler {
 }
 
 func (h *GRPCOrderHandler) CreateOrder(ctx context.Context, req *proto.CreateOrderRequest) (*proto.CreateOrderResponse, error) {
+	userID := req.GetUserId()
+	if identity, ok := auth.IdentityFromContext(ctx); ok {
+		if !identity.Admin && userID != identity.UserID {
+			return nil, domain.ErrPermissionDenied
+		}
+	}
+
 	order := domain.Order{
-		UserID:      req.GetUserId(),
+		UserID:      userID,
 		AmountCents: req.GetAmount(),
+		Items:       make([]domain.OrderItem, 0, len(req.GetItems())),
+	}
+	for _, item := range req.GetItems() {
+		order.Items = append(order.Items, domain.OrderItem{
+			SKU:            item.GetSku(),
+			Name:           item.GetName(),
+			Quantity:       item.GetQuantity(),
+			UnitPriceCents: item.GetUnitPrice(),
+		})
 	}
 	orderID, err := h.service.CreateOrder(ctx, order)
 	if err != nil {
-		return nil, status.Error(getGRPCErrorCode(err), err.Error())
+		return nil, err
 	}
 
 	resp := &proto.CreateOrderResponse{
@@ -39,33 +55,34 @@ func (h *GRPCOrderHandler) CreateOrder(ctx context.Context, req *proto.CreateOrd
 func (h *GRPCOrderHandler) GetOrder(ctx context.Context, req *proto.GetOrderRequest) (*proto.GetOrderResponse, error) {
 	order, err := h.service.GetOrderByID(ctx, req.GetTransactionId())
 	if err != nil {
-		return nil, status.Error(getGRPCErrorCode(err), err.Error())
+		return nil, err
+	}
+
+	// Чужие заказы неотличимы от несуществующих, чтобы не раскрывать их наличие.
+	if identity, ok := auth.IdentityFromContext(ctx); ok && !identity.Admin && order.UserID != identity.UserID {
+		return nil, domain.ErrNotFound.WithMetadata("order_id", strconv.FormatInt(order.ID, 10))
 	}
 
 	statusNum, ok := proto.Status_value[order.Status]
 	if !ok {
-		return nil, status.Error(codes.Internal, "invalid output status value")
+		return nil, fmt.Errorf("invalid order status %q", order.Status)
 	}
 
-	return &proto.GetOrderResponse{
+	resp := &proto.GetOrderResponse{
 		Id:       order.ID,
 		ClientId: order.UserID,
 		Amount:   order.AmountCents,
 		Status:   proto.Status(statusNum),
-	}, nil
-}
-
-func getGRPCErrorCode(err error) codes.Code {
-	switch {
-	case errors.Is(err, context.Canceled):
-		return codes.Canceled
-	case errors.Is(err, context.DeadlineExceeded):
-		return codes.DeadlineExceeded
-	case errors.Is(err, domain.ErrNotFound):
-		return codes.NotFound
-	case errors.Is(err, domain.ErrInvalidData):
-		return codes.InvalidArgument
-	default:
-		return codes.Unknown
+		Items:    make([]*proto.OrderItem, 0, len(order.Items)),
+	}
+	for _, item := range order.Items {
+		resp.Items = append(resp.Items, &proto.OrderItem{
+			Sku:       item.SKU,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPriceCents,
+		})
 	}
+
+	return resp, nil
 }
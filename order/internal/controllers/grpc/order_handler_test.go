@@ -0,0 +1,114 @@
+//go:build unit_test
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/order/internal/domain"
+	"github.com/hickar/crtex_test_assignment/order/internal/repository"
+	"github.com/hickar/crtex_test_assignment/order/proto"
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
+)
+
+func TestCreateOrderAuthorization(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		userID   int64
+		expected int64
+		err      error
+	}{
+		{
+			name:     "Unauthenticated_AnyUser",
+			userID:   5,
+			expected: 5,
+		},
+		{
+			name:     "Owner",
+			identity: &auth.Identity{UserID: 5},
+			userID:   5,
+			expected: 5,
+		},
+		{
+			name:     "Owner_MissingUserID",
+			identity: &auth.Identity{UserID: 5},
+			err:      domain.ErrPermissionDenied,
+		},
+		{
+			name: "Unauthenticated_MissingUserID",
+			err:  domain.ErrInvalidData,
+		},
+		{
+			name:     "Admin_OtherUser",
+			identity: &auth.Identity{UserID: 1, Admin: true},
+			userID:   5,
+			expected: 5,
+		},
+		{
+			name:     "Forbidden_OtherUser",
+			identity: &auth.Identity{UserID: 1},
+			userID:   5,
+			err:      domain.ErrPermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryOrderRepository()
+			handler := NewOrderHandler(domain.NewOrderService(repo))
+
+			ctx := context.Background()
+			if tt.identity != nil {
+				ctx = auth.WithIdentity(ctx, *tt.identity)
+			}
+
+			resp, err := handler.CreateOrder(ctx, &proto.CreateOrderRequest{UserId: tt.userID, Amount: 100})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			order, err := repo.GetOrderByID(ctx, resp.TransactionId)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, order.UserID)
+		})
+	}
+}
+
+func TestGetOrderAuthorization(t *testing.T) {
+	repo := repository.NewMemoryOrderRepository()
+	handler := NewOrderHandler(domain.NewOrderService(repo))
+
+	order, err := repo.CreateOrder(context.Background(), domain.Order{UserID: 5, AmountCents: 100})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		identity auth.Identity
+		err      error
+	}{
+		{name: "Owner", identity: auth.Identity{UserID: 5}},
+		{name: "Admin", identity: auth.Identity{UserID: 1, Admin: true}},
+		{name: "OtherUser_NotFound", identity: auth.Identity{UserID: 1}, err: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := auth.WithIdentity(context.Background(), tt.identity)
+
+			resp, err := handler.GetOrder(ctx, &proto.GetOrderRequest{TransactionId: order.ID})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, order.ID, resp.Id)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/segmentio/kafka-go"
 
@@ -12,22 +11,19 @@ import (
 
 type OrderHandler struct {
 	service domain.Service
+	codec   *events.Codec
 }
 
-func NewOrderHandler(service domain.Service) *OrderHandler {
-	return &OrderHandler{service: service}
+func NewOrderHandler(service domain.Service, codec *events.Codec) *OrderHandler {
+	return &OrderHandler{service: service, codec: codec}
 }
 
 func (h *OrderHandler) NewAccountOrderEvent(ctx context.Context, message *kafka.Message) error {
-	var eventMsg AccountMessage
-	if err := json.Unmarshal(message.Value, &eventMsg); err != nil {
+	event, err := h.codec.DecodeAccountOrderPaymentEvent(ctx, message)
+	if err != nil {
 		return err
 	}
 
-	err := h.service.UpdateOrder(ctx, eventMsg.Payload)
+	err = h.service.UpdateOrder(ctx, event)
 	return err
 }
-
-type AccountMessage struct {
-	Payload events.AccountOrderPaymentEvent `json:"payload"`
-}
@@ -1,7 +1,16 @@
 package domain
 
-import "errors"
+import "github.com/hickar/crtex_test_assignment/pkg/apperror"
 
-var ErrNotFound = errors.New("queried order is not found")
+// ErrorDomain - домен ошибок сервиса в деталях google.rpc.ErrorInfo.
+const ErrorDomain = "order.crtex"
 
-var ErrInvalidData = errors.New("invalid input data provided")
+var ErrNotFound = apperror.New(apperror.CodeNotFound, "ORDER_NOT_FOUND", "queried order is not found")
+
+var ErrInvalidData = apperror.New(apperror.CodeInvalidArgument, "INVALID_ORDER", "invalid input data provided")
+
+var ErrPermissionDenied = apperror.New(
+	apperror.CodePermissionDenied,
+	"ORDER_ACCESS_DENIED",
+	"orders can only be created for the caller's account",
+)
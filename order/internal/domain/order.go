@@ -2,9 +2,12 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/hickar/crtex_test_assignment/events"
+	"github.com/hickar/crtex_test_assignment/pkg/apperror"
 )
 
 type Service interface {
@@ -18,8 +21,21 @@ type Order struct {
 	UserID      int64
 	AmountCents int64
 	Status      string
+	Items       []OrderItem
 }
 
+// OrderItem - позиция заказа. Name и UnitPriceCents - снимок товара
+// на момент оформления заказа.
+type OrderItem struct {
+	SKU            string
+	Name           string
+	Quantity       int64
+	UnitPriceCents int64
+}
+
+// MaxOrderAmountCents - наибольшая сумма заказа, 1 000 000 рублей.
+const MaxOrderAmountCents = 100_000_000
+
 type OrderRepository interface {
 	GetOrderByID(context.Context, int64) (Order, error)
 	CreateOrder(context.Context, Order) (Order, error)
@@ -38,8 +54,8 @@ func (s *OrderService) CreateOrder(ctx context.Context, order Order) (int64, err
 	var orderID int64
 	var err error
 
-	if !isValidOrderPayload(order) {
-		return orderID, ErrInvalidData
+	if violations := validateOrder(&order); len(violations) > 0 {
+		return orderID, ErrInvalidData.WithViolations(violations...)
 	}
 
 	order, err = s.repo.CreateOrder(ctx, order)
@@ -53,6 +69,9 @@ func (s *OrderService) CreateOrder(ctx context.Context, order Order) (int64, err
 
 func (s *OrderService) GetOrderByID(ctx context.Context, orderID int64) (Order, error) {
 	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if errors.Is(err, ErrNotFound) {
+		return order, ErrNotFound.WithMetadata("order_id", strconv.FormatInt(orderID, 10))
+	}
 	if err != nil {
 		return order, fmt.Errorf("failed to retrieve order by id: %w", err)
 	}
@@ -73,8 +92,65 @@ func (s *OrderService) UpdateOrder(ctx context.Context, event events.AccountOrde
 	return err
 }
 
-func isValidOrderPayload(order Order) bool {
-	return order.AmountCents > 0
+// validateOrder проверяет заказ и для заказа с позициями вычисляет его сумму.
+// Переданная клиентом сумма такого заказа должна совпадать с вычисленной.
+func validateOrder(order *Order) []apperror.FieldViolation {
+	var violations []apperror.FieldViolation
+	violation := func(field, description string) {
+		violations = append(violations, apperror.FieldViolation{Field: field, Description: description})
+	}
+
+	if order.UserID <= 0 {
+		violation("user_id", "must be greater than 0")
+	}
+
+	if len(order.Items) == 0 {
+		if order.AmountCents <= 0 {
+			violation("amount", "must be greater than 0")
+		}
+		if order.AmountCents > MaxOrderAmountCents {
+			violation("amount", fmt.Sprintf("must be less than or equal to %d", MaxOrderAmountCents))
+		}
+		return violations
+	}
+
+	var total int64
+	for i, item := range order.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		if item.SKU == "" {
+			violation(field+".sku", "is required")
+		}
+		if item.Name == "" {
+			violation(field+".name", "is required")
+		}
+		if item.Quantity <= 0 {
+			violation(field+".quantity", "must be greater than 0")
+		}
+		if item.UnitPriceCents <= 0 {
+			violation(field+".unit_price", "must be greater than 0")
+		}
+		if item.Quantity <= 0 || item.UnitPriceCents <= 0 {
+			continue
+		}
+
+		// Сумма сравнивается с пределом до умножения, чтобы избежать переполнения.
+		if item.Quantity > (MaxOrderAmountCents-total)/item.UnitPriceCents {
+			violation("items", fmt.Sprintf("total amount must be less than or equal to %d", MaxOrderAmountCents))
+			return violations
+		}
+		total += item.Quantity * item.UnitPriceCents
+	}
+	if len(violations) > 0 {
+		return violations
+	}
+
+	if order.AmountCents != 0 && order.AmountCents != total {
+		violation("amount", fmt.Sprintf("must be equal to the sum of items (%d)", total))
+		return violations
+	}
+	order.AmountCents = total
+
+	return nil
 }
 
 func isValidOrderEventPayload(event events.AccountOrderPaymentEvent) bool {
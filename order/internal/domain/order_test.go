@@ -8,8 +8,10 @@ import (
 	"testing"
 
 	"github.com/hickar/crtex_test_assignment/events"
+	"github.com/hickar/crtex_test_assignment/pkg/apperror"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateOrder(t *testing.T) {
@@ -64,6 +66,94 @@ func TestCreateOrder(t *testing.T) {
 	}
 }
 
+func TestCreateOrderViolations(t *testing.T) {
+	service := NewOrderService(newOrderRepoStub(nil, nil, nil))
+
+	_, err := service.CreateOrder(context.Background(), Order{UserID: 1})
+
+	appErr, ok := apperror.From(err)
+	require.True(t, ok)
+	assert.Equal(t, []apperror.FieldViolation{{Field: "amount", Description: "must be greater than 0"}}, appErr.Violations)
+}
+
+func TestCreateOrderUserID(t *testing.T) {
+	service := NewOrderService(newOrderRepoStub(nil, nil, nil))
+
+	for _, userID := range []int64{0, -1} {
+		_, err := service.CreateOrder(context.Background(), Order{UserID: userID, AmountCents: 100})
+
+		appErr, ok := apperror.From(err)
+		require.True(t, ok)
+		assert.Equal(t, []apperror.FieldViolation{{Field: "user_id", Description: "must be greater than 0"}}, appErr.Violations)
+	}
+}
+
+func TestCreateOrderItems(t *testing.T) {
+	var created Order
+	service := NewOrderService(newOrderRepoStub(nil, func(_ context.Context, order Order) (Order, error) {
+		created = order
+		order.ID = 1
+		return order, nil
+	}, nil))
+
+	items := []OrderItem{
+		{SKU: "BOOK-1", Name: "Book", Quantity: 2, UnitPriceCents: 1500},
+		{SKU: "PEN-7", Name: "Pen", Quantity: 1, UnitPriceCents: 250},
+	}
+
+	_, err := service.CreateOrder(context.Background(), Order{UserID: 1, Items: items})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3250, created.AmountCents, "total must be computed from items")
+	assert.Equal(t, items, created.Items)
+
+	_, err = service.CreateOrder(context.Background(), Order{UserID: 1, AmountCents: 3250, Items: items})
+	require.NoError(t, err, "matching client total must be accepted")
+
+	tests := []struct {
+		name       string
+		order      Order
+		violations []apperror.FieldViolation
+	}{
+		{
+			name:  "AmountMismatch",
+			order: Order{UserID: 1, AmountCents: 100, Items: items},
+			violations: []apperror.FieldViolation{
+				{Field: "amount", Description: "must be equal to the sum of items (3250)"},
+			},
+		},
+		{
+			name: "InvalidItem",
+			order: Order{UserID: 1, Items: []OrderItem{
+				items[0],
+				{SKU: "", Name: "Pen", Quantity: 0, UnitPriceCents: 250},
+			}},
+			violations: []apperror.FieldViolation{
+				{Field: "items[1].sku", Description: "is required"},
+				{Field: "items[1].quantity", Description: "must be greater than 0"},
+			},
+		},
+		{
+			name: "TotalOverflow",
+			order: Order{UserID: 1, Items: []OrderItem{
+				{SKU: "CAR", Name: "Car", Quantity: 1 << 40, UnitPriceCents: 1 << 40},
+			}},
+			violations: []apperror.FieldViolation{
+				{Field: "items", Description: "total amount must be less than or equal to 100000000"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateOrder(context.Background(), tt.order)
+			require.ErrorIs(t, err, ErrInvalidData)
+
+			appErr, _ := apperror.From(err)
+			assert.Equal(t, tt.violations, appErr.Violations)
+		})
+	}
+}
+
 func TestGetOrderByID(t *testing.T) {
 	repo := newOrderRepoStub(func(_ context.Context, orderID int64) (Order, error) {
 		if orderID == 0 {
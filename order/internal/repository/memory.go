@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/hickar/crtex_test_assignment/events"
+
+	"github.com/hickar/crtex_test_assignment/order/internal/domain"
+)
+
+// MemoryOrderRepository - in-memory реализация domain.OrderRepository.
+// Как и OrderRepository, вместе с заказом атомарно записывает событие
+// в outbox, которое затем вычитывается через OutboxEvents.
+type MemoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[int64]domain.Order
+	outbox []events.OrderCreatedEvent
+}
+
+func NewMemoryOrderRepository() *MemoryOrderRepository {
+	return &MemoryOrderRepository{
+		orders: make(map[int64]domain.Order),
+	}
+}
+
+func (r *MemoryOrderRepository) CreateOrder(_ context.Context, order domain.Order) (domain.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order.ID = int64(len(r.orders)) + 1
+	order.Status = string(events.OrderStatusCreated)
+	order.Items = slices.Clone(order.Items)
+	r.orders[order.ID] = order
+
+	r.outbox = append(r.outbox, events.OrderCreatedEvent{
+		ID:          int64(len(r.outbox)) + 1,
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		AmountCents: order.AmountCents,
+	})
+
+	return order, nil
+}
+
+func (r *MemoryOrderRepository) GetOrderByID(_ context.Context, orderID int64) (domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order, ok := r.orders[orderID]
+	if !ok {
+		return domain.Order{}, domain.ErrNotFound
+	}
+	order.Items = slices.Clone(order.Items)
+
+	return order, nil
+}
+
+func (r *MemoryOrderRepository) UpdateOrderStatusByID(_ context.Context, orderID int64, orderStatus string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[orderID]
+	if !ok {
+		return nil
+	}
+
+	order.Status = orderStatus
+	r.orders[orderID] = order
+
+	return nil
+}
+
+// OutboxEvents возвращает события outbox'а с идентификатором больше afterID.
+func (r *MemoryOrderRepository) OutboxEvents(afterID int64) []events.OrderCreatedEvent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if afterID < 0 || afterID >= int64(len(r.outbox)) {
+		return nil
+	}
+
+	return append([]events.OrderCreatedEvent(nil), r.outbox[afterID:]...)
+}
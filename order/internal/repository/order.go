@@ -41,6 +41,21 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order domain.Order) (
 		return order, err
 	}
 
+	if len(order.Items) > 0 {
+		_, err = tx.CopyFrom(
+			ctx,
+			pgx.Identifier{"order_items"},
+			[]string{"order_id", "sku", "name", "quantity", "unit_price_cents"},
+			pgx.CopyFromSlice(len(order.Items), func(i int) ([]any, error) {
+				item := order.Items[i]
+				return []any{order.ID, item.SKU, item.Name, item.Quantity, item.UnitPriceCents}, nil
+			}),
+		)
+		if err != nil {
+			return order, err
+		}
+	}
+
 	query = `INSERT INTO order_create_events (order_id, amount_cents, user_id) VALUES ($1, $2, $3);`
 	_, err = tx.Exec(
 		ctx,
@@ -77,6 +92,22 @@ func (r *OrderRepository) GetOrderByID(ctx context.Context, orderID int64) (doma
 	if errors.Is(err, pgx.ErrNoRows) {
 		return order, domain.ErrNotFound
 	}
+	if err != nil {
+		return order, err
+	}
+
+	query = `SELECT sku, name, quantity, unit_price_cents FROM order_items WHERE order_id = $1 ORDER BY id;`
+
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return order, err
+	}
+
+	order.Items, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.OrderItem, error) {
+		var item domain.OrderItem
+		err := row.Scan(&item.SKU, &item.Name, &item.Quantity, &item.UnitPriceCents)
+		return item, err
+	})
 
 	return order, err
 }
@@ -7,11 +7,23 @@ CREATE TABLE IF NOT EXISTS orders (
   status ORDER_STATUS NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS order_items (
+  id BIGSERIAL PRIMARY KEY,
+  order_id BIGINT NOT NULL REFERENCES orders ON DELETE CASCADE,
+  sku TEXT NOT NULL,
+  name TEXT NOT NULL,
+  quantity BIGINT NOT NULL CHECK (quantity > 0),
+  unit_price_cents BIGINT NOT NULL CHECK (unit_price_cents > 0)
+);
+
+CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items (order_id);
+
 CREATE TABLE IF NOT EXISTS order_create_events (
   id BIGSERIAL PRIMARY KEY,
   order_id BIGINT REFERENCES orders ON DELETE RESTRICT,
   amount_cents BIGINT NOT NULL,
-  user_id BIGINT NOT NULL
+  user_id BIGINT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
 ALTER TABLE order_create_events REPLICA IDENTITY FULL;
@@ -19,3 +31,9 @@ ALTER TABLE order_create_events REPLICA IDENTITY FULL;
 CREATE PUBLICATION order_events_publication FOR TABLE order_create_events;
 
 SELECT pg_create_logical_replication_slot('order_events_replication', 'pgoutput');
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+  key TEXT PRIMARY KEY,
+  tokens DOUBLE PRECISION NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,8 @@
+package proto
+
+import _ "embed"
+
+// OpenAPI - описание REST/JSON API сервиса, сгенерированное из order.proto.
+//
+//go:embed order.swagger.yaml
+var OpenAPI []byte
@@ -0,0 +1,120 @@
+// Package apperror описывает ошибки предметной области, которые передаются
+// клиентам: код, машиночитаемая причина, безопасное сообщение и метаданные.
+// Исходная ошибка (Cause) клиенту не передаётся и используется только в журнале.
+package apperror
+
+import (
+	"errors"
+	"maps"
+	"time"
+)
+
+// Code - категория ошибки. Коды соответствуют кодам GRPC, в которые
+// они преобразуются на границе сервиса.
+type Code int
+
+const (
+	CodeInternal Code = iota
+	CodeInvalidArgument
+	CodeNotFound
+	CodeAlreadyExists
+	CodePermissionDenied
+	CodeUnauthenticated
+	CodeFailedPrecondition
+	CodeResourceExhausted
+	CodeUnavailable
+)
+
+// FieldViolation - нарушение ограничения поля запроса.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+type Error struct {
+	Code Code
+	// Reason - постоянный идентификатор причины в UPPER_SNAKE_CASE,
+	// например ORDER_NOT_FOUND.
+	Reason string
+	// Message - текст для клиента, не должен содержать внутренних деталей.
+	Message    string
+	Metadata   map[string]string
+	Violations []FieldViolation
+	// RetryAfter - через сколько имеет смысл повторить запрос, если задано.
+	RetryAfter time.Duration
+	Cause      error
+}
+
+func New(code Code, reason, message string) *Error {
+	return &Error{Code: code, Reason: reason, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is сравнивает ошибки по коду и причине, поэтому копии, созданные With*
+// и Wrap, остаются равными исходной ошибке для errors.Is.
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if !errors.As(target, &t) {
+		return false
+	}
+
+	return e.Code == t.Code && e.Reason == t.Reason
+}
+
+// Wrap возвращает копию ошибки с исходной ошибкой cause.
+func (e *Error) Wrap(cause error) *Error {
+	c := e.clone()
+	c.Cause = cause
+	return c
+}
+
+// WithMetadata возвращает копию ошибки с добавленной парой метаданных.
+func (e *Error) WithMetadata(key, value string) *Error {
+	c := e.clone()
+	c.Metadata = maps.Clone(e.Metadata)
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string, 1)
+	}
+	c.Metadata[key] = value
+	return c
+}
+
+// WithViolations возвращает копию ошибки с нарушениями полей запроса.
+func (e *Error) WithViolations(violations ...FieldViolation) *Error {
+	c := e.clone()
+	c.Violations = append(append([]FieldViolation(nil), e.Violations...), violations...)
+	return c
+}
+
+// WithRetryAfter возвращает копию ошибки с рекомендуемой задержкой повтора.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	c := e.clone()
+	c.RetryAfter = d
+	return c
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	return &c
+}
+
+// From возвращает первую *Error в цепочке err.
+func From(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+
+	return nil, false
+}
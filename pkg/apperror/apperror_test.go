@@ -0,0 +1,33 @@
+//go:build unit_test
+
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNotFound = New(CodeNotFound, "ORDER_NOT_FOUND", "order not found")
+
+func TestError(t *testing.T) {
+	cause := errors.New("no rows in result set")
+	err := fmt.Errorf("failed to retrieve order: %w", errNotFound.WithMetadata("order_id", "1").Wrap(cause))
+
+	assert.ErrorIs(t, err, errNotFound, "copies must match the original error")
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, New(CodeNotFound, "ACCOUNT_NOT_FOUND", "account not found"))
+	assert.Equal(t, "failed to retrieve order: order not found: no rows in result set", err.Error())
+
+	appErr, ok := From(err)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"order_id": "1"}, appErr.Metadata)
+	assert.Nil(t, errNotFound.Metadata, "original error must not be modified")
+	assert.Nil(t, errNotFound.Cause)
+
+	_, ok = From(cause)
+	assert.False(t, ok)
+}
@@ -0,0 +1,33 @@
+// Package auth проверяет JWT и передаёт личность вызывающего через контекст.
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Identity - личность вызывающего, полученная из claims токена.
+type Identity struct {
+	UserID int64
+	Scopes []string
+	// Admin означает наличие scope'а администратора: такой вызывающий
+	// может работать с данными любых пользователей.
+	Admin bool
+}
+
+func (i Identity) HasScope(scope string) bool {
+	return slices.Contains(i.Scopes, scope)
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext возвращает личность вызывающего. ok равен false,
+// если запрос не проходил аутентификацию.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
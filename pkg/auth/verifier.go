@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrNoKeys       = errors.New("neither hmac secret nor jwks is configured")
+)
+
+type Configuration struct {
+	// HMACSecret - общий секрет для токенов, подписанных HS256.
+	HMACSecret string
+	// JWKSFile и JWKSURL - источник открытых ключей для токенов, подписанных RS256.
+	JWKSFile string
+	JWKSURL  string
+	// JWKSRefreshInterval ограничивает частоту перечитывания JWKS по URL
+	// при появлении токенов с неизвестным kid.
+	JWKSRefreshInterval time.Duration
+	Issuer              string
+	Audience            string
+	AdminScope          string
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	// Scope - scope'ы через пробел (RFC 8693), Scp - массивом, как у некоторых провайдеров.
+	Scope string   `json:"scope"`
+	Scp   []string `json:"scp"`
+}
+
+// Verifier проверяет подпись и срок действия токена и извлекает из него
+// личность вызывающего: идентификатор пользователя берётся из claim'а sub.
+type Verifier struct {
+	secret     []byte
+	keys       *keySet
+	adminScope string
+	parser     *jwt.Parser
+}
+
+func NewVerifier(ctx context.Context, cfg Configuration) (*Verifier, error) {
+	v := &Verifier{adminScope: cfg.AdminScope}
+
+	var methods []string
+	if cfg.HMACSecret != "" {
+		v.secret = []byte(cfg.HMACSecret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	var err error
+	switch {
+	case cfg.JWKSFile != "":
+		v.keys, err = loadKeySetFile(cfg.JWKSFile)
+	case cfg.JWKSURL != "":
+		if cfg.JWKSRefreshInterval <= 0 {
+			cfg.JWKSRefreshInterval = time.Minute
+		}
+		v.keys, err = loadKeySetURL(ctx, cfg.JWKSURL, cfg.JWKSRefreshInterval)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if v.keys != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	if len(methods) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+func (v *Verifier) Verify(ctx context.Context, token string) (Identity, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, func(t *jwt.Token) (any, error) {
+		switch t.Method.Alg() {
+		case jwt.SigningMethodHS256.Alg():
+			return v.secret, nil
+		case jwt.SigningMethodRS256.Alg():
+			kid, _ := t.Header["kid"].(string)
+			return v.keys.key(ctx, kid)
+		default:
+			return nil, fmt.Errorf("unexpected signing method %q", t.Method.Alg())
+		}
+	})
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	userID, err := strconv.ParseInt(c.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return Identity{}, fmt.Errorf("%w: subject %q is not a user id", ErrInvalidToken, c.Subject)
+	}
+
+	scopes := append(strings.Fields(c.Scope), c.Scp...)
+
+	return Identity{
+		UserID: userID,
+		Scopes: scopes,
+		Admin:  v.adminScope != "" && slices.Contains(scopes, v.adminScope),
+	}, nil
+}
@@ -0,0 +1,228 @@
+//go:build unit_test
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+func TestVerifierHS256(t *testing.T) {
+	verifier, err := NewVerifier(context.Background(), Configuration{
+		HMACSecret: testSecret,
+		Issuer:     "auth.test",
+		AdminScope: "orders:admin",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		token    string
+		expected Identity
+		err      bool
+	}{
+		{
+			name:     "User",
+			token:    signHS256(t, testClaims("7", "orders:read orders:write")),
+			expected: Identity{UserID: 7, Scopes: []string{"orders:read", "orders:write"}},
+		},
+		{
+			name:     "Admin",
+			token:    signHS256(t, testClaims("1", "orders:admin")),
+			expected: Identity{UserID: 1, Scopes: []string{"orders:admin"}, Admin: true},
+		},
+		{
+			name: "Invalid_Expired",
+			token: signHS256(t, jwt.MapClaims{
+				"sub": "7",
+				"iss": "auth.test",
+				"exp": time.Now().Add(-time.Hour).Unix(),
+			}),
+			err: true,
+		},
+		{
+			name:  "Invalid_NoExpiration",
+			token: signHS256(t, jwt.MapClaims{"sub": "7", "iss": "auth.test"}),
+			err:   true,
+		},
+		{
+			name: "Invalid_Issuer",
+			token: signHS256(t, jwt.MapClaims{
+				"sub": "7",
+				"iss": "other",
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}),
+			err: true,
+		},
+		{
+			name:  "Invalid_Subject",
+			token: signHS256(t, testClaims("service-account", "")),
+			err:   true,
+		},
+		{
+			name: "Invalid_Signature",
+			token: func() string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims("7", "")).SignedString([]byte("other"))
+				require.NoError(t, err)
+				return token
+			}(),
+			err: true,
+		},
+		{
+			name:  "Invalid_UnexpectedMethod",
+			token: signRS256(t, newRSAKey(t), "key-1", testClaims("7", "")),
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := verifier.Verify(context.Background(), tt.token)
+			if tt.err {
+				assert.ErrorIs(t, err, ErrInvalidToken)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, identity)
+		})
+	}
+}
+
+func TestVerifierRS256File(t *testing.T) {
+	key := newRSAKey(t)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks(t, map[string]*rsa.PrivateKey{"key-1": key}), 0o600))
+
+	verifier, err := NewVerifier(context.Background(), Configuration{JWKSFile: path})
+	require.NoError(t, err)
+
+	identity, err := verifier.Verify(context.Background(), signRS256(t, key, "key-1", testClaims("3", "")))
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, identity.UserID)
+
+	_, err = verifier.Verify(context.Background(), signRS256(t, key, "key-2", testClaims("3", "")))
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, err = verifier.Verify(context.Background(), signRS256(t, newRSAKey(t), "key-1", testClaims("3", "")))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifierRS256URL_KeyRotation(t *testing.T) {
+	oldKey, newKey := newRSAKey(t), newRSAKey(t)
+
+	var (
+		mu       sync.Mutex
+		keys     = map[string]*rsa.PrivateKey{"old": oldKey}
+		requests int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		_, _ = w.Write(jwks(t, keys))
+	}))
+	defer server.Close()
+
+	verifier, err := NewVerifier(context.Background(), Configuration{
+		JWKSURL:             server.URL,
+		JWKSRefreshInterval: time.Nanosecond,
+	})
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(context.Background(), signRS256(t, oldKey, "old", testClaims("3", "")))
+	require.NoError(t, err)
+
+	mu.Lock()
+	keys["new"] = newKey
+	mu.Unlock()
+
+	_, err = verifier.Verify(context.Background(), signRS256(t, newKey, "new", testClaims("3", "")))
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestNewVerifier_NoKeys(t *testing.T) {
+	_, err := NewVerifier(context.Background(), Configuration{})
+	assert.ErrorIs(t, err, ErrNoKeys)
+}
+
+func testClaims(subject, scope string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   subject,
+		"iss":   "auth.test",
+		"scope": scope,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+
+	return token
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	return key
+}
+
+func jwks(t *testing.T, keys map[string]*rsa.PrivateKey) []byte {
+	t.Helper()
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, jsonWebKey{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	return data
+}
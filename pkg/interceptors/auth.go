@@ -0,0 +1,87 @@
+package interceptors
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
+)
+
+// TokenVerifier проверяет токен и возвращает личность вызывающего.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (auth.Identity, error)
+}
+
+// AuthInterceptor требует у каждого запроса, кроме publicMethods, заголовок
+// "authorization: Bearer <token>" и передаёт личность вызывающего в контекст.
+// Причина отклонения токена клиенту не передаётся и пишется в logger.
+func AuthInterceptor(verifier TokenVerifier, logger *slog.Logger, publicMethods ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if slices.Contains(publicMethods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, verifier, logger, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func AuthStreamInterceptor(verifier TokenVerifier, logger *slog.Logger, publicMethods ...string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if slices.Contains(publicMethods, info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authenticate(ss.Context(), verifier, logger, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, verifier TokenVerifier, logger *slog.Logger, method string) (context.Context, error) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	identity, err := verifier.Verify(ctx, token)
+	if err != nil {
+		logger.WarnContext(ctx, "bearer token rejected",
+			slog.String("method", method),
+			slog.Any("error", err),
+		)
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+
+	return auth.WithIdentity(ctx, identity), nil
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	for _, value := range md.Get("authorization") {
+		scheme, token, found := strings.Cut(value, " ")
+		if found && strings.EqualFold(scheme, "bearer") && token != "" {
+			return strings.TrimSpace(token), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,131 @@
+//go:build unit_test
+
+package interceptors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
+)
+
+type verifierFunc func(ctx context.Context, token string) (auth.Identity, error)
+
+func (f verifierFunc) Verify(ctx context.Context, token string) (auth.Identity, error) {
+	return f(ctx, token)
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	verifier := verifierFunc(func(_ context.Context, token string) (auth.Identity, error) {
+		if token != "valid" {
+			return auth.Identity{}, errors.New("invalid token")
+		}
+		return auth.Identity{UserID: 7}, nil
+	})
+	interceptor := AuthInterceptor(verifier, slog.Default(), "/order.Order/Public")
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		code          codes.Code
+		identity      *auth.Identity
+	}{
+		{
+			name:          "Valid",
+			method:        "/order.Order/GetOrder",
+			authorization: "Bearer valid",
+			identity:      &auth.Identity{UserID: 7},
+		},
+		{
+			name:          "Valid_LowercaseScheme",
+			method:        "/order.Order/GetOrder",
+			authorization: "bearer valid",
+			identity:      &auth.Identity{UserID: 7},
+		},
+		{
+			name:   "PublicMethod",
+			method: "/order.Order/Public",
+		},
+		{
+			name:   "Invalid_MissingToken",
+			method: "/order.Order/GetOrder",
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:          "Invalid_BasicAuth",
+			method:        "/order.Order/GetOrder",
+			authorization: "Basic dXNlcjpwYXNz",
+			code:          codes.Unauthenticated,
+		},
+		{
+			name:          "Invalid_Token",
+			method:        "/order.Order/GetOrder",
+			authorization: "Bearer forged",
+			code:          codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+			}
+
+			var (
+				called   bool
+				identity auth.Identity
+				ok       bool
+			)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, func(ctx context.Context, _ any) (any, error) {
+				called = true
+				identity, ok = auth.IdentityFromContext(ctx)
+				return nil, nil
+			})
+
+			if tt.code != codes.OK {
+				assert.Equal(t, tt.code, status.Code(err))
+				assert.False(t, called)
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, called)
+			if tt.identity != nil {
+				assert.True(t, ok)
+				assert.Equal(t, *tt.identity, identity)
+			} else {
+				assert.False(t, ok)
+			}
+		})
+	}
+}
+
+func TestAuthInterceptor_HidesVerifyError(t *testing.T) {
+	verifier := verifierFunc(func(context.Context, string) (auth.Identity, error) {
+		return auth.Identity{}, errors.New("token is expired by 1h0m0s")
+	})
+	var logs bytes.Buffer
+	interceptor := AuthInterceptor(verifier, slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer expired"))
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/order.Order/GetOrder"}, func(context.Context, any) (any, error) {
+		return nil, nil
+	})
+
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+	assert.Equal(t, "invalid bearer token", st.Message())
+	assert.Contains(t, logs.String(), "token is expired by 1h0m0s")
+}
@@ -0,0 +1,71 @@
+package interceptors
+
+import (
+	"log/slog"
+
+	"google.golang.org/grpc"
+
+	"github.com/hickar/crtex_test_assignment/pkg/ratelimit"
+)
+
+// ChainConfiguration - набор интерсепторов GRPC-сервера. Необязательные
+// интерсепторы отключаются, если соответствующее поле не задано.
+type ChainConfiguration struct {
+	Logger *slog.Logger
+	// ErrorDomain - домен сервиса в деталях google.rpc.ErrorInfo, например "order.crtex".
+	ErrorDomain string
+	// Metrics включает сбор метрик запросов.
+	Metrics *Metrics
+	// Verifier включает аутентификацию всех методов, кроме PublicMethods.
+	Verifier      TokenVerifier
+	PublicMethods []string
+	// RateLimiter включает ограничение частоты и числа одновременных запросов.
+	RateLimiter *ratelimit.Limiter
+}
+
+// ServerOptions возвращает опции grpc.NewServer с цепочками унарных и потоковых
+// интерсепторов в одинаковом порядке: идентификатор запроса, метрики,
+// журналирование, преобразование ошибок, восстановление после паники,
+// аутентификация, ограничение запросов, проверка запроса.
+func ServerOptions(cfg ChainConfiguration) []grpc.ServerOption {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	unary := []grpc.UnaryServerInterceptor{RequestIDInterceptor()}
+	stream := []grpc.StreamServerInterceptor{RequestIDStreamInterceptor()}
+
+	if cfg.Metrics != nil {
+		unary = append(unary, MetricsInterceptor(cfg.Metrics))
+		stream = append(stream, MetricsStreamInterceptor(cfg.Metrics))
+	}
+
+	unary = append(unary,
+		LoggerInterceptor(cfg.Logger),
+		ErrorInterceptor(cfg.Logger, cfg.ErrorDomain),
+		RecoveryInterceptor(cfg.Logger),
+	)
+	stream = append(stream,
+		LoggerStreamInterceptor(cfg.Logger),
+		ErrorStreamInterceptor(cfg.Logger, cfg.ErrorDomain),
+		RecoveryStreamInterceptor(cfg.Logger),
+	)
+
+	if cfg.Verifier != nil {
+		unary = append(unary, AuthInterceptor(cfg.Verifier, cfg.Logger, cfg.PublicMethods...))
+		stream = append(stream, AuthStreamInterceptor(cfg.Verifier, cfg.Logger, cfg.PublicMethods...))
+	}
+
+	if cfg.RateLimiter != nil {
+		unary = append(unary, RateLimitInterceptor(cfg.RateLimiter))
+		stream = append(stream, RateLimitStreamInterceptor(cfg.RateLimiter))
+	}
+
+	unary = append(unary, ValidationInterceptor())
+	stream = append(stream, ValidationStreamInterceptor())
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
@@ -0,0 +1,156 @@
+//go:build unit_test
+
+package interceptors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
+	"github.com/hickar/crtex_test_assignment/pkg/requestid"
+)
+
+func TestServerOptions(t *testing.T) {
+	var logs syncBuffer
+	registry := prometheus.NewRegistry()
+	metrics, err := NewMetrics(registry)
+	require.NoError(t, err)
+
+	verifier := verifierFunc(func(_ context.Context, token string) (auth.Identity, error) {
+		if token != "valid" {
+			return auth.Identity{}, errors.New("invalid token")
+		}
+		return auth.Identity{UserID: 1}, nil
+	})
+
+	client := startHealthServer(t, ServerOptions(ChainConfiguration{
+		Logger:   slog.New(requestid.NewLogHandler(slog.NewJSONHandler(&logs, nil))),
+		Metrics:  metrics,
+		Verifier: verifier,
+	}))
+
+	authorized := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer valid")
+
+	t.Run("Unary", func(t *testing.T) {
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		var header metadata.MD
+		resp, err := client.Check(authorized, &healthpb.HealthCheckRequest{}, grpc.Header(&header))
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+		assert.NotEmpty(t, header.Get(requestid.MetadataKey))
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(authorized, requestid.MetadataKey, "stream-1"))
+		stream, err = client.Watch(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+		cancel()
+
+		assert.Eventually(t, func() bool {
+			return strings.Contains(logs.String(), `"request_id":"stream-1"`)
+		}, time.Second, 10*time.Millisecond, "stream must be logged with request id")
+	})
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	handled := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "grpc_server_handled_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			key := ""
+			for _, label := range metric.GetLabel() {
+				key += label.GetValue() + " "
+			}
+			handled[key] = metric.GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, 1.0, handled["Unauthenticated /grpc.health.v1.Health/Check unary "])
+	assert.Equal(t, 1.0, handled["OK /grpc.health.v1.Health/Check unary "])
+	assert.Equal(t, 1.0, handled["Unauthenticated /grpc.health.v1.Health/Watch server_stream "])
+}
+
+func TestServerOptions_PublicMethods(t *testing.T) {
+	client := startHealthServer(t, ServerOptions(ChainConfiguration{
+		Verifier: verifierFunc(func(context.Context, string) (auth.Identity, error) {
+			return auth.Identity{}, errors.New("invalid token")
+		}),
+		PublicMethods: []string{"/grpc.health.v1.Health/Check"},
+	}))
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+}
+
+// syncBuffer - bytes.Buffer, в который сервер пишет журнал из своих горутин.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func startHealthServer(t *testing.T, opts []grpc.ServerOption) healthpb.HealthClient {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() {
+		_ = server.Serve(ln)
+	}()
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+	})
+
+	return healthpb.NewHealthClient(conn)
+}
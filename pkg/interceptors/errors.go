@@ -0,0 +1,124 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/hickar/crtex_test_assignment/pkg/apperror"
+)
+
+// ErrorInterceptor преобразует ошибки обработчиков в GRPC-статусы.
+// Для apperror.Error клиент получает код, сообщение и детали ErrorInfo
+// (с доменом domain), BadRequest и RetryInfo. Прочие ошибки возвращаются
+// как Internal без текста. Скрытые от клиента причины пишутся в журнал.
+// Ошибки, уже имеющие GRPC-статус, возвращаются без изменений.
+func ErrorInterceptor(logger *slog.Logger, domain string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		return resp, mapError(ctx, logger, domain, info.FullMethod, err)
+	}
+}
+
+func ErrorStreamInterceptor(logger *slog.Logger, domain string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return mapError(ss.Context(), logger, domain, info.FullMethod, handler(srv, ss))
+	}
+}
+
+func mapError(ctx context.Context, logger *slog.Logger, domain, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	if appErr, ok := apperror.From(err); ok && appErr.Code != apperror.CodeInternal {
+		if appErr.Cause != nil {
+			logger.WarnContext(ctx, "request failed",
+				slog.String("method", method),
+				slog.String("reason", appErr.Reason),
+				slog.Any("error", err),
+			)
+		}
+
+		return appErrorStatus(appErr, domain).Err()
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, context.Canceled.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, context.DeadlineExceeded.Error())
+	}
+
+	logger.ErrorContext(ctx, "internal error",
+		slog.String("method", method),
+		slog.Any("error", err),
+	)
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+func appErrorStatus(appErr *apperror.Error, domain string) *status.Status {
+	st := status.New(grpcCode(appErr.Code), appErr.Message)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   appErr.Reason,
+		Domain:   domain,
+		Metadata: appErr.Metadata,
+	}}
+
+	if len(appErr.Violations) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, v := range appErr.Violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Description,
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	if appErr.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(appErr.RetryAfter)})
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+
+	return withDetails
+}
+
+func grpcCode(code apperror.Code) codes.Code {
+	switch code {
+	case apperror.CodeInvalidArgument:
+		return codes.InvalidArgument
+	case apperror.CodeNotFound:
+		return codes.NotFound
+	case apperror.CodeAlreadyExists:
+		return codes.AlreadyExists
+	case apperror.CodePermissionDenied:
+		return codes.PermissionDenied
+	case apperror.CodeUnauthenticated:
+		return codes.Unauthenticated
+	case apperror.CodeFailedPrecondition:
+		return codes.FailedPrecondition
+	case apperror.CodeResourceExhausted:
+		return codes.ResourceExhausted
+	case apperror.CodeUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
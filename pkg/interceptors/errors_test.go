@@ -0,0 +1,123 @@
+//go:build unit_test
+
+package interceptors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hickar/crtex_test_assignment/pkg/apperror"
+)
+
+var errTestNotFound = apperror.New(apperror.CodeNotFound, "ORDER_NOT_FOUND", "order not found")
+
+func TestErrorInterceptor(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+		msg  string
+	}{
+		{name: "Status", err: status.Error(codes.NotFound, "not found"), code: codes.NotFound, msg: "not found"},
+		{name: "Canceled", err: fmt.Errorf("query: %w", context.Canceled), code: codes.Canceled},
+		{name: "DeadlineExceeded", err: context.DeadlineExceeded, code: codes.DeadlineExceeded},
+		{name: "Internal", err: errors.New("pq: connection refused"), code: codes.Internal, msg: "internal error"},
+		{
+			name: "AppError_Wrapped",
+			err:  fmt.Errorf("failed to retrieve order by id: %w", errTestNotFound),
+			code: codes.NotFound,
+			msg:  "order not found",
+		},
+		{
+			name: "AppError_InternalCode",
+			err:  apperror.New(apperror.CodeInternal, "STORAGE", "storage failure"),
+			code: codes.Internal,
+			msg:  "internal error",
+		},
+		{name: "NoError", code: codes.OK},
+	}
+
+	interceptor := ErrorInterceptor(slog.Default(), "order.test")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) {
+				return nil, tt.err
+			})
+
+			st := status.Convert(err)
+			assert.Equal(t, tt.code, st.Code())
+			if tt.msg != "" {
+				assert.Equal(t, tt.msg, st.Message())
+			}
+		})
+	}
+}
+
+func TestErrorInterceptor_Details(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := ErrorInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)), "order.test")
+	info := &grpc.UnaryServerInfo{FullMethod: "/order.Order/GetOrder"}
+
+	appErr := errTestNotFound.
+		WithMetadata("order_id", "42").
+		WithViolations(apperror.FieldViolation{Field: "transaction_id", Description: "unknown order"}).
+		WithRetryAfter(3 * time.Second).
+		Wrap(errors.New("pq: no rows in result set"))
+
+	_, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return nil, appErr
+	})
+
+	st := status.Convert(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "order not found", st.Message(), "cause must not be sent to client")
+
+	details := st.Details()
+	require.Len(t, details, 3)
+
+	errorInfo, ok := details[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, "ORDER_NOT_FOUND", errorInfo.Reason)
+	assert.Equal(t, "order.test", errorInfo.Domain)
+	assert.Equal(t, map[string]string{"order_id": "42"}, errorInfo.Metadata)
+
+	badRequest, ok := details[1].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, badRequest.FieldViolations, 1)
+	assert.Equal(t, "transaction_id", badRequest.FieldViolations[0].Field)
+
+	retryInfo, ok := details[2].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Equal(t, 3*time.Second, retryInfo.RetryDelay.AsDuration())
+
+	assert.Contains(t, buf.String(), "pq: no rows in result set", "cause must be logged")
+	assert.Contains(t, buf.String(), "/order.Order/GetOrder")
+}
+
+func TestErrorInterceptor_LogsInternal(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := ErrorStreamInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)), "order.test")
+
+	err := interceptor(nil, &testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test/Stream"},
+		func(any, grpc.ServerStream) error {
+			return errors.New("pq: connection refused")
+		})
+
+	st := status.Convert(err)
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "internal error", st.Message())
+	assert.Contains(t, buf.String(), `"level":"ERROR"`)
+	assert.Contains(t, buf.String(), "pq: connection refused")
+}
@@ -13,7 +13,8 @@ func LoggerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
 		start := time.Now()
 		resp, err := handler(ctx, req)
 
-		logger.Info(
+		logger.InfoContext(
+			ctx,
 			"request processed",
 			slog.String("method", info.FullMethod),
 			slog.Int64("response_time_ms", time.Since(start).Milliseconds()),
@@ -23,3 +24,20 @@ func LoggerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
 		return resp, err
 	}
 }
+
+func LoggerStreamInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+
+		logger.InfoContext(
+			ss.Context(),
+			"stream processed",
+			slog.String("method", info.FullMethod),
+			slog.Int64("response_time_ms", time.Since(start).Milliseconds()),
+			slog.Any("error", err),
+		)
+
+		return err
+	}
+}
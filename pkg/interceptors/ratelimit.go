@@ -0,0 +1,102 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
+	"github.com/hickar/crtex_test_assignment/pkg/ratelimit"
+)
+
+// RetryAfterKey - ключ метаданных ответа с числом секунд до повтора запроса.
+const RetryAfterKey = "retry-after"
+
+// RateLimitInterceptor ограничивает запросы по пользователю из контекста,
+// а для неаутентифицированных запросов - по адресу клиента. Поэтому в цепочке
+// он должен следовать за AuthInterceptor.
+func RateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		release, err := acquire(ctx, limiter, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return handler(ctx, req)
+	}
+}
+
+func RateLimitStreamInterceptor(limiter *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, err := acquire(ss.Context(), limiter, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return handler(srv, ss)
+	}
+}
+
+func acquire(ctx context.Context, limiter *ratelimit.Limiter, method string) (func(), error) {
+	release, err := limiter.Acquire(ctx, method, rateLimitSubject(ctx))
+	if err == nil {
+		return release, nil
+	}
+
+	var limitErr *ratelimit.LimitError
+	if !errors.As(err, &limitErr) {
+		return nil, status.Error(codes.Unavailable, "rate limiter is unavailable")
+	}
+
+	seconds := strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds())))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RetryAfterKey, seconds))
+
+	st, _ := status.New(codes.ResourceExhausted, limitErr.Err.Error()).
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(limitErr.RetryAfter)})
+
+	return nil, st.Err()
+}
+
+func rateLimitSubject(ctx context.Context) string {
+	if identity, ok := auth.IdentityFromContext(ctx); ok {
+		return "user:" + strconv.FormatInt(identity.UserID, 10)
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	// Запросы REST-шлюза приходят с локального адреса, адрес клиента
+	// шлюз передаёт в x-forwarded-for. Другим клиентам заголовок не доверяется.
+	// Шлюз дописывает адрес клиента в конец заголовка, присланного клиентом,
+	// поэтому доверять можно только последнему значению.
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 {
+				addrs := strings.Split(forwarded[len(forwarded)-1], ",")
+				host = strings.TrimSpace(addrs[len(addrs)-1])
+			}
+		}
+	}
+
+	return "addr:" + host
+}
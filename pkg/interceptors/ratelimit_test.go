@@ -0,0 +1,89 @@
+//go:build unit_test
+
+package interceptors
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/hickar/crtex_test_assignment/pkg/auth"
+	"github.com/hickar/crtex_test_assignment/pkg/ratelimit"
+)
+
+func TestRateLimitInterceptor(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), []ratelimit.Rule{
+		{Method: "/order.Order/CreateOrder", Limit: ratelimit.Limit{Rate: 0.5, Burst: 1}},
+	})
+
+	interceptor := RateLimitInterceptor(limiter)
+	info := &grpc.UnaryServerInfo{FullMethod: "/order.Order/CreateOrder"}
+	handler := func(context.Context, any) (any, error) { return "ok", nil }
+
+	user1 := auth.WithIdentity(context.Background(), auth.Identity{UserID: 1})
+	_, err := interceptor(user1, nil, info, handler)
+	require.NoError(t, err)
+
+	_, err = interceptor(user1, nil, info, handler)
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.InDelta(t, 2*time.Second, retryInfo.RetryDelay.AsDuration(), float64(100*time.Millisecond))
+
+	user2 := auth.WithIdentity(context.Background(), auth.Identity{UserID: 2})
+	_, err = interceptor(user2, nil, info, handler)
+	assert.NoError(t, err, "other users must not be affected")
+}
+
+func TestRateLimitSubject(t *testing.T) {
+	withPeer := func(addr string, md metadata.MD) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(addr), Port: 5000}})
+		return metadata.NewIncomingContext(ctx, md)
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{
+			name:     "Identity",
+			ctx:      auth.WithIdentity(withPeer("10.0.0.1", nil), auth.Identity{UserID: 7}),
+			expected: "user:7",
+		},
+		{
+			name:     "RemoteAddress",
+			ctx:      withPeer("10.0.0.1", metadata.Pairs("x-forwarded-for", "1.2.3.4")),
+			expected: "addr:10.0.0.1",
+		},
+		{
+			name:     "Gateway_ForwardedFor",
+			ctx:      withPeer("127.0.0.1", metadata.Pairs("x-forwarded-for", "10.0.0.2")),
+			expected: "addr:10.0.0.2",
+		},
+		{
+			// Первые значения присылает клиент, адрес дописывает шлюз.
+			name:     "Gateway_SpoofedForwardedFor",
+			ctx:      withPeer("127.0.0.1", metadata.Pairs("x-forwarded-for", "1.2.3.4, 10.0.0.2")),
+			expected: "addr:10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rateLimitSubject(tt.ctx))
+		})
+	}
+}
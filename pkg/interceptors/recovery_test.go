@@ -0,0 +1,55 @@
+//go:build unit_test
+
+package interceptors
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRecoveryInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := RecoveryInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)))
+	info := &grpc.UnaryServerInfo{FullMethod: "/order.Order/CreateOrder"}
+
+	_, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		panic("boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, buf.String(), "boom")
+	assert.Contains(t, buf.String(), "recovery_test.go", "stack trace must be logged")
+
+	resp, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+}
+
+func TestRecoveryStreamInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := RecoveryStreamInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	err := interceptor(nil, &testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test/Stream"},
+		func(any, grpc.ServerStream) error {
+			panic("boom")
+		})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, buf.String(), "/test/Stream")
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
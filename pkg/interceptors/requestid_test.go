@@ -0,0 +1,54 @@
+//go:build unit_test
+
+package interceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/hickar/crtex_test_assignment/pkg/requestid"
+)
+
+func TestRequestIDInterceptor(t *testing.T) {
+	interceptor := RequestIDInterceptor()
+
+	call := func(ctx context.Context) string {
+		var id string
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+			var ok bool
+			id, ok = requestid.FromContext(ctx)
+			require.True(t, ok)
+			return nil, nil
+		})
+		require.NoError(t, err)
+
+		return id
+	}
+
+	incoming := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestid.MetadataKey, "client-id"))
+	assert.Equal(t, "client-id", call(incoming))
+
+	invalid := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestid.MetadataKey, "bad id"))
+	generated := call(invalid)
+	assert.NotEqual(t, "bad id", generated)
+	assert.True(t, requestid.Valid(generated))
+
+	assert.NotEqual(t, call(context.Background()), call(context.Background()))
+}
+
+func TestRequestIDStreamInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestid.MetadataKey, "stream-id"))
+
+	err := RequestIDStreamInterceptor()(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{},
+		func(_ any, ss grpc.ServerStream) error {
+			id, _ := requestid.FromContext(ss.Context())
+			assert.Equal(t, "stream-id", id)
+			return nil
+		})
+	require.NoError(t, err)
+}
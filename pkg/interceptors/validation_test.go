@@ -0,0 +1,75 @@
+//go:build unit_test
+
+package interceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hickar/crtex_test_assignment/pkg/validation/testpb"
+)
+
+func TestValidationInterceptor(t *testing.T) {
+	interceptor := ValidationInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/order.Order/CreateOrder"}
+
+	var called bool
+	handler := func(context.Context, any) (any, error) {
+		called = true
+		return "ok", nil
+	}
+
+	valid := &testpb.Request{
+		Name:    "order",
+		Amount:  1,
+		Items:   []*testpb.Item{{Sku: "A", Quantity: 1}},
+		Primary: &testpb.Item{Sku: "B", Quantity: 1},
+	}
+	_, err := interceptor(context.Background(), valid, info, handler)
+	require.NoError(t, err)
+	assert.True(t, called)
+
+	called = false
+	invalid := &testpb.Request{Name: "order", Amount: 1, Items: valid.Items, Primary: &testpb.Item{Sku: "B"}}
+	_, err = interceptor(context.Background(), invalid, info, handler)
+	assert.False(t, called, "handler must not be called for invalid request")
+
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, badRequest.FieldViolations, 1)
+	assert.Equal(t, "primary.quantity", badRequest.FieldViolations[0].Field)
+	assert.Equal(t, "must be greater than 0", badRequest.FieldViolations[0].Description)
+}
+
+func TestValidationStreamInterceptor(t *testing.T) {
+	interceptor := ValidationStreamInterceptor()
+	stream := &recvStream{msgs: []*testpb.Item{{Sku: "A", Quantity: 1}, {Sku: "A", Quantity: 0}}}
+
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{}, func(_ any, ss grpc.ServerStream) error {
+		require.NoError(t, ss.RecvMsg(&testpb.Item{}))
+		return ss.RecvMsg(&testpb.Item{})
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+type recvStream struct {
+	grpc.ServerStream
+	msgs []*testpb.Item
+}
+
+func (s *recvStream) RecvMsg(m any) error {
+	item := m.(*testpb.Item)
+	item.Sku, item.Quantity = s.msgs[0].Sku, s.msgs[0].Quantity
+	s.msgs = s.msgs[1:]
+	return nil
+}
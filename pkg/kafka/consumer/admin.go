@@ -0,0 +1,344 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrGroupActive возвращается при попытке изменить смещения группы,
+// у которой есть активные участники.
+var ErrGroupActive = errors.New("consumer group has active members")
+
+// OffsetManager - операции со смещениями топиков и групп консьюмеров.
+// Смещения возвращаются по номерам партиций.
+type OffsetManager interface {
+	// LowWatermarks возвращает смещения первых доступных сообщений партиций.
+	LowWatermarks(ctx context.Context, topic string) (map[int]int64, error)
+	// HighWatermarks возвращает смещения, следующие за последними сообщениями партиций.
+	HighWatermarks(ctx context.Context, topic string) (map[int]int64, error)
+	// OffsetsForTime возвращает смещения первых сообщений, записанных не раньше t,
+	// а для партиций без таких сообщений - HighWatermarks.
+	OffsetsForTime(ctx context.Context, topic string, t time.Time) (map[int]int64, error)
+	// CommittedOffsets возвращает зафиксированные группой смещения. Партиции
+	// без зафиксированных смещений не возвращаются.
+	CommittedOffsets(ctx context.Context, groupID, topic string) (map[int]int64, error)
+	// CommitOffsets фиксирует смещения от имени группы без участия в ней.
+	CommitOffsets(ctx context.Context, groupID, topic string, offsets map[int]int64) error
+	// GroupMembers возвращает число активных участников группы.
+	GroupMembers(ctx context.Context, groupID string) (int, error)
+}
+
+// GroupAdmin - OffsetManager, описывающий участников групп и назначенные им партиции.
+type GroupAdmin interface {
+	OffsetManager
+	DescribeGroup(ctx context.Context, groupID string) (kafka.DescribeGroupsResponseGroup, error)
+}
+
+// ResolveOffsets возвращает смещения партиций топика для позиции start.
+func ResolveOffsets(ctx context.Context, m OffsetManager, topic string, start StartOffset, t time.Time) (map[int]int64, error) {
+	switch start {
+	case StartOffsetEarliest:
+		return m.LowWatermarks(ctx, topic)
+	case StartOffsetLatest, "":
+		return m.HighWatermarks(ctx, topic)
+	case StartOffsetTimestamp:
+		return m.OffsetsForTime(ctx, topic, t)
+	default:
+		return nil, fmt.Errorf("%w: unknown start offset %q", ErrInvalidConfiguration, start)
+	}
+}
+
+// ResetGroupOffsets устанавливает смещения группы groupID в партициях topic.
+// Значения offsets задают смещения явно, а партиции со значением
+// kafka.FirstOffset или kafka.LastOffset получают первое или следующее
+// за последним смещение. Пустой offsets означает все партиции топика,
+// сброшенные на позицию start. Группа не должна иметь активных участников,
+// иначе они перезапишут смещения. Возвращает установленные смещения.
+func ResetGroupOffsets(
+	ctx context.Context,
+	m OffsetManager,
+	groupID, topic string,
+	offsets map[int]int64,
+	start StartOffset,
+	t time.Time,
+) (map[int]int64, error) {
+	if groupID == "" {
+		return nil, fmt.Errorf("%w: group id is not set", ErrInvalidConfiguration)
+	}
+
+	members, err := m.GroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if members > 0 {
+		return nil, fmt.Errorf("%w: %q has %d members", ErrGroupActive, groupID, members)
+	}
+
+	low, err := m.LowWatermarks(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	high, err := m.HighWatermarks(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolved map[int]int64
+	if len(offsets) == 0 {
+		if resolved, err = ResolveOffsets(ctx, m, topic, start, t); err != nil {
+			return nil, err
+		}
+	} else {
+		resolved = maps.Clone(offsets)
+	}
+
+	for _, partition := range sortedKeys(resolved) {
+		end, ok := high[partition]
+		if !ok {
+			return nil, fmt.Errorf("partition %d of topic %q not found", partition, topic)
+		}
+
+		switch offset := resolved[partition]; {
+		case offset == kafka.FirstOffset:
+			resolved[partition] = low[partition]
+		case offset == kafka.LastOffset:
+			resolved[partition] = end
+		case offset < low[partition] || offset > end:
+			return nil, fmt.Errorf("offset %d of partition %d is out of range [%d, %d]", offset, partition, low[partition], end)
+		}
+	}
+
+	if err = m.CommitOffsets(ctx, groupID, topic, resolved); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// initGroupOffsets фиксирует для партиций без смещений группы смещения,
+// соответствующие cfg.StartTime. Kafka не поддерживает начало чтения группы
+// с момента времени, поэтому смещения фиксируются до подключения к группе.
+// Если в группе уже есть участники, смещения не меняются.
+func initGroupOffsets(ctx context.Context, m OffsetManager, cfg Configuration) error {
+	members, err := m.GroupMembers(ctx, cfg.GroupID)
+	if err != nil || members > 0 {
+		return err
+	}
+
+	for _, topic := range cfg.topics() {
+		committed, err := m.CommittedOffsets(ctx, cfg.GroupID, topic)
+		if err != nil {
+			return err
+		}
+
+		offsets, err := m.OffsetsForTime(ctx, topic, cfg.StartTime)
+		if err != nil {
+			return err
+		}
+		for partition := range committed {
+			delete(offsets, partition)
+		}
+		if len(offsets) == 0 {
+			continue
+		}
+
+		if err = m.CommitOffsets(ctx, cfg.GroupID, topic, offsets); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Admin - GroupAdmin для Kafka.
+type Admin struct {
+	client *kafka.Client
+}
+
+func NewAdmin(cfg Configuration) *Admin {
+	return &Admin{
+		client: &kafka.Client{
+			Addr: kafka.TCP(cfg.BrokerURLs...),
+			Transport: &kafka.Transport{
+				TLS:  cfg.TLS,
+				SASL: cfg.SASL,
+			},
+		},
+	}
+}
+
+func (a *Admin) LowWatermarks(ctx context.Context, topic string) (map[int]int64, error) {
+	return a.listOffsets(ctx, topic, kafka.FirstOffset)
+}
+
+func (a *Admin) HighWatermarks(ctx context.Context, topic string) (map[int]int64, error) {
+	return a.listOffsets(ctx, topic, kafka.LastOffset)
+}
+
+func (a *Admin) OffsetsForTime(ctx context.Context, topic string, t time.Time) (map[int]int64, error) {
+	offsets, err := a.listOffsets(ctx, topic, t.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+
+	// Для партиций без сообщений после t Kafka возвращает -1.
+	var high map[int]int64
+	for partition, offset := range offsets {
+		if offset >= 0 {
+			continue
+		}
+		if high == nil {
+			if high, err = a.HighWatermarks(ctx, topic); err != nil {
+				return nil, err
+			}
+		}
+		offsets[partition] = high[partition]
+	}
+
+	return offsets, nil
+}
+
+func (a *Admin) listOffsets(ctx context.Context, topic string, timestamp int64) (map[int]int64, error) {
+	partitions, err := a.partitions(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]kafka.OffsetRequest, 0, len(partitions))
+	for _, partition := range partitions {
+		requests = append(requests, kafka.OffsetRequest{Partition: partition, Timestamp: timestamp})
+	}
+
+	resp, err := a.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partition offsets: %w", err)
+	}
+
+	offsets := make(map[int]int64, len(partitions))
+	for _, po := range resp.Topics[topic] {
+		if po.Error != nil {
+			return nil, fmt.Errorf("failed to list offsets of partition %d: %w", po.Partition, po.Error)
+		}
+
+		switch timestamp {
+		case kafka.FirstOffset:
+			offsets[po.Partition] = po.FirstOffset
+		case kafka.LastOffset:
+			offsets[po.Partition] = po.LastOffset
+		default:
+			offsets[po.Partition] = -1
+			for offset := range po.Offsets {
+				offsets[po.Partition] = offset
+			}
+		}
+	}
+
+	return offsets, nil
+}
+
+func (a *Admin) partitions(ctx context.Context, topic string) ([]int, error) {
+	meta, err := a.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch topic metadata: %w", err)
+	}
+	if len(meta.Topics) == 0 {
+		return nil, fmt.Errorf("topic %q not found", topic)
+	}
+	if meta.Topics[0].Error != nil {
+		return nil, fmt.Errorf("failed to fetch topic metadata: %w", meta.Topics[0].Error)
+	}
+
+	partitions := make([]int, 0, len(meta.Topics[0].Partitions))
+	for _, partition := range meta.Topics[0].Partitions {
+		partitions = append(partitions, partition.ID)
+	}
+
+	return partitions, nil
+}
+
+func (a *Admin) CommittedOffsets(ctx context.Context, groupID, topic string) (map[int]int64, error) {
+	partitions, err := a.partitions(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: groupID,
+		Topics:  map[string][]int{topic: partitions},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", resp.Error)
+	}
+
+	offsets := make(map[int]int64)
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to fetch committed offset of partition %d: %w", p.Partition, p.Error)
+		}
+		if p.CommittedOffset >= 0 {
+			offsets[p.Partition] = p.CommittedOffset
+		}
+	}
+
+	return offsets, nil
+}
+
+func (a *Admin) CommitOffsets(ctx context.Context, groupID, topic string, offsets map[int]int64) error {
+	commits := make([]kafka.OffsetCommit, 0, len(offsets))
+	for partition, offset := range offsets {
+		commits = append(commits, kafka.OffsetCommit{Partition: partition, Offset: offset})
+	}
+
+	// Поколение -1 и пустой идентификатор участника - фиксация смещений
+	// без участия в группе, которую Kafka принимает только у пустой группы.
+	resp, err := a.client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      groupID,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit offsets: %w", err)
+	}
+
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return fmt.Errorf("failed to commit offset of partition %d: %w", p.Partition, p.Error)
+		}
+	}
+
+	return nil
+}
+
+func (a *Admin) GroupMembers(ctx context.Context, groupID string) (int, error) {
+	group, err := a.DescribeGroup(ctx, groupID)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(group.Members), nil
+}
+
+func (a *Admin) DescribeGroup(ctx context.Context, groupID string) (kafka.DescribeGroupsResponseGroup, error) {
+	resp, err := a.client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{groupID}})
+	if err != nil {
+		return kafka.DescribeGroupsResponseGroup{}, fmt.Errorf("failed to describe consumer group: %w", err)
+	}
+	if len(resp.Groups) == 0 {
+		return kafka.DescribeGroupsResponseGroup{GroupID: groupID}, nil
+	}
+	if resp.Groups[0].Error != nil {
+		return kafka.DescribeGroupsResponseGroup{}, fmt.Errorf("failed to describe consumer group: %w", resp.Groups[0].Error)
+	}
+
+	return resp.Groups[0], nil
+}
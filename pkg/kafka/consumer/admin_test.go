@@ -0,0 +1,155 @@
+//go:build unit_test
+
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/pkg/kafka/membroker"
+)
+
+var _ OffsetManager = (*membroker.Broker)(nil)
+
+func TestConfigurationValidate(t *testing.T) {
+	valid := Configuration{BrokerURLs: []string{"localhost:9092"}, GroupID: "test", Topic: testTopic}
+	require.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Configuration)
+	}{
+		{"NoBrokers", func(cfg *Configuration) { cfg.BrokerURLs = nil }},
+		{"NoTopic", func(cfg *Configuration) { cfg.Topic = "" }},
+		{"TopicAndGroupTopics", func(cfg *Configuration) { cfg.GroupTopics = []string{"other"} }},
+		{"GroupTopicsWithoutGroup", func(cfg *Configuration) {
+			cfg.Topic, cfg.GroupID, cfg.GroupTopics = "", "", []string{"other"}
+		}},
+		{"UnknownStartOffset", func(cfg *Configuration) { cfg.StartOffset = "middle" }},
+		{"TimestampWithoutTime", func(cfg *Configuration) { cfg.StartOffset = StartOffsetTimestamp }},
+		{"TimeWithoutTimestamp", func(cfg *Configuration) { cfg.StartTime = time.Now() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			assert.ErrorIs(t, cfg.Validate(), ErrInvalidConfiguration)
+		})
+	}
+}
+
+func TestResetGroupOffsets(t *testing.T) {
+	ctx := context.Background()
+	broker := newTestBroker(t, 2, 10)
+
+	offsets, err := ResetGroupOffsets(ctx, broker, "test", testTopic, nil, StartOffsetLatest, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 5, 1: 5}, offsets)
+
+	offsets, err = ResetGroupOffsets(ctx, broker, "test", testTopic, nil, StartOffsetEarliest, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 0, 1: 0}, offsets)
+
+	offsets, err = ResetGroupOffsets(ctx, broker, "test", testTopic, map[int]int64{0: 3, 1: kafka.LastOffset}, "", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 3, 1: 5}, offsets)
+	assert.EqualValues(t, 3, broker.CommittedOffset("test", testTopic, 0))
+	assert.EqualValues(t, 5, broker.CommittedOffset("test", testTopic, 1))
+
+	_, err = ResetGroupOffsets(ctx, broker, "test", testTopic, map[int]int64{0: 6}, "", time.Time{})
+	assert.Error(t, err, "offset beyond high watermark must be rejected")
+	_, err = ResetGroupOffsets(ctx, broker, "test", testTopic, map[int]int64{2: 0}, "", time.Time{})
+	assert.Error(t, err, "unknown partition must be rejected")
+	assert.EqualValues(t, 3, broker.CommittedOffset("test", testTopic, 0))
+
+	reader := broker.NewReader(membroker.ReaderConfig{GroupID: "test", Topic: testTopic})
+	defer reader.Close()
+
+	_, err = ResetGroupOffsets(ctx, broker, "test", testTopic, nil, StartOffsetEarliest, time.Time{})
+	assert.ErrorIs(t, err, ErrGroupActive)
+}
+
+func TestResetGroupOffsetsTimestamp(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	broker := membroker.NewBroker()
+	broker.CreateTopic(testTopic, 1)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, broker.Produce(ctx, kafka.Message{
+			Topic: testTopic,
+			Time:  start.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+
+	offsets, err := ResetGroupOffsets(ctx, broker, "test", testTopic, nil, StartOffsetTimestamp, start.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 2}, offsets)
+
+	offsets, err = ResetGroupOffsets(ctx, broker, "test", testTopic, nil, StartOffsetTimestamp, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 5}, offsets)
+}
+
+func TestInitGroupOffsets(t *testing.T) {
+	ctx := context.Background()
+	broker := newTestBroker(t, 2, 10)
+	require.NoError(t, broker.CommitOffsets(ctx, "test", testTopic, map[int]int64{0: 4}))
+
+	cfg := Configuration{
+		GroupID:     "test",
+		Topic:       testTopic,
+		StartOffset: StartOffsetTimestamp,
+		StartTime:   time.Now().Add(time.Hour),
+	}
+	require.NoError(t, initGroupOffsets(ctx, broker, cfg))
+
+	assert.EqualValues(t, 4, broker.CommittedOffset("test", testTopic, 0), "committed partitions must be untouched")
+	assert.EqualValues(t, 5, broker.CommittedOffset("test", testTopic, 1))
+}
+
+func TestResetOffsetsCommand(t *testing.T) {
+	ctx := context.Background()
+	broker := newTestBroker(t, 2, 10)
+	cfg := Configuration{BrokerURLs: []string{"localhost:9092"}, GroupID: "test", Topic: testTopic}
+
+	opts, resetCfg, err := ParseResetOffsetsFlags([]string{"-to", "earliest", "-partitions", "1"}, cfg, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, ResetOffsets(ctx, broker, opts, resetCfg, &out))
+
+	var result struct {
+		Group   string           `json:"group"`
+		Offsets map[string]int64 `json:"offsets"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, "test", result.Group)
+	assert.Equal(t, map[string]int64{"1": 0}, result.Offsets)
+	assert.EqualValues(t, -1, broker.CommittedOffset("test", testTopic, 0), "other partitions must be untouched")
+
+	opts, resetCfg, err = ParseResetOffsetsFlags([]string{"-offsets", "0=latest,1=2"}, cfg, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, ResetOffsets(ctx, broker, opts, resetCfg, &bytes.Buffer{}))
+	assert.EqualValues(t, 5, broker.CommittedOffset("test", testTopic, 0))
+	assert.EqualValues(t, 2, broker.CommittedOffset("test", testTopic, 1))
+
+	for _, args := range [][]string{
+		{},
+		{"-to", "earliest", "-offsets", "0=1"},
+		{"-to", "timestamp"},
+		{"-offsets", "0"},
+		{"-offsets", "0=1", "-partitions", "0"},
+	} {
+		_, _, err = ParseResetOffsetsFlags(args, cfg, &bytes.Buffer{})
+		assert.Error(t, err, args)
+	}
+}
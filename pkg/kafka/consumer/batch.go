@@ -0,0 +1,256 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// BatchRouteHandler обрабатывает пачку сообщений. Частичная ошибка обработки
+// возвращается как BatchError, любая другая ошибка относится ко всем сообщениям.
+type BatchRouteHandler func(context.Context, []*kafka.Message) error
+
+// BatchError - ошибки обработки сообщений пачки по их индексам.
+type BatchError map[int]error
+
+func (e BatchError) Error() string {
+	indices := make([]int, 0, len(e))
+	for i := range e {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	parts := make([]string, 0, len(indices))
+	for _, i := range indices {
+		parts = append(parts, fmt.Sprintf("message %d: %s", i, e[i]))
+	}
+
+	return fmt.Sprintf("%d of batch messages failed: %s", len(e), strings.Join(parts, "; "))
+}
+
+// callBatchHandler вызывает handler и раскладывает его результат по сообщениям.
+// Паника в обработчике считается ошибкой обработки всех сообщений пачки.
+func callBatchHandler(ctx context.Context, handler BatchRouteHandler, messages []*kafka.Message) (errs []error) {
+	errs = make([]error, len(messages))
+
+	defer func() {
+		if r := recover(); r != nil {
+			for i := range errs {
+				errs[i] = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+			}
+		}
+	}()
+
+	err := handler(ctx, messages)
+	var batchErr BatchError
+	if errors.As(err, &batchErr) {
+		for i, merr := range batchErr {
+			if i >= 0 && i < len(errs) {
+				errs[i] = merr
+			}
+		}
+		return errs
+	}
+
+	for i := range errs {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+type pendingBatch struct {
+	messages []kafka.Message
+	deadline time.Time
+}
+
+// runBatches - Run в пакетном режиме. Пачки одной партиции обрабатывает
+// один и тот же обработчик, поэтому они не выполняются параллельно.
+func (c *Consumer) runBatches(ctx context.Context) error {
+	batchChs := make([]chan []kafka.Message, c.workerCount)
+	tracker := newOffsetTracker()
+
+	var wg sync.WaitGroup
+	for i := range batchChs {
+		batchCh := make(chan []kafka.Message)
+		batchChs[i] = batchCh
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.batchWorker(ctx, batchCh, tracker)
+		}()
+	}
+
+	fetchErr := c.fetchBatches(ctx, batchChs, tracker)
+	for _, batchCh := range batchChs {
+		close(batchCh)
+	}
+	wg.Wait()
+
+	return errors.Join(fetchErr, c.source.Close())
+}
+
+// fetchBatches собирает сообщения в пачки по партициям. Пачка отправляется
+// обработчикам, когда в ней BatchSize сообщений или с получения её первого
+// сообщения прошло BatchTimeout.
+func (c *Consumer) fetchBatches(ctx context.Context, batchChs []chan []kafka.Message, tracker *offsetTracker) error {
+	fetched := make(chan kafka.Message)
+	var fetchErr error
+	go func() {
+		defer close(fetched)
+		for {
+			if c.waitResumed(ctx) != nil {
+				return
+			}
+
+			msg, err := c.source.FetchMessage(ctx)
+			if err != nil {
+				fetchErr = err
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case fetched <- msg:
+			}
+		}
+	}()
+
+	// Неотправленные пачки при остановке отбрасываются: их смещения
+	// не фиксируются, и сообщения будут прочитаны повторно.
+	stop := func() error {
+		for range fetched {
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return fmt.Errorf("error during message reading: %w", fetchErr)
+	}
+
+	pending := make(map[partitionKey]*pendingBatch)
+	var (
+		timer  *time.Timer
+		timerC <-chan time.Time
+	)
+	// resetTimer заводит таймер на ближайший срок отправки пачки.
+	resetTimer := func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		timer, timerC = nil, nil
+
+		var deadline time.Time
+		for _, batch := range pending {
+			if deadline.IsZero() || batch.deadline.Before(deadline) {
+				deadline = batch.deadline
+			}
+		}
+		if !deadline.IsZero() {
+			timer = time.NewTimer(time.Until(deadline))
+			timerC = timer.C
+		}
+	}
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	send := func(key partitionKey) bool {
+		batch := pending[key]
+		delete(pending, key)
+
+		select {
+		case <-ctx.Done():
+			return false
+		case batchChs[batchWorkerIndex(key, len(batchChs))] <- batch.messages:
+			return true
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return stop()
+
+		case msg, ok := <-fetched:
+			if !ok {
+				return stop()
+			}
+			tracker.track(msg)
+
+			key := partitionKey{topic: msg.Topic, partition: msg.Partition}
+			batch, ok := pending[key]
+			if !ok {
+				batch = &pendingBatch{deadline: time.Now().Add(c.batchTimeout)}
+				pending[key] = batch
+			}
+			batch.messages = append(batch.messages, msg)
+
+			if len(batch.messages) >= c.batchSize {
+				if !send(key) {
+					return stop()
+				}
+				resetTimer()
+			} else if timer == nil {
+				resetTimer()
+			}
+
+		case now := <-timerC:
+			for key, batch := range pending {
+				if !batch.deadline.After(now) && !send(key) {
+					return stop()
+				}
+			}
+			resetTimer()
+		}
+	}
+}
+
+func (c *Consumer) batchWorker(ctx context.Context, batchCh <-chan []kafka.Message, tracker *offsetTracker) {
+	for batch := range batchCh {
+		messages := make([]*kafka.Message, len(batch))
+		for i := range batch {
+			messages[i] = &batch[i]
+		}
+
+		hctx, cancel := withHandlerTimeout(ctx, c.handlerTimeout)
+		errs := c.batchRouter.RouteBatch(hctx, messages)
+		cancel()
+
+		// Сообщения пачки принадлежат одной партиции, поэтому достаточно
+		// зафиксировать последнее из завершённых смещений.
+		var (
+			commitMsg kafka.Message
+			commit    bool
+		)
+		for i, message := range batch {
+			c.logSkipped(message, errs[i])
+
+			if msg, ok := tracker.complete(message); ok {
+				commitMsg, commit = msg, true
+			}
+		}
+		if commit {
+			c.commit(ctx, commitMsg)
+		}
+	}
+}
+
+// batchWorkerIndex выбирает обработчик пачек партиции.
+func batchWorkerIndex(key partitionKey, workers int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key.topic))
+
+	return int((h.Sum32() + uint32(key.partition)) % uint32(workers))
+}
@@ -0,0 +1,185 @@
+//go:build unit_test
+
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerBatches(t *testing.T) {
+	broker := newTestBroker(t, 2, 11)
+
+	var (
+		mu        sync.Mutex
+		batches   [][]*kafka.Message
+		processed int
+	)
+	router := NewTopicRouter()
+	router.HandleBatch(testTopic, func(_ context.Context, messages []*kafka.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		batches = append(batches, messages)
+		processed += len(messages)
+		return nil
+	})
+
+	stop := runConsumer(t, broker, router, Configuration{BatchSize: 3, BatchTimeout: 20 * time.Millisecond})
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return processed == 11
+	}, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, stop(), context.Canceled)
+
+	for _, batch := range batches {
+		assert.LessOrEqual(t, len(batch), 3)
+		for _, msg := range batch {
+			assert.Equal(t, batch[0].Partition, msg.Partition, "batch must contain messages of one partition")
+		}
+	}
+	assert.Less(t, len(batches), 11, "messages must be grouped")
+
+	for p := 0; p < 2; p++ {
+		expected := int64(len(broker.Messages(testTopic, p)))
+		assert.Equal(t, expected, broker.CommittedOffset("test", testTopic, p), "partition %d", p)
+	}
+}
+
+func TestConsumerBatchesSerializedPerPartition(t *testing.T) {
+	broker := newTestBroker(t, 2, 40)
+
+	var (
+		mu        sync.Mutex
+		inFlight  = make(map[int]int)
+		overlaps  int
+		processed int
+	)
+	router := NewTopicRouter()
+	router.HandleBatch(testTopic, func(_ context.Context, messages []*kafka.Message) error {
+		partition := messages[0].Partition
+
+		mu.Lock()
+		inFlight[partition]++
+		if inFlight[partition] > 1 {
+			overlaps++
+		}
+		mu.Unlock()
+
+		time.Sleep(2 * time.Millisecond)
+
+		mu.Lock()
+		inFlight[partition]--
+		processed += len(messages)
+		mu.Unlock()
+		return nil
+	})
+
+	stop := runConsumer(t, broker, router, Configuration{
+		BatchSize:    2,
+		BatchTimeout: 5 * time.Millisecond,
+		WorkerCount:  4,
+	})
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return processed == 40
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, stop(), context.Canceled)
+
+	assert.Zero(t, overlaps, "batches of one partition must not be handled concurrently")
+}
+
+func TestConsumerBatchTimeout(t *testing.T) {
+	broker := newTestBroker(t, 1, 2)
+
+	handled := make(chan int, 1)
+	router := NewTopicRouter()
+	router.HandleBatch(testTopic, func(_ context.Context, messages []*kafka.Message) error {
+		handled <- len(messages)
+		return nil
+	})
+
+	stop := runConsumer(t, broker, router, Configuration{BatchSize: 100, BatchTimeout: 30 * time.Millisecond})
+	defer stop()
+
+	select {
+	case n := <-handled:
+		assert.Equal(t, 2, n, "incomplete batch must be handled after timeout")
+	case <-time.After(time.Second):
+		t.Fatal("batch was not handled")
+	}
+}
+
+func TestTopicRouterRouteBatch(t *testing.T) {
+	failed := errors.New("failed")
+
+	var results sync.Map
+	router := NewTopicRouter()
+	router.Use(func(next RouteHandler) RouteHandler {
+		return func(ctx context.Context, message *kafka.Message) error {
+			err := next(ctx, message)
+			results.Store(string(message.Key), err)
+			return err
+		}
+	})
+
+	var batchSizes []int
+	router.HandleBatch("orders", func(_ context.Context, messages []*kafka.Message) error {
+		batchSizes = append(batchSizes, len(messages))
+		for i, msg := range messages {
+			if string(msg.Key) == "o2" {
+				return BatchError{i: failed}
+			}
+		}
+		return nil
+	})
+	router.Handle("accounts", func(context.Context, *kafka.Message) error {
+		return nil
+	})
+
+	messages := []*kafka.Message{
+		{Topic: "orders", Key: []byte("o1")},
+		{Topic: "accounts", Key: []byte("a1")},
+		{Topic: "orders", Key: []byte("o2")},
+		{Topic: "unknown", Key: []byte("u1")},
+		{Topic: "orders", Key: []byte("o3")},
+	}
+	errs := router.RouteBatch(context.Background(), messages)
+	require.Len(t, errs, 5)
+
+	assert.Equal(t, []int{3}, batchSizes, "orders must be handled in one batch")
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.ErrorIs(t, errs[2], failed)
+	assert.ErrorIs(t, errs[3], ErrNoRoute)
+	assert.NoError(t, errs[4])
+
+	result, _ := results.Load("o2")
+	assert.ErrorIs(t, result.(error), failed, "middleware must receive the result of batch message")
+
+	assert.ErrorIs(t, router.Route(context.Background(), &kafka.Message{Topic: "orders", Key: []byte("o2")}), failed)
+	assert.Equal(t, []int{3, 1}, batchSizes, "Route must pass a single message batch")
+}
+
+func TestTopicRouterRouteBatchPanic(t *testing.T) {
+	router := NewTopicRouter()
+	router.HandleBatch("orders", func(context.Context, []*kafka.Message) error {
+		panic("boom")
+	})
+
+	errs := router.RouteBatch(context.Background(), []*kafka.Message{{Topic: "orders"}, {Topic: "orders"}})
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrHandlerPanic)
+	}
+}
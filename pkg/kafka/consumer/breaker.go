@@ -0,0 +1,282 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Pauser приостанавливает и возобновляет получение сообщений. Реализуется Consumer.
+type Pauser interface {
+	Pause()
+	Resume()
+}
+
+// CircuitBreakerConfiguration - параметры CircuitBreaker.
+type CircuitBreakerConfiguration struct {
+	// FailureThreshold - число ошибок инфраструктуры подряд, после которого
+	// предохранитель размыкается. По умолчанию 5.
+	FailureThreshold int
+	// IsFailure определяет ошибки инфраструктуры. По умолчанию ею считается
+	// любая ошибка обработки.
+	IsFailure func(error) bool
+	// Probe проверяет доступность инфраструктуры, пока предохранитель разомкнут.
+	// Если не задана, предохранитель замыкается через ProbeInterval.
+	Probe func(context.Context) error
+	// ProbeInterval - интервал между проверками доступности. По умолчанию 5 секунд.
+	ProbeInterval time.Duration
+	// RetryBackoff - пауза перед повтором сообщения, пока порог не достигнут.
+	// Удваивается с каждой попыткой, но не превышает ProbeInterval.
+	// По умолчанию 100 мс.
+	RetryBackoff time.Duration
+	Logger       *slog.Logger
+}
+
+// CircuitBreaker останавливает обработку сообщений при недоступности
+// инфраструктуры, например базы данных. После FailureThreshold ошибок
+// инфраструктуры подряд предохранитель размыкается: подключённые Pauser
+// приостанавливаются, а доступность периодически проверяется Probe. После
+// успешной проверки предохранитель замыкается и получение сообщений
+// возобновляется.
+//
+// Сообщение с ошибкой инфраструктуры не возвращает ошибку, а обрабатывается
+// повторно: с паузой RetryBackoff, пока порог не достигнут, и после замыкания, если ошибка
+// разомкнула предохранитель или пришлась на время, пока он разомкнут. Поэтому
+// при недоступности инфраструктуры сообщения не попадают в DLQ. Ожидание
+// ограничено контекстом консьюмера, а не временем обработки сообщения: каждая
+// попытка получает новое ограничение HandlerTimeout.
+type CircuitBreaker struct {
+	cfg CircuitBreakerConfiguration
+
+	mu       sync.Mutex
+	failures int
+	// closed закрывается при замыкании; nil, пока предохранитель замкнут.
+	closed  chan struct{}
+	pausers []Pauser
+}
+
+func NewCircuitBreaker(cfg CircuitBreakerConfiguration) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(err error) bool { return err != nil }
+	}
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = 5 * time.Second
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 100 * time.Millisecond
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &CircuitBreaker{cfg: cfg}
+}
+
+// Attach подключает p: он приостанавливается при размыкании предохранителя
+// и возобновляется при замыкании.
+func (b *CircuitBreaker) Attach(p Pauser) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pausers = append(b.pausers, p)
+	if b.closed != nil {
+		p.Pause()
+	}
+}
+
+// Open сообщает, разомкнут ли предохранитель.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.closed != nil
+}
+
+// Middleware возвращает middleware для обработчиков отдельных сообщений.
+// Регистрируется раньше DeadLetterMiddleware, чтобы в DLQ попадал только
+// окончательный результат обработки.
+func (b *CircuitBreaker) Middleware() RouteMiddleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(ctx context.Context, message *kafka.Message) error {
+			return b.call(ctx, b.cfg.IsFailure, func(ctx context.Context) error {
+				return next(ctx, message)
+			})
+		}
+	}
+}
+
+// WrapBatch оборачивает пакетный обработчик. В пакетном режиме middleware
+// получают уже готовые результаты обработки, поэтому повторить обработку
+// после замыкания можно только для всей пачки. Ошибкой инфраструктуры
+// считается пачка, в которой хотя бы одна ошибка удовлетворяет IsFailure.
+func (b *CircuitBreaker) WrapBatch(next BatchRouteHandler) BatchRouteHandler {
+	isFailure := func(err error) bool {
+		var batchErr BatchError
+		if !errors.As(err, &batchErr) {
+			return b.cfg.IsFailure(err)
+		}
+		for _, e := range batchErr {
+			if b.cfg.IsFailure(e) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(ctx context.Context, messages []*kafka.Message) error {
+		return b.call(ctx, isFailure, func(ctx context.Context) error {
+			return next(ctx, messages)
+		})
+	}
+}
+
+func (b *CircuitBreaker) call(ctx context.Context, isFailure func(error) bool, fn func(context.Context) error) error {
+	// Под управлением консьюмера ожидание ограничено его контекстом, а каждая
+	// попытка получает новое ограничение времени обработки: ограничение,
+	// с которым сообщение пришло, могло истечь, пока предохранитель разомкнут.
+	waitCtx := ctx
+	attempt := func() (context.Context, context.CancelFunc) { return ctx, func() {} }
+	if scope, ok := ctx.Value(handlerScopeKey{}).(handlerScope); ok {
+		waitCtx = scope.ctx
+		attempt = func() (context.Context, context.CancelFunc) {
+			return withHandlerTimeout(scope.ctx, scope.timeout)
+		}
+	}
+
+	backoff := b.cfg.RetryBackoff
+	for {
+		if err := b.wait(waitCtx); err != nil {
+			return err
+		}
+
+		actx, cancel := attempt()
+		err := fn(actx)
+		cancel()
+
+		if err == nil || !isFailure(err) {
+			b.succeed()
+			return err
+		}
+		// При остановке консьюмера ошибка может быть вызвана отменой контекста.
+		if waitCtx.Err() != nil {
+			return err
+		}
+
+		if b.fail() {
+			b.cfg.Logger.WarnContext(ctx, "kafka message will be retried after circuit breaker closes", slog.Any("error", err))
+			continue
+		}
+
+		b.cfg.Logger.WarnContext(ctx, "kafka message will be retried", slog.Any("error", err), slog.Duration("backoff", backoff))
+		if werr := sleepContext(waitCtx, backoff); werr != nil {
+			return err
+		}
+		backoff = min(2*backoff, b.cfg.ProbeInterval)
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// wait блокируется, пока предохранитель разомкнут.
+func (b *CircuitBreaker) wait(ctx context.Context) error {
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+
+	if closed == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-closed:
+		return nil
+	}
+}
+
+func (b *CircuitBreaker) succeed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed == nil {
+		b.failures = 0
+	}
+}
+
+// fail учитывает ошибку инфраструктуры и размыкает предохранитель при
+// достижении порога. Возвращает true, если предохранитель разомкнут.
+func (b *CircuitBreaker) fail() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed != nil {
+		return true
+	}
+
+	b.failures++
+	if b.failures < b.cfg.FailureThreshold {
+		return false
+	}
+
+	closed := make(chan struct{})
+	b.closed = closed
+	for _, p := range b.pausers {
+		p.Pause()
+	}
+	b.cfg.Logger.Error("circuit breaker opened", slog.Int("failures", b.failures))
+
+	go b.probe(closed)
+
+	return true
+}
+
+// probe проверяет доступность инфраструктуры до первой успешной проверки
+// и замыкает предохранитель.
+func (b *CircuitBreaker) probe(closed chan struct{}) {
+	ticker := time.NewTicker(b.cfg.ProbeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if b.cfg.Probe != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), b.cfg.ProbeInterval)
+			err := b.cfg.Probe(ctx)
+			cancel()
+
+			if err != nil {
+				b.cfg.Logger.Warn("circuit breaker probe failed", slog.Any("error", err))
+				continue
+			}
+		}
+
+		b.mu.Lock()
+		b.failures = 0
+		b.closed = nil
+		close(closed)
+		for _, p := range b.pausers {
+			p.Resume()
+		}
+		b.mu.Unlock()
+
+		b.cfg.Logger.Info("circuit breaker closed")
+		return
+	}
+}
@@ -0,0 +1,256 @@
+//go:build unit_test
+
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/pkg/kafka/membroker"
+)
+
+var errDatabaseDown = errors.New("database is down")
+
+func isDatabaseDown(err error) bool {
+	return errors.Is(err, errDatabaseDown)
+}
+
+func TestConsumerPause(t *testing.T) {
+	broker := newTestBroker(t, 1, 5)
+
+	var processed atomic.Int64
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(_ context.Context, _ *kafka.Message) error {
+		processed.Add(1)
+		return nil
+	})
+
+	c := newTestConsumer(broker, router)
+	c.Pause()
+	assert.True(t, c.Paused())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, processed.Load(), "paused consumer must not fetch messages")
+
+	c.Resume()
+	require.Eventually(t, func() bool {
+		return processed.Load() == 5
+	}, time.Second, 10*time.Millisecond)
+	assert.False(t, c.Paused())
+}
+
+func TestCircuitBreakerPausesConsumer(t *testing.T) {
+	broker := newTestBroker(t, 1, 5)
+
+	var healthy atomic.Bool
+	breaker := NewCircuitBreaker(CircuitBreakerConfiguration{
+		FailureThreshold: 2,
+		IsFailure:        isDatabaseDown,
+		Probe: func(context.Context) error {
+			if !healthy.Load() {
+				return errDatabaseDown
+			}
+			return nil
+		},
+		ProbeInterval: 10 * time.Millisecond,
+	})
+
+	var processed, failed atomic.Int64
+	router := NewTopicRouter()
+	router.Use(breaker.Middleware())
+	// Вместо DLQ считаются сообщения, обработка которых завершилась ошибкой.
+	router.Use(func(next RouteHandler) RouteHandler {
+		return func(ctx context.Context, message *kafka.Message) error {
+			err := next(ctx, message)
+			if err != nil {
+				failed.Add(1)
+			}
+			return err
+		}
+	})
+	router.Handle(testTopic, func(_ context.Context, _ *kafka.Message) error {
+		if !healthy.Load() {
+			return errDatabaseDown
+		}
+		processed.Add(1)
+		return nil
+	})
+
+	c := newTestConsumer(broker, router)
+	breaker.Attach(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	require.Eventually(t, breaker.Open, time.Second, 5*time.Millisecond)
+	assert.True(t, c.Paused())
+
+	healthy.Store(true)
+	require.Eventually(t, func() bool {
+		return processed.Load() == 5
+	}, time.Second, 10*time.Millisecond)
+	assert.False(t, breaker.Open())
+	assert.False(t, c.Paused())
+	assert.Zero(t, failed.Load(), "infrastructure failures must be retried")
+}
+
+func TestCircuitBreakerOutageLongerThanHandlerTimeout(t *testing.T) {
+	broker := newTestBroker(t, 1, 5)
+	broker.CreateTopic(testDLQTopic, 1)
+
+	var healthy atomic.Bool
+	breaker := NewCircuitBreaker(CircuitBreakerConfiguration{
+		FailureThreshold: 3,
+		IsFailure:        isDatabaseDown,
+		Probe: func(context.Context) error {
+			if !healthy.Load() {
+				return errDatabaseDown
+			}
+			return nil
+		},
+		ProbeInterval: 10 * time.Millisecond,
+	})
+
+	var processed atomic.Int64
+	router := NewTopicRouter()
+	router.Use(breaker.Middleware())
+	router.Use(DeadLetterMiddleware(broker, testDLQTopic))
+	router.Handle(testTopic, func(ctx context.Context, _ *kafka.Message) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !healthy.Load() {
+			return errDatabaseDown
+		}
+		processed.Add(1)
+		return nil
+	})
+
+	source := broker.NewReader(membroker.ReaderConfig{
+		GroupID:     "test",
+		Topic:       testTopic,
+		StartOffset: kafka.FirstOffset,
+	})
+	c := NewConsumerWithSource(Configuration{WorkerCount: 2, HandlerTimeout: 20 * time.Millisecond}, source, router)
+	breaker.Attach(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	require.Eventually(t, breaker.Open, time.Second, 5*time.Millisecond)
+	// Сообщения, ожидающие замыкания, переживают несколько HandlerTimeout.
+	time.Sleep(100 * time.Millisecond)
+
+	healthy.Store(true)
+	require.Eventually(t, func() bool {
+		return processed.Load() == 5
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, broker.Messages(testDLQTopic, 0), "no message must be dead-lettered during the outage")
+}
+
+func TestCircuitBreakerCountsConsecutiveFailures(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerConfiguration{
+		FailureThreshold: 2,
+		IsFailure:        isDatabaseDown,
+		ProbeInterval:    time.Hour,
+		RetryBackoff:     time.Millisecond,
+	})
+
+	// Ошибка инфраструктуры повторяется, а успех или другая ошибка
+	// сбрасывают счётчик, поэтому порог не достигается.
+	results := []error{errDatabaseDown, nil, errDatabaseDown, errors.New("invalid event"), errDatabaseDown, nil}
+	handler := breaker.Middleware()(func(context.Context, *kafka.Message) error {
+		err := results[0]
+		results = results[1:]
+		return err
+	})
+
+	var errs []error
+	for len(results) > 0 {
+		errs = append(errs, handler(context.Background(), &kafka.Message{}))
+	}
+	assert.Equal(t, []error{nil, errors.New("invalid event"), nil}, errs)
+	assert.False(t, breaker.Open())
+}
+
+func TestCircuitBreakerRetryBackoff(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerConfiguration{
+		FailureThreshold: 10,
+		IsFailure:        isDatabaseDown,
+		ProbeInterval:    40 * time.Millisecond,
+		RetryBackoff:     10 * time.Millisecond,
+	})
+
+	var calls []time.Time
+	handler := breaker.Middleware()(func(context.Context, *kafka.Message) error {
+		calls = append(calls, time.Now())
+		if len(calls) < 5 {
+			return errDatabaseDown
+		}
+		return nil
+	})
+
+	require.NoError(t, handler(context.Background(), &kafka.Message{}))
+	require.Len(t, calls, 5)
+
+	// Паузы 10, 20, 40 и 40 мс: удваиваются, но не превышают ProbeInterval.
+	for i, want := range []time.Duration{10, 20, 40, 40} {
+		gap := calls[i+1].Sub(calls[i])
+		assert.GreaterOrEqual(t, gap, want*time.Millisecond, "retry %d", i+1)
+	}
+
+	// Ожидание перед повтором прерывается отменой контекста.
+	breaker = NewCircuitBreaker(CircuitBreakerConfiguration{IsFailure: isDatabaseDown, RetryBackoff: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := breaker.Middleware()(func(context.Context, *kafka.Message) error {
+		return errDatabaseDown
+	})(ctx, &kafka.Message{})
+	assert.ErrorIs(t, err, errDatabaseDown)
+}
+
+func TestCircuitBreakerWrapBatch(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerConfiguration{
+		FailureThreshold: 1,
+		IsFailure:        isDatabaseDown,
+		ProbeInterval:    10 * time.Millisecond,
+	})
+
+	calls := 0
+	handler := breaker.WrapBatch(func(context.Context, []*kafka.Message) error {
+		calls++
+		if calls == 1 {
+			return BatchError{1: errDatabaseDown}
+		}
+		return BatchError{0: errors.New("invalid event")}
+	})
+
+	err := handler(context.Background(), make([]*kafka.Message, 2))
+	assert.Equal(t, 2, calls, "batch must be retried after the breaker closes")
+	assert.Equal(t, BatchError{0: errors.New("invalid event")}, err)
+	assert.False(t, breaker.Open())
+}
+
+func newTestConsumer(broker *membroker.Broker, router MessageRouter) *Consumer {
+	source := broker.NewReader(membroker.ReaderConfig{
+		GroupID:     "test",
+		Topic:       testTopic,
+		StartOffset: kafka.FirstOffset,
+	})
+
+	return NewConsumerWithSource(Configuration{WorkerCount: 1}, source, router)
+}
@@ -2,127 +2,398 @@ package consumer
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+)
+
+var ErrInvalidConfiguration = errors.New("invalid consumer configuration")
+
+// StartOffset определяет, с какого сообщения читаются партиции, для которых
+// у группы нет зафиксированных смещений (без группы - всегда).
+type StartOffset string
+
+const (
+	StartOffsetEarliest StartOffset = "earliest"
+	// StartOffsetLatest - только сообщения, записанные после запуска. Используется по умолчанию.
+	StartOffsetLatest StartOffset = "latest"
+	// StartOffsetTimestamp - сообщения, записанные начиная с Configuration.StartTime.
+	StartOffsetTimestamp StartOffset = "timestamp"
 )
 
 type Configuration struct {
-	BrokerURLs        []string
-	GroupID           string
+	BrokerURLs []string
+	GroupID    string
+	// GroupTopics и Topic взаимоисключающие: GroupTopics требует GroupID.
 	GroupTopics       []string
 	Topic             string
+	StartOffset       StartOffset
+	StartTime         time.Time
 	SessionTimeout    time.Duration
 	HeartbeatInterval time.Duration
 	WorkerCount       int
 	Logger            *slog.Logger
 	HandlerTimeout    time.Duration
+	// BatchSize больше 1 включает пакетный режим для роутеров, реализующих
+	// BatchRouter: сообщения одной партиции передаются пачками не больше
+	// BatchSize сообщений, которые собираются не дольше BatchTimeout.
+	// HandlerTimeout в этом режиме ограничивает обработку пачки.
+	BatchSize    int
+	BatchTimeout time.Duration
+	// StatsInterval - период проверки отставания группы для источников,
+	// реализующих AssignmentSource. По умолчанию 30 секунд.
+	StatsInterval time.Duration
+	// OnPartitionsAssigned вызывается с партициями, назначенными консьюмеру
+	// в новом поколении группы, OnPartitionsRevoked - с партициями поколения
+	// при его завершении, до назначения их другим участникам, и при остановке
+	// консьюмера. Хуки вызываются для источников, реализующих RebalanceSource.
+	OnPartitionsAssigned func(context.Context, []TopicPartition)
+	OnPartitionsRevoked  func(context.Context, []TopicPartition)
+	// Metrics - метрики назначения партиций и отставания группы, если заданы.
+	Metrics *Metrics
+	// TLS и SASL используются при подключении к брокерам, если заданы.
+	TLS  *tls.Config
+	SASL sasl.Mechanism
+}
+
+// Validate проверяет параметры подключения к брокеру и их совместимость.
+func (cfg Configuration) Validate() error {
+	var errs []error
+	if len(cfg.BrokerURLs) == 0 {
+		errs = append(errs, errors.New("broker urls are not set"))
+	}
+
+	switch {
+	case cfg.Topic != "" && len(cfg.GroupTopics) > 0:
+		errs = append(errs, errors.New("topic and group topics are mutually exclusive"))
+	case cfg.Topic == "" && len(cfg.GroupTopics) == 0:
+		errs = append(errs, errors.New("either topic or group topics must be set"))
+	case len(cfg.GroupTopics) > 0 && cfg.GroupID == "":
+		errs = append(errs, errors.New("group topics require group id"))
+	}
+
+	if cfg.BatchSize < 0 || cfg.BatchTimeout < 0 {
+		errs = append(errs, errors.New("batch size and timeout must not be negative"))
+	}
+
+	switch cfg.StartOffset {
+	case "", StartOffsetEarliest, StartOffsetLatest:
+		if !cfg.StartTime.IsZero() {
+			errs = append(errs, fmt.Errorf("start time requires start offset %q", StartOffsetTimestamp))
+		}
+	case StartOffsetTimestamp:
+		if cfg.StartTime.IsZero() {
+			errs = append(errs, fmt.Errorf("start offset %q requires start time", StartOffsetTimestamp))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown start offset %q", cfg.StartOffset))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfiguration, err)
+	}
+
+	return nil
+}
+
+// topics возвращает топики, которые читает консьюмер.
+func (cfg Configuration) topics() []string {
+	if cfg.Topic != "" {
+		return []string{cfg.Topic}
+	}
+
+	return cfg.GroupTopics
 }
 
 type RouteHandler func(context.Context, *kafka.Message) error
 
 type RouteMiddleware func(RouteHandler) RouteHandler
 
+// MessageSource абстрагирует чтение сообщений из брокера, что позволяет
+// подменять kafka.Reader, например, in-memory реализацией в тестах.
+type MessageSource interface {
+	FetchMessage(context.Context) (kafka.Message, error)
+	CommitMessages(context.Context, ...kafka.Message) error
+	Close() error
+}
+
 type Consumer struct {
-	r      *kafka.Reader
+	source MessageSource
 	router MessageRouter
+	logger *slog.Logger
 
 	workerCount    int
 	handlerTimeout time.Duration
+	commitTimeout  time.Duration
+
+	// batchRouter задан в пакетном режиме.
+	batchRouter  BatchRouter
+	batchSize    int
+	batchTimeout time.Duration
+
+	groupID       string
+	statsInterval time.Duration
+	onAssigned    func(context.Context, []TopicPartition)
+	onRevoked     func(context.Context, []TopicPartition)
+	metrics       *Metrics
+
+	assignMu sync.Mutex
+	// assigned - партиции, назначенные в текущем поколении группы.
+	assigned map[TopicPartition]bool
+
+	pauseMu sync.Mutex
+	// resumed закрывается при возобновлении чтения; nil, пока чтение не приостановлено.
+	resumed chan struct{}
 }
 
 type MessageRouter interface {
 	Handle(string, RouteHandler, ...RouteMiddleware)
-	Route(context.Context, *kafka.Message)
+	Route(context.Context, *kafka.Message) error
+}
+
+// BatchRouter - роутер, обрабатывающий сообщения пачками. Возвращает ошибки
+// обработки по индексам сообщений.
+type BatchRouter interface {
+	RouteBatch(context.Context, []*kafka.Message) []error
 }
 
 func NewConsumer(
 	cfg Configuration,
 	router MessageRouter,
 ) (*Consumer, error) {
-	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:           cfg.BrokerURLs,
-		GroupID:           cfg.GroupID,
-		GroupTopics:       cfg.GroupTopics,
-		Topic:             cfg.Topic,
-		Dialer:            &kafka.Dialer{},
-		MaxBytes:          10e6, // 10 MB
-		HeartbeatInterval: cfg.HeartbeatInterval,
-		SessionTimeout:    cfg.SessionTimeout,
-	})
-	err := r.SetOffset(kafka.LastOffset)
+	source, err := NewKafkaSource(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	return NewConsumerWithSource(cfg, source, router), nil
+}
+
+// NewConsumerWithSource создаёт Consumer поверх произвольного источника сообщений.
+// Параметры подключения к брокеру из cfg при этом игнорируются.
+func NewConsumerWithSource(
+	cfg Configuration,
+	source MessageSource,
+	router MessageRouter,
+) *Consumer {
 	if cfg.WorkerCount <= 0 {
 		cfg.WorkerCount = 8
 	}
 	if cfg.HandlerTimeout <= 0 {
 		cfg.HandlerTimeout = time.Minute
 	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.StatsInterval <= 0 {
+		cfg.StatsInterval = 30 * time.Second
+	}
 
-	return &Consumer{
-		r:              r,
+	c := &Consumer{
+		source:         source,
 		router:         router,
+		logger:         cfg.Logger,
 		workerCount:    cfg.WorkerCount,
 		handlerTimeout: cfg.HandlerTimeout,
-	}, nil
+		commitTimeout:  10 * time.Second,
+		groupID:        cfg.GroupID,
+		statsInterval:  cfg.StatsInterval,
+		onAssigned:     cfg.OnPartitionsAssigned,
+		onRevoked:      cfg.OnPartitionsRevoked,
+		metrics:        cfg.Metrics,
+		assigned:       make(map[TopicPartition]bool),
+	}
+
+	if batchRouter, ok := router.(BatchRouter); ok && cfg.BatchSize > 1 {
+		c.batchRouter = batchRouter
+		c.batchSize = cfg.BatchSize
+		c.batchTimeout = cfg.BatchTimeout
+		if c.batchTimeout <= 0 {
+			c.batchTimeout = 100 * time.Millisecond
+		}
+	}
+
+	return c
 }
 
+// Run читает сообщения до отмены контекста или ошибки чтения. Перед выходом
+// дожидается завершения уже полученных сообщений и фиксирует их смещения.
 func (c *Consumer) Run(ctx context.Context) error {
-	messageCh := make(chan *kafka.Message)
-	errCh := make(chan error)
-
-	defer close(messageCh)
+	if source, ok := c.source.(RebalanceSource); ok {
+		c.watchRebalances(ctx, source)
+	}
+	if source, ok := c.source.(AssignmentSource); ok {
+		mctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			c.monitor(mctx, source)
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+	}
 
-	go func() {
-		for {
-			msg, err := c.r.ReadMessage(ctx)
-			if err != nil {
-				errCh <- fmt.Errorf("error during message reading: %w", err)
-				return
-			}
+	if c.batchRouter != nil {
+		return c.runBatches(ctx)
+	}
 
-			select {
-			case <-ctx.Done():
-				return
-			case messageCh <- &msg:
-			}
-		}
-	}()
+	messageCh := make(chan kafka.Message)
+	tracker := newOffsetTracker()
 
+	var wg sync.WaitGroup
 	for i := 0; i < c.workerCount; i++ {
-		go c.worker(ctx, messageCh)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.worker(ctx, messageCh, tracker)
+		}()
 	}
 
-	var closeErr error
-	select {
-	case <-ctx.Done():
-		closeErr = ctx.Err()
-	case closeErr = <-errCh:
+	fetchErr := c.fetch(ctx, messageCh, tracker)
+	close(messageCh)
+	wg.Wait()
+
+	return errors.Join(fetchErr, c.source.Close())
+}
+
+// Pause приостанавливает получение новых сообщений. Уже полученные сообщения
+// обрабатываются, группа консьюмеров при этом не покидается.
+func (c *Consumer) Pause() {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+
+	if c.resumed == nil {
+		c.resumed = make(chan struct{})
+		c.logger.Warn("kafka consumer paused")
 	}
+}
+
+// Resume возобновляет получение сообщений после Pause.
+func (c *Consumer) Resume() {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
 
-	return errors.Join(closeErr, c.r.Close())
+	if c.resumed != nil {
+		close(c.resumed)
+		c.resumed = nil
+		c.logger.Info("kafka consumer resumed")
+	}
 }
 
-func (c *Consumer) worker(ctx context.Context, messageCh <-chan *kafka.Message) {
+func (c *Consumer) Paused() bool {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+
+	return c.resumed != nil
+}
+
+// waitResumed блокируется, пока чтение приостановлено.
+func (c *Consumer) waitResumed(ctx context.Context) error {
 	for {
+		c.pauseMu.Lock()
+		resumed := c.resumed
+		c.pauseMu.Unlock()
+
+		if resumed == nil {
+			return nil
+		}
+
 		select {
-		case message, ok := <-messageCh:
-			if !ok {
-				return
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-resumed:
+		}
+	}
+}
+
+func (c *Consumer) fetch(ctx context.Context, messageCh chan<- kafka.Message, tracker *offsetTracker) error {
+	for {
+		if err := c.waitResumed(ctx); err != nil {
+			return err
+		}
+
+		msg, err := c.source.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
 			}
 
-			hctx, cancel := context.WithTimeout(ctx, c.handlerTimeout)
-			c.router.Route(hctx, message)
-			cancel()
+			return fmt.Errorf("error during message reading: %w", err)
+		}
 
+		tracker.track(msg)
+
+		select {
 		case <-ctx.Done():
-			return
+			return ctx.Err()
+		case messageCh <- msg:
+		}
+	}
+}
+
+func (c *Consumer) worker(ctx context.Context, messageCh <-chan kafka.Message, tracker *offsetTracker) {
+	for message := range messageCh {
+		hctx, cancel := withHandlerTimeout(ctx, c.handlerTimeout)
+		err := c.router.Route(hctx, &message)
+		cancel()
+
+		c.logSkipped(message, err)
+
+		if commitMsg, ok := tracker.complete(message); ok {
+			c.commit(ctx, commitMsg)
 		}
 	}
 }
+
+type handlerScopeKey struct{}
+
+// handlerScope - контекст консьюмера и ограничение времени обработки сообщения.
+type handlerScope struct {
+	ctx     context.Context
+	timeout time.Duration
+}
+
+// withHandlerTimeout ограничивает обработку сообщения timeout и запоминает
+// контекст консьюмера, чтобы middleware, откладывающие обработку (например,
+// CircuitBreaker), могли ждать дольше timeout и повторять обработку с новым
+// ограничением.
+func withHandlerTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	scoped := context.WithValue(ctx, handlerScopeKey{}, handlerScope{ctx: ctx, timeout: timeout})
+	return context.WithTimeout(scoped, timeout)
+}
+
+func (c *Consumer) logSkipped(message kafka.Message, err error) {
+	if errors.Is(err, ErrNoRoute) {
+		c.logger.Warn(
+			"kafka message skipped",
+			slog.String("topic", message.Topic),
+			slog.String("key", string(message.Key)),
+			slog.Any("error", err),
+		)
+	}
+}
+
+func (c *Consumer) commit(ctx context.Context, message kafka.Message) {
+	// Смещения фиксируются и при остановке консьюмера, поэтому отмена
+	// родительского контекста здесь не учитывается.
+	cctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.commitTimeout)
+	defer cancel()
+
+	if err := c.source.CommitMessages(cctx, message); err != nil {
+		c.logger.Error(
+			"failed to commit kafka message offset",
+			slog.String("topic", message.Topic),
+			slog.Int("partition", message.Partition),
+			slog.Int64("offset", message.Offset),
+			slog.Any("error", err),
+		)
+	}
+}
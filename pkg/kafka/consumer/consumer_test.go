@@ -0,0 +1,257 @@
+//go:build unit_test
+
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/pkg/kafka/membroker"
+)
+
+const testTopic = "orders.public.order_create_events"
+
+func TestConsumerProcessesAndCommits(t *testing.T) {
+	broker := newTestBroker(t, 3, 30)
+
+	var processed atomic.Int64
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(_ context.Context, _ *kafka.Message) error {
+		processed.Add(1)
+		return nil
+	})
+
+	stop := runConsumer(t, broker, router, Configuration{WorkerCount: 4})
+
+	require.Eventually(t, func() bool {
+		return processed.Load() == 30
+	}, time.Second, 10*time.Millisecond)
+
+	assert.ErrorIs(t, stop(), context.Canceled)
+
+	for p := 0; p < 3; p++ {
+		expected := int64(len(broker.Messages(testTopic, p)))
+		assert.Equal(t, expected, broker.CommittedOffset("test", testTopic, p), "partition %d", p)
+	}
+}
+
+func TestConsumerWorkerConcurrency(t *testing.T) {
+	const workerCount = 4
+	broker := newTestBroker(t, 2, 20)
+
+	var (
+		active    atomic.Int64
+		maxActive atomic.Int64
+		processed atomic.Int64
+	)
+
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(_ context.Context, _ *kafka.Message) error {
+		current := active.Add(1)
+		for {
+			peak := maxActive.Load()
+			if current <= peak || maxActive.CompareAndSwap(peak, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		active.Add(-1)
+		processed.Add(1)
+
+		return nil
+	})
+
+	stop := runConsumer(t, broker, router, Configuration{WorkerCount: workerCount})
+
+	require.Eventually(t, func() bool {
+		return processed.Load() == 20
+	}, 2*time.Second, 10*time.Millisecond)
+	_ = stop()
+
+	assert.Equal(t, int64(workerCount), maxActive.Load())
+}
+
+func TestConsumerHandlerTimeout(t *testing.T) {
+	broker := newTestBroker(t, 1, 1)
+
+	errCh := make(chan error, 1)
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(ctx context.Context, _ *kafka.Message) error {
+		<-ctx.Done()
+		errCh <- ctx.Err()
+		return ctx.Err()
+	})
+
+	stop := runConsumer(t, broker, router, Configuration{HandlerTimeout: 20 * time.Millisecond})
+	defer stop()
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("handler was not timed out")
+	}
+
+	// Сообщение, обработка которого завершилась ошибкой, не перечитывается.
+	require.Eventually(t, func() bool {
+		return broker.CommittedOffset("test", testTopic, 0) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestConsumerCommitsContiguousOffsets(t *testing.T) {
+	broker := newTestBroker(t, 1, 5)
+
+	release := make(chan struct{})
+	var processed atomic.Int64
+
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(_ context.Context, message *kafka.Message) error {
+		if message.Offset == 0 {
+			<-release
+		}
+
+		processed.Add(1)
+		return nil
+	})
+
+	stop := runConsumer(t, broker, router, Configuration{WorkerCount: 5})
+	defer stop()
+
+	require.Eventually(t, func() bool {
+		return processed.Load() == 4
+	}, time.Second, 10*time.Millisecond)
+
+	// Пока первое сообщение не обработано, смещение партиции не двигается.
+	assert.Equal(t, int64(-1), broker.CommittedOffset("test", testTopic, 0))
+
+	close(release)
+	require.Eventually(t, func() bool {
+		return broker.CommittedOffset("test", testTopic, 0) == 5
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestOffsetTrackerIgnoresStaleOffsets(t *testing.T) {
+	tracker := newOffsetTracker()
+	message := func(offset int64) kafka.Message {
+		return kafka.Message{Topic: testTopic, Offset: offset}
+	}
+
+	_, ok := tracker.complete(message(0))
+	assert.False(t, ok, "untracked partition")
+
+	for _, offset := range []int64{5, 6, 7} {
+		tracker.track(message(offset))
+	}
+	// Партиция перечитана с начала после ребалансировки.
+	tracker.track(message(2))
+
+	for _, offset := range []int64{5, 6, 7, 100} {
+		_, ok = tracker.complete(message(offset))
+		assert.False(t, ok)
+	}
+
+	p := tracker.partitions[partitionKey{topic: testTopic}]
+	assert.Empty(t, p.done, "stale offsets must not be recorded")
+
+	committed, ok := tracker.complete(message(2))
+	require.True(t, ok)
+	assert.Equal(t, int64(2), committed.Offset)
+	assert.Empty(t, p.pending)
+}
+
+func TestConsumerShutdownWaitsForInFlight(t *testing.T) {
+	broker := newTestBroker(t, 1, 1)
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(_ context.Context, _ *kafka.Message) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+
+		return nil
+	})
+
+	stop := runConsumer(t, broker, router, Configuration{})
+	<-started
+
+	err := stop()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, finished.Load(), "consumer must wait for in-flight handlers")
+	assert.Equal(t, int64(1), broker.CommittedOffset("test", testTopic, 0))
+}
+
+func TestConsumerUnmatchedMessagesAreCommitted(t *testing.T) {
+	broker := newTestBroker(t, 1, 3)
+
+	stop := runConsumer(t, broker, NewTopicRouter(), Configuration{})
+	defer stop()
+
+	require.Eventually(t, func() bool {
+		return broker.CommittedOffset("test", testTopic, 0) == 3
+	}, time.Second, 10*time.Millisecond)
+}
+
+func newTestBroker(t *testing.T, partitions, messages int) *membroker.Broker {
+	t.Helper()
+
+	broker := membroker.NewBroker()
+	broker.CreateTopic(testTopic, partitions)
+
+	for i := 0; i < messages; i++ {
+		err := broker.Produce(context.Background(), kafka.Message{
+			Topic: testTopic,
+			Key:   []byte{byte(i)},
+		})
+		require.NoError(t, err)
+	}
+
+	return broker
+}
+
+// runConsumer запускает консьюмер в группе "test" и возвращает функцию,
+// останавливающую его и возвращающую результат Run.
+func runConsumer(t *testing.T, broker *membroker.Broker, router MessageRouter, cfg Configuration) func() error {
+	t.Helper()
+
+	source := broker.NewReader(membroker.ReaderConfig{
+		GroupID:     "test",
+		Topic:       testTopic,
+		StartOffset: kafka.FirstOffset,
+	})
+	c := NewConsumerWithSource(cfg, source, router)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.Run(ctx)
+	}()
+
+	var once sync.Once
+	var runErr error
+
+	return func() error {
+		once.Do(func() {
+			cancel()
+
+			select {
+			case runErr = <-errCh:
+			case <-time.After(time.Second):
+				runErr = errors.New("consumer did not stop in time")
+			}
+		})
+
+		return runErr
+	}
+}
@@ -0,0 +1,285 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Заголовки, которые DeadLetterMiddleware добавляет к сообщениям DLQ.
+const (
+	HeaderDLQOriginalTopic     = "dlq-original-topic"
+	HeaderDLQOriginalPartition = "dlq-original-partition"
+	HeaderDLQOriginalOffset    = "dlq-original-offset"
+	HeaderDLQError             = "dlq-error"
+	HeaderDLQFailedAt          = "dlq-failed-at"
+)
+
+// ErrNotDeadLetter возвращается для сообщений без заголовков DLQ.
+var ErrNotDeadLetter = errors.New("message is not a dead letter")
+
+// MessageWriter записывает сообщения в топики, указанные в kafka.Message.Topic.
+// Реализуется kafka.Writer без заданного топика.
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, messages ...kafka.Message) error
+}
+
+// NewKafkaWriter создаёт kafka.Writer с параметрами подключения из cfg.
+// Топик берётся из записываемых сообщений и создаётся при первой записи,
+// если это разрешено брокером.
+func NewKafkaWriter(cfg Configuration) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(cfg.BrokerURLs...),
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+		Transport: &kafka.Transport{
+			TLS:  cfg.TLS,
+			SASL: cfg.SASL,
+		},
+	}
+}
+
+// DeadLetterMiddleware записывает сообщения, обработка которых завершилась
+// ошибкой, в топик topic вместе с исходными заголовками и заголовками DLQ.
+// Ошибка обработчика возвращается дальше без изменений.
+func DeadLetterMiddleware(writer MessageWriter, topic string) RouteMiddleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(ctx context.Context, message *kafka.Message) error {
+			err := next(ctx, message)
+			if err == nil {
+				return nil
+			}
+
+			// Контекст обработчика может быть уже отменён по таймауту.
+			wctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+			defer cancel()
+
+			if werr := writer.WriteMessages(wctx, newDeadLetterMessage(topic, message, err, time.Now())); werr != nil {
+				return errors.Join(err, fmt.Errorf("failed to write message to dead letter topic: %w", werr))
+			}
+
+			return err
+		}
+	}
+}
+
+func newDeadLetterMessage(topic string, message *kafka.Message, err error, failedAt time.Time) kafka.Message {
+	headers := append(withoutDeadLetterHeaders(message.Headers),
+		kafka.Header{Key: HeaderDLQOriginalTopic, Value: []byte(message.Topic)},
+		kafka.Header{Key: HeaderDLQOriginalPartition, Value: []byte(strconv.Itoa(message.Partition))},
+		kafka.Header{Key: HeaderDLQOriginalOffset, Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		kafka.Header{Key: HeaderDLQError, Value: []byte(err.Error())},
+		kafka.Header{Key: HeaderDLQFailedAt, Value: []byte(failedAt.UTC().Format(time.RFC3339Nano))},
+	)
+
+	return kafka.Message{
+		Topic:   topic,
+		Key:     message.Key,
+		Value:   message.Value,
+		Headers: headers,
+	}
+}
+
+// withoutDeadLetterHeaders возвращает копию заголовков без заголовков DLQ,
+// чтобы при повторном попадании в DLQ они не дублировались.
+func withoutDeadLetterHeaders(headers []kafka.Header) []kafka.Header {
+	result := make([]kafka.Header, 0, len(headers)+5)
+	for _, header := range headers {
+		if !strings.HasPrefix(header.Key, "dlq-") {
+			result = append(result, header)
+		}
+	}
+
+	return result
+}
+
+// DeadLetter - сообщение DLQ с разобранными заголовками.
+type DeadLetter struct {
+	// Message - сообщение в топике DLQ.
+	Message           kafka.Message
+	OriginalTopic     string
+	OriginalPartition int
+	OriginalOffset    int64
+	Error             string
+	FailedAt          time.Time
+}
+
+// ParseDeadLetter разбирает заголовки сообщения DLQ.
+func ParseDeadLetter(message kafka.Message) (DeadLetter, error) {
+	dl := DeadLetter{Message: message}
+
+	values := make(map[string]string, 5)
+	for _, header := range message.Headers {
+		values[header.Key] = string(header.Value)
+	}
+
+	var ok bool
+	if dl.OriginalTopic, ok = values[HeaderDLQOriginalTopic]; !ok || dl.OriginalTopic == "" {
+		return dl, fmt.Errorf("%w: header %q is missing", ErrNotDeadLetter, HeaderDLQOriginalTopic)
+	}
+	dl.Error = values[HeaderDLQError]
+
+	var err error
+	if dl.OriginalPartition, err = strconv.Atoi(values[HeaderDLQOriginalPartition]); err != nil {
+		return dl, fmt.Errorf("%w: invalid header %q", ErrNotDeadLetter, HeaderDLQOriginalPartition)
+	}
+	if dl.OriginalOffset, err = strconv.ParseInt(values[HeaderDLQOriginalOffset], 10, 64); err != nil {
+		return dl, fmt.Errorf("%w: invalid header %q", ErrNotDeadLetter, HeaderDLQOriginalOffset)
+	}
+	if dl.FailedAt, err = time.Parse(time.RFC3339Nano, values[HeaderDLQFailedAt]); err != nil {
+		return dl, fmt.Errorf("%w: invalid header %q", ErrNotDeadLetter, HeaderDLQFailedAt)
+	}
+
+	return dl, nil
+}
+
+// Original восстанавливает исходное сообщение: топик, партицию, смещение
+// и заголовки без заголовков DLQ.
+func (d DeadLetter) Original() kafka.Message {
+	return kafka.Message{
+		Topic:     d.OriginalTopic,
+		Partition: d.OriginalPartition,
+		Offset:    d.OriginalOffset,
+		Key:       d.Message.Key,
+		Value:     d.Message.Value,
+		Headers:   withoutDeadLetterHeaders(d.Message.Headers),
+		Time:      d.Message.Time,
+	}
+}
+
+// DeadLetterPosition - положение сообщения в топике DLQ.
+type DeadLetterPosition struct {
+	Partition int
+	Offset    int64
+}
+
+// DeadLetterFilter отбирает сообщения DLQ. Нулевые значения полей
+// означают отсутствие ограничения.
+type DeadLetterFilter struct {
+	// Error - шаблон текста ошибки обработки.
+	Error         *regexp.Regexp
+	OriginalTopic string
+	// Since и Until ограничивают время ошибки обработки.
+	Since time.Time
+	Until time.Time
+	// Positions - выбранные сообщения DLQ.
+	Positions []DeadLetterPosition
+}
+
+func (f DeadLetterFilter) Match(d DeadLetter) bool {
+	switch {
+	case f.Error != nil && !f.Error.MatchString(d.Error):
+		return false
+	case f.OriginalTopic != "" && d.OriginalTopic != f.OriginalTopic:
+		return false
+	case !f.Since.IsZero() && d.FailedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && d.FailedAt.After(f.Until):
+		return false
+	case len(f.Positions) > 0 && !slices.Contains(f.Positions, DeadLetterPosition{
+		Partition: d.Message.Partition,
+		Offset:    d.Message.Offset,
+	}):
+		return false
+	}
+
+	return true
+}
+
+// RedriveTarget определяет, куда повторно отправляются сообщения DLQ.
+type RedriveTarget string
+
+const (
+	// RedriveToTopic записывает сообщение в исходный топик, где его прочитает рабочий консьюмер.
+	RedriveToTopic RedriveTarget = "topic"
+	// RedriveToHandler сразу передаёт сообщение обработчику маршрута.
+	RedriveToHandler RedriveTarget = "handler"
+)
+
+// DeadLetterQueue - просмотр и повторная отправка сообщений топика DLQ.
+type DeadLetterQueue struct {
+	topic  string
+	source ReplaySource
+	writer MessageWriter
+	router *TopicRouter
+
+	// IdleTimeout - сколько ждать следующего сообщения DLQ до остановки чтения.
+	IdleTimeout time.Duration
+}
+
+// NewDeadLetterQueue создаёт DeadLetterQueue. Сообщения читаются из source
+// отдельной группой консьюмеров. writer нужен для RedriveToTopic, router -
+// для RedriveToHandler.
+func NewDeadLetterQueue(topic string, source ReplaySource, writer MessageWriter, router *TopicRouter) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		topic:  topic,
+		source: source,
+		writer: writer,
+		router: router,
+	}
+}
+
+// Scan вызывает fn для каждого подходящего под filter сообщения DLQ, записанного
+// до начала чтения, и закрывает источник. Сообщения без заголовков DLQ
+// и ошибки fn не прерывают чтение и возвращаются вместе по его окончании.
+func (q *DeadLetterQueue) Scan(ctx context.Context, filter DeadLetterFilter, fn func(DeadLetter) error) error {
+	router := NewTopicRouter()
+	router.HandleUnmatched(func(_ context.Context, message *kafka.Message) error {
+		dl, err := ParseDeadLetter(*message)
+		if err != nil {
+			return err
+		}
+		if !filter.Match(dl) {
+			return nil
+		}
+
+		return fn(dl)
+	})
+
+	var errs []error
+	_, err := NewReplayer(ReplayConfiguration{
+		Topic:       q.topic,
+		IdleTimeout: q.IdleTimeout,
+		OnResult: func(result ReplayResult) {
+			if result.Err != nil {
+				errs = append(errs, fmt.Errorf("partition %d offset %d: %w", result.Partition, result.Offset, result.Err))
+			}
+		},
+	}, q.source, router).Run(ctx)
+
+	return errors.Join(append(errs, err)...)
+}
+
+// Redrive повторно отправляет сообщение DLQ в target.
+func (q *DeadLetterQueue) Redrive(ctx context.Context, dl DeadLetter, target RedriveTarget) error {
+	message := dl.Original()
+
+	switch target {
+	case RedriveToTopic:
+		if q.writer == nil {
+			return errors.New("message writer is not configured")
+		}
+		// Партицию и смещение назначит брокер.
+		return q.writer.WriteMessages(ctx, kafka.Message{
+			Topic:   message.Topic,
+			Key:     message.Key,
+			Value:   message.Value,
+			Headers: message.Headers,
+		})
+	case RedriveToHandler:
+		if q.router == nil {
+			return errors.New("message router is not configured")
+		}
+		return q.router.Route(ctx, &message)
+	default:
+		return fmt.Errorf("unknown redrive target %q", target)
+	}
+}
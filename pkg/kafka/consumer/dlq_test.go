@@ -0,0 +1,181 @@
+//go:build unit_test
+
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/pkg/kafka/membroker"
+)
+
+const testDLQTopic = "test.dlq"
+
+// newDeadLetterBroker возвращает брокер, в DLQ которого попали сообщения
+// testTopic с нечётными ключами.
+func newDeadLetterBroker(t *testing.T) *membroker.Broker {
+	t.Helper()
+
+	broker := newTestBroker(t, 2, 6)
+	broker.CreateTopic(testDLQTopic, 1)
+
+	handler := DeadLetterMiddleware(broker, testDLQTopic)(func(_ context.Context, msg *kafka.Message) error {
+		if msg.Key[0]%2 == 1 {
+			return fmt.Errorf("odd key %d", msg.Key[0])
+		}
+		return nil
+	})
+
+	for p := 0; p < 2; p++ {
+		for _, msg := range broker.Messages(testTopic, p) {
+			msg.Headers = []kafka.Header{{Key: "x-request-id", Value: []byte("req")}}
+			err := handler(context.Background(), &msg)
+			assert.Equal(t, msg.Key[0]%2 == 1, err != nil, "handler error must be returned")
+		}
+	}
+
+	return broker
+}
+
+func newDeadLetterSource(broker *membroker.Broker, groupID string) *membroker.Reader {
+	return broker.NewReader(membroker.ReaderConfig{
+		GroupID:     groupID,
+		Topic:       testDLQTopic,
+		StartOffset: kafka.FirstOffset,
+	})
+}
+
+func TestDeadLetterMiddleware(t *testing.T) {
+	broker := newDeadLetterBroker(t)
+
+	letters := broker.Messages(testDLQTopic, 0)
+	require.Len(t, letters, 3)
+
+	dl, err := ParseDeadLetter(letters[0])
+	require.NoError(t, err)
+	assert.Equal(t, testTopic, dl.OriginalTopic)
+	assert.Equal(t, fmt.Sprintf("odd key %d", dl.Message.Key[0]), dl.Error)
+	assert.False(t, dl.FailedAt.IsZero())
+
+	original := broker.Messages(testTopic, dl.OriginalPartition)[dl.OriginalOffset]
+	assert.Equal(t, original.Key, dl.Message.Key)
+
+	restored := dl.Original()
+	assert.Equal(t, testTopic, restored.Topic)
+	assert.Equal(t, dl.OriginalOffset, restored.Offset)
+	assert.Equal(t, []kafka.Header{{Key: "x-request-id", Value: []byte("req")}}, restored.Headers)
+
+	_, err = ParseDeadLetter(original)
+	assert.ErrorIs(t, err, ErrNotDeadLetter)
+}
+
+func TestDeadLetterQueueScan(t *testing.T) {
+	broker := newDeadLetterBroker(t)
+	require.NoError(t, broker.Produce(context.Background(), kafka.Message{Topic: testDLQTopic, Value: []byte("garbage")}))
+
+	var keys []byte
+	q := NewDeadLetterQueue(testDLQTopic, newDeadLetterSource(broker, "dlq"), nil, nil)
+	err := q.Scan(context.Background(), DeadLetterFilter{Error: regexp.MustCompile(`key [13]$`)}, func(dl DeadLetter) error {
+		keys = append(keys, dl.Message.Key[0])
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrNotDeadLetter, "messages without dead letter headers must be reported")
+	assert.ElementsMatch(t, []byte{1, 3}, keys)
+
+	keys = nil
+	q = NewDeadLetterQueue(testDLQTopic, newDeadLetterSource(broker, "dlq-positions"), nil, nil)
+	_ = q.Scan(context.Background(), DeadLetterFilter{Positions: []DeadLetterPosition{{Partition: 0, Offset: 2}}}, func(dl DeadLetter) error {
+		keys = append(keys, dl.Message.Key[0])
+		return nil
+	})
+	assert.Equal(t, []byte{broker.Messages(testDLQTopic, 0)[2].Key[0]}, keys)
+}
+
+func TestDeadLetterQueueRedrive(t *testing.T) {
+	ctx := context.Background()
+	broker := newDeadLetterBroker(t)
+	dl, err := ParseDeadLetter(broker.Messages(testDLQTopic, 0)[0])
+	require.NoError(t, err)
+
+	var routed *kafka.Message
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(_ context.Context, msg *kafka.Message) error {
+		routed = msg
+		return nil
+	})
+	q := NewDeadLetterQueue(testDLQTopic, nil, broker, router)
+
+	require.NoError(t, q.Redrive(ctx, dl, RedriveToHandler))
+	require.NotNil(t, routed)
+	assert.Equal(t, dl.OriginalOffset, routed.Offset)
+
+	partition := dl.OriginalPartition
+	before := len(broker.Messages(testTopic, partition))
+	require.NoError(t, q.Redrive(ctx, dl, RedriveToTopic))
+	messages := broker.Messages(testTopic, partition)
+	require.Len(t, messages, before+1)
+	assert.Equal(t, dl.Message.Key, messages[before].Key)
+	assert.Equal(t, []kafka.Header{{Key: "x-request-id", Value: []byte("req")}}, messages[before].Headers)
+
+	assert.Error(t, q.Redrive(ctx, dl, "elsewhere"))
+}
+
+func TestDeadLettersCommand(t *testing.T) {
+	broker := newDeadLetterBroker(t)
+	cfg := Configuration{GroupID: "test", Topic: testTopic}
+	decode := func(_ context.Context, msg *kafka.Message) (any, error) {
+		return map[string]int{"key": int(msg.Key[0])}, nil
+	}
+
+	opts, err := ParseDeadLetterFlags([]string{DeadLetterList, "-error", "key 5"}, cfg, testDLQTopic, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(opts.GroupID, "test-dlq-"))
+
+	var out bytes.Buffer
+	q := NewDeadLetterQueue(testDLQTopic, newDeadLetterSource(broker, opts.GroupID), broker, nil)
+	require.NoError(t, DeadLetters(context.Background(), opts, q, decode, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var line struct {
+		OriginalTopic string         `json:"original_topic"`
+		Error         string         `json:"error"`
+		Payload       map[string]int `json:"payload"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, testTopic, line.OriginalTopic)
+	assert.Equal(t, "odd key 5", line.Error)
+	assert.Equal(t, map[string]int{"key": 5}, line.Payload)
+	assert.JSONEq(t, `{"summary":{"listed":1}}`, lines[1])
+
+	opts, err = ParseDeadLetterFlags([]string{DeadLetterRedrive, "-messages", "0:0,0:1"}, cfg, testDLQTopic, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	out.Reset()
+	q = NewDeadLetterQueue(testDLQTopic, newDeadLetterSource(broker, opts.GroupID+"-redrive"), broker, nil)
+	require.NoError(t, DeadLetters(context.Background(), opts, q, nil, &out))
+	assert.Contains(t, out.String(), `{"summary":{"listed":2,"redriven":2}}`)
+	assert.Len(t, append(broker.Messages(testTopic, 0), broker.Messages(testTopic, 1)...), 8)
+
+	for _, args := range [][]string{
+		{},
+		{"show"},
+		{DeadLetterRedrive},
+		{DeadLetterRedrive, "-all", "-to", "nowhere"},
+		{DeadLetterList, "-messages", "1"},
+		{DeadLetterList, "-group", "test"},
+	} {
+		_, err = ParseDeadLetterFlags(args, cfg, testDLQTopic, &bytes.Buffer{})
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,274 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// DeadLetterCommand - имя подкоманды сервисов для работы с DLQ.
+const DeadLetterCommand = "dlq"
+
+// Действия подкоманды dlq.
+const (
+	DeadLetterList    = "list"
+	DeadLetterRedrive = "redrive"
+)
+
+// DeadLetterDecoder декодирует payload исходного сообщения для вывода.
+type DeadLetterDecoder func(ctx context.Context, message *kafka.Message) (any, error)
+
+// DeadLetterDecoderFor создаёт DeadLetterDecoder из функции декодирования события.
+func DeadLetterDecoderFor[T any](decode func(context.Context, *kafka.Message) (T, error)) DeadLetterDecoder {
+	return func(ctx context.Context, message *kafka.Message) (any, error) {
+		return decode(ctx, message)
+	}
+}
+
+// DeadLetterOptions - параметры подкоманды dlq.
+type DeadLetterOptions struct {
+	Action string
+	Topic  string
+	// GroupID - группа консьюмеров для чтения DLQ.
+	GroupID     string
+	Filter      DeadLetterFilter
+	Target      RedriveTarget
+	IdleTimeout time.Duration
+}
+
+// ParseDeadLetterFlags разбирает аргументы подкоманды dlq: действие list или
+// redrive и флаги. Топик DLQ по умолчанию - topic, группа - новая группа
+// с суффиксом "-dlq-<время запуска>". Для redrive без фильтров требуется -all,
+// чтобы случайно не отправить повторно весь DLQ.
+func ParseDeadLetterFlags(args []string, cfg Configuration, topic string, output io.Writer) (DeadLetterOptions, error) {
+	var opts DeadLetterOptions
+	if len(args) == 0 || (args[0] != DeadLetterList && args[0] != DeadLetterRedrive) {
+		return opts, fmt.Errorf("action must be %q or %q", DeadLetterList, DeadLetterRedrive)
+	}
+	opts.Action = args[0]
+
+	fs := flag.NewFlagSet(DeadLetterCommand+" "+opts.Action, flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	var (
+		errPattern string
+		since      string
+		until      string
+		messages   string
+		target     string
+		all        bool
+	)
+	fs.StringVar(&opts.Topic, "topic", topic, "Dead letter topic")
+	fs.StringVar(&opts.GroupID, "group", "", "Consumer group for reading the dead letter topic. Defaults to a new group")
+	fs.StringVar(&errPattern, "error", "", "Regular expression the processing error must match")
+	fs.StringVar(&opts.Filter.OriginalTopic, "original-topic", "", "Original topic of messages")
+	fs.StringVar(&since, "from-time", "", "Messages failed at or after this RFC 3339 time")
+	fs.StringVar(&until, "to-time", "", "Messages failed at or before this RFC 3339 time")
+	fs.StringVar(&messages, "messages", "", "Comma-separated partition:offset positions of messages in the dead letter topic")
+	fs.DurationVar(&opts.IdleTimeout, "idle-timeout", 10*time.Second, "Stop if no message arrives for this long")
+	if opts.Action == DeadLetterRedrive {
+		fs.StringVar(&target, "to", string(RedriveToTopic), "Redrive target: topic or handler")
+		fs.BoolVar(&all, "all", false, "Redrive all messages when no filter is set")
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return opts, err
+	}
+
+	if opts.Topic == "" {
+		return opts, errors.New("dead letter topic is not set")
+	}
+
+	var err error
+	if errPattern != "" {
+		if opts.Filter.Error, err = regexp.Compile(errPattern); err != nil {
+			return opts, fmt.Errorf("invalid error pattern: %w", err)
+		}
+	}
+	if opts.Filter.Since, err = parseReplayTime(since); err != nil {
+		return opts, err
+	}
+	if opts.Filter.Until, err = parseReplayTime(until); err != nil {
+		return opts, err
+	}
+	if opts.Filter.Positions, err = parseDeadLetterPositions(messages); err != nil {
+		return opts, err
+	}
+
+	if opts.Action == DeadLetterRedrive {
+		opts.Target = RedriveTarget(target)
+		if opts.Target != RedriveToTopic && opts.Target != RedriveToHandler {
+			return opts, fmt.Errorf("unknown redrive target %q", target)
+		}
+		filtered := errPattern != "" || opts.Filter.OriginalTopic != "" || since != "" || until != "" || messages != ""
+		if !filtered && !all {
+			return opts, errors.New("redrive of all messages requires -all")
+		}
+	}
+
+	if opts.GroupID == "" {
+		prefix := cfg.GroupID
+		if prefix == "" {
+			prefix = opts.Topic
+		}
+		opts.GroupID = fmt.Sprintf("%s-dlq-%d", prefix, time.Now().Unix())
+	}
+	if opts.GroupID == cfg.GroupID {
+		return opts, fmt.Errorf("dead letter group must differ from the consumer group %q", cfg.GroupID)
+	}
+
+	return opts, nil
+}
+
+func parseDeadLetterPositions(value string) ([]DeadLetterPosition, error) {
+	var positions []DeadLetterPosition
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		p, o, ok := strings.Cut(item, ":")
+		partition, perr := strconv.Atoi(p)
+		offset, oerr := strconv.ParseInt(o, 10, 64)
+		if !ok || perr != nil || oerr != nil || partition < 0 || offset < 0 {
+			return nil, fmt.Errorf("invalid message position %q", item)
+		}
+		positions = append(positions, DeadLetterPosition{Partition: partition, Offset: offset})
+	}
+
+	return positions, nil
+}
+
+// RunDeadLetterCommand выполняет подкоманду dlq: читает топик DLQ отдельной
+// группой консьюмеров и пишет в output подходящие сообщения с декодированным
+// payload'ом и итог построчно в JSON. Действие redrive отправляет сообщения
+// в исходный топик или обработчику router. Возвращает ошибку, если хотя бы
+// одно сообщение не удалось разобрать или отправить повторно.
+func RunDeadLetterCommand(
+	ctx context.Context,
+	args []string,
+	cfg Configuration,
+	topic string,
+	router *TopicRouter,
+	decode DeadLetterDecoder,
+	output io.Writer,
+) error {
+	opts, err := ParseDeadLetterFlags(args, cfg, topic, output)
+	if err != nil {
+		return err
+	}
+
+	cfg.GroupID = opts.GroupID
+	cfg.GroupTopics = nil
+	cfg.Topic = opts.Topic
+
+	source, err := NewKafkaReplaySource(cfg)
+	if err != nil {
+		return err
+	}
+
+	var writer MessageWriter
+	if opts.Target == RedriveToTopic {
+		kw := NewKafkaWriter(cfg)
+		defer kw.Close()
+		writer = kw
+	}
+
+	return DeadLetters(ctx, opts, NewDeadLetterQueue(opts.Topic, source, writer, router), decode, output)
+}
+
+// DeadLetters выполняет действие подкоманды dlq с q и пишет результаты в output,
+// как RunDeadLetterCommand.
+func DeadLetters(ctx context.Context, opts DeadLetterOptions, q *DeadLetterQueue, decode DeadLetterDecoder, output io.Writer) error {
+	q.IdleTimeout = opts.IdleTimeout
+	encoder := json.NewEncoder(output)
+
+	summary := map[string]int{}
+	err := q.Scan(ctx, opts.Filter, func(dl DeadLetter) error {
+		line := newDeadLetterLine(ctx, dl, decode)
+		summary["listed"]++
+
+		if opts.Action == DeadLetterRedrive {
+			if rerr := q.Redrive(ctx, dl, opts.Target); rerr != nil {
+				line.RedriveError = rerr.Error()
+				summary["failed"]++
+			} else {
+				line.Redriven = opts.Target
+				summary["redriven"]++
+			}
+		}
+
+		_ = encoder.Encode(line)
+		return nil
+	})
+
+	_ = encoder.Encode(struct {
+		Summary map[string]int `json:"summary"`
+	}{summary})
+	if err != nil {
+		return err
+	}
+
+	if failed := summary["failed"]; failed > 0 {
+		return fmt.Errorf("%d messages failed", failed)
+	}
+
+	return nil
+}
+
+type deadLetterLine struct {
+	Partition         int             `json:"partition"`
+	Offset            int64           `json:"offset"`
+	OriginalTopic     string          `json:"original_topic"`
+	OriginalPartition int             `json:"original_partition"`
+	OriginalOffset    int64           `json:"original_offset"`
+	Key               string          `json:"key,omitempty"`
+	Error             string          `json:"error"`
+	FailedAt          time.Time       `json:"failed_at"`
+	Payload           any             `json:"payload,omitempty"`
+	Value             json.RawMessage `json:"value,omitempty"`
+	DecodeError       string          `json:"decode_error,omitempty"`
+	Redriven          RedriveTarget   `json:"redriven,omitempty"`
+	RedriveError      string          `json:"redrive_error,omitempty"`
+}
+
+func newDeadLetterLine(ctx context.Context, dl DeadLetter, decode DeadLetterDecoder) deadLetterLine {
+	line := deadLetterLine{
+		Partition:         dl.Message.Partition,
+		Offset:            dl.Message.Offset,
+		OriginalTopic:     dl.OriginalTopic,
+		OriginalPartition: dl.OriginalPartition,
+		OriginalOffset:    dl.OriginalOffset,
+		Key:               string(dl.Message.Key),
+		Error:             dl.Error,
+		FailedAt:          dl.FailedAt,
+	}
+
+	if decode != nil {
+		original := dl.Original()
+		payload, err := decode(ctx, &original)
+		if err == nil {
+			line.Payload = payload
+			return line
+		}
+		line.DecodeError = err.Error()
+	}
+
+	// Нераспознанный payload выводится как есть: JSON - без изменений, прочее - строкой.
+	if json.Valid(dl.Message.Value) {
+		line.Value = dl.Message.Value
+	} else {
+		line.Value, _ = json.Marshal(string(dl.Message.Value))
+	}
+
+	return line
+}
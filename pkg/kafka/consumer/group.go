@@ -0,0 +1,108 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// GroupState - участники группы консьюмеров, назначенные им партиции
+// и смещения группы.
+type GroupState struct {
+	GroupID    string           `json:"group_id"`
+	State      string           `json:"state"`
+	Members    []GroupMember    `json:"members"`
+	Partitions []PartitionState `json:"partitions"`
+}
+
+type GroupMember struct {
+	MemberID   string           `json:"member_id"`
+	ClientID   string           `json:"client_id"`
+	ClientHost string           `json:"client_host"`
+	Partitions []TopicPartition `json:"partitions"`
+}
+
+// PartitionState - смещения группы в партиции и участник, которому она назначена.
+type PartitionState struct {
+	PartitionLag
+	MemberID string `json:"member_id,omitempty"`
+}
+
+// DescribeGroupState возвращает состояние группы groupID во всех партициях topics.
+func DescribeGroupState(ctx context.Context, admin GroupAdmin, groupID string, topics []string) (GroupState, error) {
+	group, err := admin.DescribeGroup(ctx, groupID)
+	if err != nil {
+		return GroupState{}, err
+	}
+
+	state := GroupState{
+		GroupID:    groupID,
+		State:      group.GroupState,
+		Members:    make([]GroupMember, 0, len(group.Members)),
+		Partitions: []PartitionState{},
+	}
+	owners := make(map[TopicPartition]string)
+	for _, m := range group.Members {
+		member := GroupMember{
+			MemberID:   m.MemberID,
+			ClientID:   m.ClientID,
+			ClientHost: m.ClientHost,
+			Partitions: []TopicPartition{},
+		}
+		for _, topic := range m.MemberAssignments.Topics {
+			for _, partition := range topic.Partitions {
+				tp := TopicPartition{Topic: topic.Topic, Partition: partition}
+				member.Partitions = append(member.Partitions, tp)
+				owners[tp] = m.MemberID
+			}
+		}
+		sortPartitions(member.Partitions)
+		state.Members = append(state.Members, member)
+	}
+
+	for _, topic := range topics {
+		committed, err := admin.CommittedOffsets(ctx, groupID, topic)
+		if err != nil {
+			return GroupState{}, err
+		}
+		high, err := admin.HighWatermarks(ctx, topic)
+		if err != nil {
+			return GroupState{}, err
+		}
+
+		for _, partition := range sortedKeys(high) {
+			tp := TopicPartition{Topic: topic, Partition: partition}
+			state.Partitions = append(state.Partitions, PartitionState{
+				PartitionLag: newPartitionLag(tp, committed, high),
+				MemberID:     owners[tp],
+			})
+		}
+	}
+
+	return state, nil
+}
+
+// GroupStateHandler возвращает HTTP-обработчик, отдающий в формате JSON
+// GroupState группы консьюмера cfg в читаемых им топиках.
+func GroupStateHandler(admin GroupAdmin, cfg Configuration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		state, err := DescribeGroupState(ctx, admin, cfg.GroupID, cfg.topics())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(state)
+	})
+}
@@ -0,0 +1,268 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// groupReader читает партиции, назначенные участнику группы. В отличие
+// от kafka.Reader, он сообщает о назначении партиций в каждом поколении группы
+// и об их отзыве по завершении поколения, до повторного вступления в группу.
+type groupReader struct {
+	cfg    kafka.ConsumerGroupConfig
+	logger *slog.Logger
+
+	messages chan kafka.Message
+	start    sync.Once
+	// done закрывается по завершении чтения поколений.
+	done chan struct{}
+
+	mu                sync.Mutex
+	group             *kafka.ConsumerGroup
+	generation        *kafka.Generation
+	assignment        map[string][]int
+	assigned, revoked func(map[string][]int)
+}
+
+func newGroupReader(cfg kafka.ConsumerGroupConfig, logger *slog.Logger) (*groupReader, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &groupReader{
+		cfg:      cfg,
+		logger:   logger,
+		messages: make(chan kafka.Message),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// SetRebalanceHooks задаёт функции, вызываемые при назначении и отзыве партиций.
+// Участник вступает в группу при первом получении сообщения, поэтому хуки нужно
+// задать до него.
+func (r *groupReader) SetRebalanceHooks(assigned, revoked func(map[string][]int)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.assigned, r.revoked = assigned, revoked
+}
+
+func (r *groupReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if err := r.join(); err != nil {
+		return kafka.Message{}, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	case <-r.done:
+		return kafka.Message{}, kafka.ErrGroupClosed
+	case msg := <-r.messages:
+		return msg, nil
+	}
+}
+
+// CommitMessages фиксирует смещения в текущем поколении группы. Фиксация
+// партиций, не назначенных участнику в этом поколении, отклоняется, чтобы
+// сообщения прошлых поколений не перезаписали смещения нового владельца.
+func (r *groupReader) CommitMessages(_ context.Context, messages ...kafka.Message) error {
+	r.mu.Lock()
+	generation, assignment := r.generation, r.assignment
+	r.mu.Unlock()
+
+	if generation == nil {
+		return kafka.RebalanceInProgress
+	}
+
+	offsets := make(map[string]map[int]int64)
+	for _, msg := range messages {
+		if !containsPartition(assignment[msg.Topic], msg.Partition) {
+			return kafka.RebalanceInProgress
+		}
+
+		if offsets[msg.Topic] == nil {
+			offsets[msg.Topic] = make(map[int]int64)
+		}
+		if next := msg.Offset + 1; next > offsets[msg.Topic][msg.Partition] {
+			offsets[msg.Topic][msg.Partition] = next
+		}
+	}
+
+	return generation.CommitOffsets(offsets)
+}
+
+// Assignment возвращает партиции, назначенные участнику в текущем поколении.
+func (r *groupReader) Assignment(context.Context) (map[string][]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignment := make(map[string][]int, len(r.assignment))
+	for topic, partitions := range r.assignment {
+		assignment[topic] = append([]int(nil), partitions...)
+	}
+
+	return assignment, nil
+}
+
+// Close выводит участника из группы. Партиции последнего поколения
+// отзываются до возврата из Close.
+func (r *groupReader) Close() error {
+	var err error
+	r.start.Do(func() { close(r.done) })
+
+	r.mu.Lock()
+	group := r.group
+	r.mu.Unlock()
+
+	if group != nil {
+		err = group.Close()
+		<-r.done
+	}
+
+	return err
+}
+
+func (r *groupReader) join() error {
+	var err error
+	r.start.Do(func() {
+		var group *kafka.ConsumerGroup
+		if group, err = kafka.NewConsumerGroup(r.cfg); err != nil {
+			close(r.done)
+			return
+		}
+
+		r.mu.Lock()
+		r.group = group
+		r.mu.Unlock()
+
+		go r.run(group)
+	})
+
+	return err
+}
+
+func (r *groupReader) run(group *kafka.ConsumerGroup) {
+	defer close(r.done)
+
+	for {
+		generation, err := group.Next(context.Background())
+		if errors.Is(err, kafka.ErrGroupClosed) {
+			return
+		}
+		if err != nil {
+			// Группа повторяет вступление сама, ошибки только записываются.
+			r.logger.Warn("failed to join kafka consumer group", slog.String("group", r.cfg.ID), slog.Any("error", err))
+			continue
+		}
+
+		r.startGeneration(generation)
+	}
+}
+
+func (r *groupReader) startGeneration(generation *kafka.Generation) {
+	assignment := make(map[string][]int, len(generation.Assignments))
+	for topic, partitions := range generation.Assignments {
+		for _, p := range partitions {
+			assignment[topic] = append(assignment[topic], p.ID)
+		}
+		sort.Ints(assignment[topic])
+	}
+
+	r.mu.Lock()
+	r.generation, r.assignment = generation, assignment
+	assigned, revoked := r.assigned, r.revoked
+	r.mu.Unlock()
+
+	if assigned != nil {
+		assigned(assignment)
+	}
+
+	// Группа ждёт завершения функций поколения перед повторным вступлением,
+	// поэтому партиции отзываются до их назначения другим участникам.
+	generation.Start(func(ctx context.Context) {
+		<-ctx.Done()
+
+		r.mu.Lock()
+		r.generation, r.assignment = nil, nil
+		r.mu.Unlock()
+
+		if revoked != nil {
+			revoked(assignment)
+		}
+	})
+
+	for topic, partitions := range generation.Assignments {
+		for _, p := range partitions {
+			topic, p := topic, p
+			generation.Start(func(ctx context.Context) {
+				r.readPartition(ctx, topic, p.ID, p.Offset)
+			})
+		}
+	}
+}
+
+// readPartition передаёт сообщения партиции до завершения поколения.
+func (r *groupReader) readPartition(ctx context.Context, topic string, partition int, offset int64) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   r.cfg.Brokers,
+		Dialer:    r.cfg.Dialer,
+		Topic:     topic,
+		Partition: partition,
+		MaxBytes:  10e6, // 10 MB
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(offset); err != nil {
+		r.logger.Error(
+			"failed to set kafka partition offset",
+			slog.String("topic", topic),
+			slog.Int("partition", partition),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			r.logger.Warn(
+				"failed to read kafka partition",
+				slog.String("topic", topic),
+				slog.Int("partition", partition),
+				slog.Any("error", err),
+			)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case r.messages <- msg:
+		}
+	}
+}
+
+func containsPartition(partitions []int, partition int) bool {
+	for _, p := range partitions {
+		if p == partition {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,12 +2,19 @@ package consumer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"runtime/debug"
 	"time"
 
 	"github.com/segmentio/kafka-go"
+
+	"github.com/hickar/crtex_test_assignment/pkg/requestid"
 )
 
+var ErrHandlerPanic = errors.New("panic in message handler")
+
 func LoggerMiddleware(logger *slog.Logger) RouteMiddleware {
 	return func(next RouteHandler) RouteHandler {
 		return func(ctx context.Context, message *kafka.Message) error {
@@ -16,7 +23,8 @@ func LoggerMiddleware(logger *slog.Logger) RouteMiddleware {
 			respTime := time.Since(start).Milliseconds()
 
 			if err == nil {
-				logger.Info(
+				logger.InfoContext(
+					ctx,
 					"kafka message successfully processed",
 					slog.String("topic", message.Topic),
 					slog.String("key", string(message.Key)),
@@ -24,7 +32,8 @@ func LoggerMiddleware(logger *slog.Logger) RouteMiddleware {
 				)
 			}
 			if err != nil {
-				logger.Error(
+				logger.ErrorContext(
+					ctx,
 					"kafka message processing failed",
 					slog.String("topic", message.Topic),
 					slog.String("key", string(message.Key)),
@@ -37,3 +46,48 @@ func LoggerMiddleware(logger *slog.Logger) RouteMiddleware {
 		}
 	}
 }
+
+// RecoveryMiddleware перехватывает панику в обработчике, записывает её в
+// журнал вместе со стеком вызовов и возвращает ошибку ErrHandlerPanic.
+func RecoveryMiddleware(logger *slog.Logger) RouteMiddleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(ctx context.Context, message *kafka.Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.ErrorContext(
+						ctx,
+						"panic recovered in kafka message handler",
+						slog.String("topic", message.Topic),
+						slog.String("key", string(message.Key)),
+						slog.String("panic", fmt.Sprint(r)),
+						slog.String("stack", string(debug.Stack())),
+					)
+					err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+				}
+			}()
+
+			return next(ctx, message)
+		}
+	}
+}
+
+// RequestIDMiddleware берёт идентификатор запроса из заголовка сообщения
+// "x-request-id" или генерирует новый и передаёт его в контекст обработчика.
+func RequestIDMiddleware() RouteMiddleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(ctx context.Context, message *kafka.Message) error {
+			id := ""
+			for _, header := range message.Headers {
+				if header.Key == requestid.MetadataKey && requestid.Valid(string(header.Value)) {
+					id = string(header.Value)
+					break
+				}
+			}
+			if id == "" {
+				id = requestid.New()
+			}
+
+			return next(requestid.WithRequestID(ctx, id), message)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+//go:build unit_test
+
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/pkg/requestid"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	handler := RecoveryMiddleware(slog.New(slog.NewJSONHandler(&buf, nil)))(
+		func(context.Context, *kafka.Message) error {
+			panic("boom")
+		},
+	)
+
+	err := handler(context.Background(), &kafka.Message{Topic: "orders"})
+	assert.ErrorIs(t, err, ErrHandlerPanic)
+	assert.Contains(t, buf.String(), "boom")
+	assert.Contains(t, buf.String(), "middleware_test.go", "stack trace must be logged")
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(&buf, nil)))
+
+	var ids []string
+	router := NewTopicRouter()
+	router.Use(LoggerMiddleware(logger), RequestIDMiddleware())
+	router.Handle("orders", func(ctx context.Context, _ *kafka.Message) error {
+		id, ok := requestid.FromContext(ctx)
+		require.True(t, ok)
+		ids = append(ids, id)
+		return nil
+	})
+
+	require.NoError(t, router.Route(context.Background(), &kafka.Message{
+		Topic:   "orders",
+		Headers: []kafka.Header{{Key: requestid.MetadataKey, Value: []byte("upstream-id")}},
+	}))
+	require.NoError(t, router.Route(context.Background(), &kafka.Message{Topic: "orders"}))
+
+	require.Len(t, ids, 2)
+	assert.Equal(t, "upstream-id", ids[0])
+	assert.True(t, requestid.Valid(ids[1]))
+	assert.Contains(t, buf.String(), `"request_id":"upstream-id"`, "logger middleware must log request id")
+}
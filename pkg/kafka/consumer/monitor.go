@@ -0,0 +1,261 @@
+package consumer
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TopicPartition - партиция топика.
+type TopicPartition struct {
+	Topic     string `json:"topic"`
+	Partition int    `json:"partition"`
+}
+
+// AssignmentSource - источник сообщений, сообщающий назначенные ему партиции
+// и смещения его группы. Реализуется KafkaSource и membroker.Reader.
+type AssignmentSource interface {
+	// Assignment возвращает назначенные источнику партиции по топикам.
+	Assignment(ctx context.Context) (map[string][]int, error)
+	// CommittedOffsets возвращает зафиксированные группой источника смещения
+	// партиций топика. Партиции без зафиксированных смещений не возвращаются.
+	CommittedOffsets(ctx context.Context, topic string) (map[int]int64, error)
+	HighWatermarks(ctx context.Context, topic string) (map[int]int64, error)
+}
+
+// RebalanceSource - источник, сообщающий о назначении ему партиций в новом
+// поколении группы и об их отзыве по завершении поколения. Реализуется
+// KafkaSource и membroker.Reader.
+type RebalanceSource interface {
+	// SetRebalanceHooks задаёт функции, вызываемые с партициями по топикам.
+	// Вызывается до первого получения сообщения. revoked вызывается до
+	// назначения партиций другим участникам группы и при закрытии источника.
+	SetRebalanceHooks(assigned, revoked func(map[string][]int))
+}
+
+// PartitionLag - смещения группы в партиции. Если группа ещё не фиксировала
+// смещение, CommittedOffset равен -1, а отставание не считается.
+type PartitionLag struct {
+	TopicPartition
+	CommittedOffset int64 `json:"committed_offset"`
+	EndOffset       int64 `json:"end_offset"`
+	Lag             int64 `json:"lag"`
+}
+
+func newPartitionLag(tp TopicPartition, committed, high map[int]int64) PartitionLag {
+	lag := PartitionLag{TopicPartition: tp, CommittedOffset: -1, EndOffset: high[tp.Partition]}
+	if offset, ok := committed[tp.Partition]; ok {
+		lag.CommittedOffset = offset
+		lag.Lag = max(lag.EndOffset-offset, 0)
+	}
+
+	return lag
+}
+
+// Metrics - метрики Prometheus назначения партиций и отставания консьюмера.
+type Metrics struct {
+	lag             *prometheus.GaugeVec
+	committed       *prometheus.GaugeVec
+	assigned        *prometheus.GaugeVec
+	partitionEvents *prometheus.CounterVec
+	rebalances      *prometheus.CounterVec
+}
+
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_consumer_lag",
+			Help: "Number of messages behind the last committed offset of the consumer group in an assigned partition.",
+		}, []string{"group", "topic", "partition"}),
+		committed: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_consumer_committed_offset",
+			Help: "Last committed offset of the consumer group in an assigned partition.",
+		}, []string{"group", "topic", "partition"}),
+		assigned: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_consumer_assigned_partitions",
+			Help: "Number of partitions assigned to the consumer.",
+		}, []string{"group", "topic"}),
+		partitionEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_consumer_partition_events_total",
+			Help: "Total number of partitions assigned to or revoked from the consumer.",
+		}, []string{"group", "event"}),
+		rebalances: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_consumer_rebalances_total",
+			Help: "Total number of consumer group generations the consumer has joined.",
+		}, []string{"group"}),
+	}
+
+	for _, collector := range []prometheus.Collector{m.lag, m.committed, m.assigned, m.partitionEvents, m.rebalances} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// watchRebalances подключает хуки назначения и отзыва партиций к источнику.
+func (c *Consumer) watchRebalances(ctx context.Context, source RebalanceSource) {
+	// Партиции отзываются и при остановке консьюмера, когда ctx уже отменён.
+	hctx := context.WithoutCancel(ctx)
+	source.SetRebalanceHooks(
+		func(assignment map[string][]int) { c.partitionsAssigned(hctx, topicPartitions(assignment)) },
+		func(assignment map[string][]int) { c.partitionsRevoked(hctx, topicPartitions(assignment)) },
+	)
+}
+
+func (c *Consumer) partitionsAssigned(ctx context.Context, partitions []TopicPartition) {
+	c.assignMu.Lock()
+	defer c.assignMu.Unlock()
+
+	c.logger.Info("kafka partitions assigned", slog.String("group", c.groupID), slog.Any("partitions", partitions))
+	for _, tp := range partitions {
+		c.assigned[tp] = true
+	}
+
+	if c.metrics != nil {
+		c.metrics.rebalances.WithLabelValues(c.groupID).Inc()
+		c.metrics.partitionEvents.WithLabelValues(c.groupID, "assigned").Add(float64(len(partitions)))
+		c.updateAssignedMetric()
+	}
+	if c.onAssigned != nil && len(partitions) > 0 {
+		c.onAssigned(ctx, partitions)
+	}
+}
+
+func (c *Consumer) partitionsRevoked(ctx context.Context, partitions []TopicPartition) {
+	c.assignMu.Lock()
+	defer c.assignMu.Unlock()
+
+	c.logger.Info("kafka partitions revoked", slog.String("group", c.groupID), slog.Any("partitions", partitions))
+	for _, tp := range partitions {
+		delete(c.assigned, tp)
+	}
+
+	if c.metrics != nil {
+		c.metrics.partitionEvents.WithLabelValues(c.groupID, "revoked").Add(float64(len(partitions)))
+		for _, tp := range partitions {
+			c.metrics.lag.DeleteLabelValues(c.groupID, tp.Topic, strconv.Itoa(tp.Partition))
+			c.metrics.committed.DeleteLabelValues(c.groupID, tp.Topic, strconv.Itoa(tp.Partition))
+		}
+		c.updateAssignedMetric()
+	}
+	if c.onRevoked != nil && len(partitions) > 0 {
+		c.onRevoked(ctx, partitions)
+	}
+}
+
+func (c *Consumer) updateAssignedMetric() {
+	c.metrics.assigned.DeletePartialMatch(prometheus.Labels{"group": c.groupID})
+	for tp := range c.assigned {
+		c.metrics.assigned.WithLabelValues(c.groupID, tp.Topic).Inc()
+	}
+}
+
+// monitor проверяет отставание группы в назначенных партициях каждые statsInterval.
+func (c *Consumer) monitor(ctx context.Context, source AssignmentSource) {
+	ticker := time.NewTicker(c.statsInterval)
+	defer ticker.Stop()
+
+	for {
+		c.reportLag(ctx, source)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Consumer) reportLag(ctx context.Context, source AssignmentSource) {
+	assignment, err := source.Assignment(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.logger.Warn("failed to get kafka partition assignment", slog.Any("error", err))
+		}
+		return
+	}
+
+	partitions := topicPartitions(assignment)
+	if len(partitions) == 0 {
+		return
+	}
+
+	lags, err := assignedPartitionLags(ctx, source, partitions)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.logger.Warn("failed to get kafka consumer lag", slog.Any("error", err))
+		}
+		return
+	}
+
+	var total int64
+	for _, lag := range lags {
+		total += lag.Lag
+		if c.metrics != nil && lag.CommittedOffset >= 0 {
+			labels := []string{c.groupID, lag.Topic, strconv.Itoa(lag.Partition)}
+			c.metrics.lag.WithLabelValues(labels...).Set(float64(lag.Lag))
+			c.metrics.committed.WithLabelValues(labels...).Set(float64(lag.CommittedOffset))
+		}
+	}
+
+	c.logger.Info(
+		"kafka consumer lag",
+		slog.String("group", c.groupID),
+		slog.Int64("lag", total),
+		slog.Any("partitions", lags),
+	)
+}
+
+func assignedPartitionLags(ctx context.Context, source AssignmentSource, partitions []TopicPartition) ([]PartitionLag, error) {
+	type topicOffsets struct {
+		committed map[int]int64
+		high      map[int]int64
+	}
+	offsets := make(map[string]topicOffsets)
+
+	lags := make([]PartitionLag, 0, len(partitions))
+	for _, tp := range partitions {
+		o, ok := offsets[tp.Topic]
+		if !ok {
+			var err error
+			if o.committed, err = source.CommittedOffsets(ctx, tp.Topic); err != nil {
+				return nil, err
+			}
+			if o.high, err = source.HighWatermarks(ctx, tp.Topic); err != nil {
+				return nil, err
+			}
+			offsets[tp.Topic] = o
+		}
+
+		lags = append(lags, newPartitionLag(tp, o.committed, o.high))
+	}
+
+	return lags, nil
+}
+
+func topicPartitions(assignment map[string][]int) []TopicPartition {
+	var partitions []TopicPartition
+	for topic, ps := range assignment {
+		for _, p := range ps {
+			partitions = append(partitions, TopicPartition{Topic: topic, Partition: p})
+		}
+	}
+	sortPartitions(partitions)
+
+	return partitions
+}
+
+func sortPartitions(partitions []TopicPartition) {
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].Topic != partitions[j].Topic {
+			return partitions[i].Topic < partitions[j].Topic
+		}
+		return partitions[i].Partition < partitions[j].Partition
+	})
+}
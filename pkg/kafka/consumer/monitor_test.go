@@ -0,0 +1,135 @@
+//go:build unit_test
+
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/pkg/kafka/membroker"
+)
+
+var _ AssignmentSource = (*membroker.Reader)(nil)
+var _ GroupAdmin = (*membroker.Broker)(nil)
+
+func TestConsumerPartitionHooks(t *testing.T) {
+	broker := newTestBroker(t, 2, 4)
+
+	type partitionEvent struct {
+		event      string
+		partitions []TopicPartition
+	}
+	var (
+		mu     sync.Mutex
+		events []partitionEvent
+	)
+	snapshot := func() []partitionEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]partitionEvent(nil), events...)
+	}
+	record := func(event string) func(context.Context, []TopicPartition) {
+		return func(_ context.Context, partitions []TopicPartition) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, partitionEvent{event, partitions})
+		}
+	}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(context.Context, *kafka.Message) error { return nil })
+
+	stop := runConsumer(t, broker, router, Configuration{
+		GroupID:              "test",
+		StatsInterval:        10 * time.Millisecond,
+		Metrics:              metrics,
+		OnPartitionsAssigned: record("assigned"),
+		OnPartitionsRevoked:  record("revoked"),
+	})
+
+	both := []TopicPartition{{testTopic, 0}, {testTopic, 1}}
+	require.Eventually(t, func() bool {
+		return len(snapshot()) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []partitionEvent{{"assigned", both}}, snapshot())
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.assigned.WithLabelValues("test", testTopic)))
+
+	// Все сообщения обработаны, и отставание в партиции 0 становится нулевым.
+	p0 := int64(len(broker.Messages(testTopic, 0)))
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.committed.WithLabelValues("test", testTopic, "0")) == float64(p0)
+	}, time.Second, 10*time.Millisecond)
+	assert.Zero(t, testutil.ToFloat64(metrics.lag.WithLabelValues("test", testTopic, "0")))
+
+	// Второй участник группы забирает одну из партиций. Хуки вызываются
+	// при ребалансировке, а не при следующей проверке отставания.
+	second := broker.NewReader(membroker.ReaderConfig{GroupID: "test", Topic: testTopic})
+	assert.Equal(t, []partitionEvent{
+		{"assigned", both},
+		{"revoked", both},
+		{"assigned", []TopicPartition{{testTopic, 0}}},
+	}, snapshot())
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.assigned.WithLabelValues("test", testTopic)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.rebalances.WithLabelValues("test")))
+
+	assert.ErrorIs(t, stop(), context.Canceled)
+	defer second.Close()
+
+	assert.Equal(t, partitionEvent{"revoked", []TopicPartition{{testTopic, 0}}}, snapshot()[3],
+		"partitions must be revoked when the consumer stops")
+}
+
+func TestGroupStateHandler(t *testing.T) {
+	broker := newTestBroker(t, 2, 6)
+	require.NoError(t, broker.CommitOffsets(context.Background(), "test", testTopic, map[int]int64{0: 1}))
+
+	reader := broker.NewReader(membroker.ReaderConfig{ClientID: "account", GroupID: "test", Topic: testTopic})
+	defer reader.Close()
+
+	handler := GroupStateHandler(broker, Configuration{GroupID: "test", Topic: testTopic})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/kafka/group", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var state GroupState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+
+	assert.Equal(t, "test", state.GroupID)
+	assert.Equal(t, "Stable", state.State)
+	require.Len(t, state.Members, 1)
+	member := state.Members[0]
+	assert.Equal(t, "account", member.ClientID)
+	assert.Equal(t, []TopicPartition{{testTopic, 0}, {testTopic, 1}}, member.Partitions)
+
+	end0 := int64(len(broker.Messages(testTopic, 0)))
+	end1 := int64(len(broker.Messages(testTopic, 1)))
+	assert.Equal(t, []PartitionState{
+		{
+			PartitionLag: PartitionLag{TopicPartition{testTopic, 0}, 1, end0, end0 - 1},
+			MemberID:     member.MemberID,
+		},
+		{
+			PartitionLag: PartitionLag{TopicPartition{testTopic, 1}, -1, end1, 0},
+			MemberID:     member.MemberID,
+		},
+	}, state.Partitions)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/kafka/group", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
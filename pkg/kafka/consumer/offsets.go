@@ -0,0 +1,93 @@
+package consumer
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+type partitionOffsets struct {
+	pending []int64
+	done    map[int64]struct{}
+}
+
+// offsetTracker отслеживает обработанные сообщения по партициям. Так как
+// сообщения одной партиции обрабатываются параллельно, зафиксировать можно
+// только непрерывный префикс обработанных смещений.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[partitionKey]*partitionOffsets
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[partitionKey]*partitionOffsets),
+	}
+}
+
+func (t *offsetTracker) track(message kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := partitionKey{topic: message.Topic, partition: message.Partition}
+	p, ok := t.partitions[key]
+
+	// Смещение меньше уже полученного означает, что партиция была
+	// перечитана после ребалансировки, и прежнее состояние неактуально.
+	if !ok || (len(p.pending) > 0 && message.Offset <= p.pending[len(p.pending)-1]) {
+		p = &partitionOffsets{done: make(map[int64]struct{})}
+		t.partitions[key] = p
+	}
+
+	p.pending = append(p.pending, message.Offset)
+}
+
+// complete отмечает сообщение обработанным и возвращает сообщение, смещение
+// которого можно зафиксировать, если непрерывный префикс продвинулся.
+func (t *offsetTracker) complete(message kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := partitionKey{topic: message.Topic, partition: message.Partition}
+	p, ok := t.partitions[key]
+	if !ok {
+		return kafka.Message{}, false
+	}
+
+	// Сообщение, полученное до сброса состояния партиции, уже не ожидается.
+	// Его смещение не запоминается, иначе оно осталось бы в done навсегда.
+	i := sort.Search(len(p.pending), func(i int) bool { return p.pending[i] >= message.Offset })
+	if i == len(p.pending) || p.pending[i] != message.Offset {
+		return kafka.Message{}, false
+	}
+
+	p.done[message.Offset] = struct{}{}
+
+	committed := int64(-1)
+	for len(p.pending) > 0 {
+		head := p.pending[0]
+		if _, done := p.done[head]; !done {
+			break
+		}
+
+		delete(p.done, head)
+		p.pending = p.pending[1:]
+		committed = head
+	}
+
+	if committed < 0 {
+		return kafka.Message{}, false
+	}
+
+	return kafka.Message{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    committed,
+	}, true
+}
@@ -0,0 +1,237 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrReplayIncomplete возвращается, если до истечения IdleTimeout были
+// прочитаны не все сообщения диапазона.
+var ErrReplayIncomplete = errors.New("replay stopped before reaching the end of range")
+
+// ReplaySource - источник сообщений для Replayer. HighWatermarks возвращает
+// для каждой партиции смещение, следующее за последним сообщением, и задаёт
+// конец повторной обработки.
+type ReplaySource interface {
+	MessageSource
+	HighWatermarks(ctx context.Context, topic string) (map[int]int64, error)
+}
+
+type ReplayOutcome string
+
+const (
+	// ReplayProcessed - обработчик маршрута завершился без ошибки.
+	ReplayProcessed ReplayOutcome = "processed"
+	// ReplayFailed - обработчик маршрута вернул ошибку.
+	ReplayFailed ReplayOutcome = "failed"
+	// ReplayNoRoute - для сообщения нет маршрута.
+	ReplayNoRoute ReplayOutcome = "no_route"
+	// ReplayMatched - в режиме DryRun для сообщения найден маршрут, обработчик не вызывался.
+	ReplayMatched ReplayOutcome = "matched"
+)
+
+// ReplayResult - результат повторной обработки одного сообщения.
+type ReplayResult struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       string
+	Time      time.Time
+	Outcome   ReplayOutcome
+	Err       error
+}
+
+// ReplaySummary - число сообщений по результатам обработки.
+type ReplaySummary map[ReplayOutcome]int
+
+// ReplayConfiguration задаёт диапазон сообщений топика для повторной обработки.
+// Нулевые значения границ означают отсутствие ограничения. Конец диапазона
+// не дальше последних сообщений партиций на момент запуска.
+type ReplayConfiguration struct {
+	Topic string
+	// Partitions - обрабатываемые партиции, по умолчанию все.
+	Partitions []int
+	// StartOffset - первое смещение диапазона в каждой партиции.
+	StartOffset int64
+	// EndOffset - смещение, следующее за последним в диапазоне.
+	EndOffset int64
+	StartTime time.Time
+	EndTime   time.Time
+	// DryRun только определяет маршрут сообщения, не вызывая обработчик
+	// и не фиксируя смещения.
+	DryRun         bool
+	HandlerTimeout time.Duration
+	// IdleTimeout - сколько ждать следующего сообщения до остановки.
+	IdleTimeout time.Duration
+	// OnResult вызывается для каждого сообщения из диапазона.
+	OnResult func(ReplayResult)
+	Logger   *slog.Logger
+}
+
+// Replayer повторно пропускает сообщения топика через TopicRouter. Сообщения
+// обрабатываются последовательно. Смещения фиксируются в группе источника,
+// поэтому она должна отличаться от группы рабочего консьюмера.
+type Replayer struct {
+	cfg    ReplayConfiguration
+	source ReplaySource
+	router *TopicRouter
+}
+
+func NewReplayer(cfg ReplayConfiguration, source ReplaySource, router *TopicRouter) *Replayer {
+	if cfg.HandlerTimeout <= 0 {
+		cfg.HandlerTimeout = time.Minute
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = 10 * time.Second
+	}
+	if cfg.OnResult == nil {
+		cfg.OnResult = func(ReplayResult) {}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &Replayer{cfg: cfg, source: source, router: router}
+}
+
+// Run обрабатывает сообщения диапазона и закрывает источник.
+func (r *Replayer) Run(ctx context.Context) (ReplaySummary, error) {
+	summary := ReplaySummary{}
+
+	ends, err := r.partitionEnds(ctx)
+	if err != nil {
+		return summary, errors.Join(err, r.source.Close())
+	}
+
+	for len(ends) > 0 {
+		fctx, cancel := context.WithTimeout(ctx, r.cfg.IdleTimeout)
+		msg, err := r.source.FetchMessage(fctx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("%w: partitions %v", ErrReplayIncomplete, sortedKeys(ends))
+			}
+			return summary, errors.Join(err, r.source.Close())
+		}
+
+		end, ok := ends[msg.Partition]
+		if msg.Topic != r.cfg.Topic || !ok || msg.Offset >= end {
+			continue
+		}
+
+		if !r.cfg.EndTime.IsZero() && msg.Time.After(r.cfg.EndTime) {
+			delete(ends, msg.Partition)
+			continue
+		}
+
+		if r.inRange(msg) {
+			result := r.process(ctx, &msg)
+			summary[result.Outcome]++
+			r.cfg.OnResult(result)
+		}
+
+		if !r.cfg.DryRun {
+			r.commit(ctx, msg)
+		}
+
+		if msg.Offset+1 >= end {
+			delete(ends, msg.Partition)
+		}
+	}
+
+	return summary, r.source.Close()
+}
+
+// partitionEnds возвращает для партиций диапазона смещения, на которых
+// обработка завершается. Партиции без сообщений в диапазоне не возвращаются.
+func (r *Replayer) partitionEnds(ctx context.Context) (map[int]int64, error) {
+	watermarks, err := r.source.HighWatermarks(ctx, r.cfg.Topic)
+	if err != nil {
+		return nil, err
+	}
+
+	ends := make(map[int]int64, len(watermarks))
+	for partition, end := range watermarks {
+		if len(r.cfg.Partitions) > 0 && !slices.Contains(r.cfg.Partitions, partition) {
+			continue
+		}
+		if r.cfg.EndOffset > 0 && r.cfg.EndOffset < end {
+			end = r.cfg.EndOffset
+		}
+		if end > r.cfg.StartOffset {
+			ends[partition] = end
+		}
+	}
+
+	return ends, nil
+}
+
+func (r *Replayer) inRange(msg kafka.Message) bool {
+	if msg.Offset < r.cfg.StartOffset {
+		return false
+	}
+
+	return r.cfg.StartTime.IsZero() || !msg.Time.Before(r.cfg.StartTime)
+}
+
+func (r *Replayer) process(ctx context.Context, msg *kafka.Message) ReplayResult {
+	result := ReplayResult{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       string(msg.Key),
+		Time:      msg.Time,
+	}
+
+	if r.cfg.DryRun {
+		result.Outcome = ReplayMatched
+		if !r.router.Matches(msg) {
+			result.Outcome = ReplayNoRoute
+		}
+		return result
+	}
+
+	hctx, cancel := context.WithTimeout(ctx, r.cfg.HandlerTimeout)
+	defer cancel()
+
+	result.Err = r.router.Route(hctx, msg)
+	switch {
+	case result.Err == nil:
+		result.Outcome = ReplayProcessed
+	case errors.Is(result.Err, ErrNoRoute):
+		result.Outcome = ReplayNoRoute
+	default:
+		result.Outcome = ReplayFailed
+	}
+
+	return result
+}
+
+func (r *Replayer) commit(ctx context.Context, msg kafka.Message) {
+	if err := r.source.CommitMessages(ctx, msg); err != nil {
+		r.cfg.Logger.Warn(
+			"failed to commit replayed message offset",
+			slog.String("topic", msg.Topic),
+			slog.Int("partition", msg.Partition),
+			slog.Int64("offset", msg.Offset),
+			slog.Any("error", err),
+		)
+	}
+}
+
+func sortedKeys(m map[int]int64) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
@@ -0,0 +1,191 @@
+//go:build unit_test
+
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/pkg/kafka/membroker"
+)
+
+func newReplaySource(broker *membroker.Broker, groupID string) *membroker.Reader {
+	return broker.NewReader(membroker.ReaderConfig{
+		GroupID:     groupID,
+		Topic:       testTopic,
+		StartOffset: kafka.FirstOffset,
+	})
+}
+
+func TestReplayer(t *testing.T) {
+	broker := newTestBroker(t, 2, 10)
+
+	var handled atomic.Int64
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(_ context.Context, msg *kafka.Message) error {
+		handled.Add(1)
+		if msg.Key[0] == 3 {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+
+	var results []ReplayResult
+	summary, err := NewReplayer(ReplayConfiguration{
+		Topic:    testTopic,
+		OnResult: func(r ReplayResult) { results = append(results, r) },
+	}, newReplaySource(broker, "replay"), router).Run(context.Background())
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 10, handled.Load())
+	assert.Len(t, results, 10)
+	assert.Equal(t, ReplaySummary{ReplayProcessed: 9, ReplayFailed: 1}, summary)
+
+	for p := 0; p < 2; p++ {
+		size := int64(len(broker.Messages(testTopic, p)))
+		assert.Equal(t, size, broker.CommittedOffset("replay", testTopic, p), "replay group must be committed")
+		assert.EqualValues(t, -1, broker.CommittedOffset("test", testTopic, p), "live group must be untouched")
+	}
+}
+
+func TestReplayerDryRun(t *testing.T) {
+	broker := newTestBroker(t, 1, 5)
+
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(context.Context, *kafka.Message) error {
+		t.Error("handler must not be called in dry run")
+		return nil
+	})
+
+	summary, err := NewReplayer(ReplayConfiguration{Topic: testTopic, DryRun: true},
+		newReplaySource(broker, "replay"), router).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ReplaySummary{ReplayMatched: 5}, summary)
+	assert.EqualValues(t, -1, broker.CommittedOffset("replay", testTopic, 0), "dry run must not commit")
+
+	summary, err = NewReplayer(ReplayConfiguration{Topic: testTopic, DryRun: true},
+		newReplaySource(broker, "replay-2"), NewTopicRouter()).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ReplaySummary{ReplayNoRoute: 5}, summary)
+}
+
+func TestReplayerRange(t *testing.T) {
+	broker := membroker.NewBroker()
+	broker.CreateTopic(testTopic, 1)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, broker.Produce(context.Background(), kafka.Message{
+			Topic: testTopic,
+			Time:  start.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(context.Context, *kafka.Message) error { return nil })
+
+	tests := []struct {
+		name    string
+		cfg     ReplayConfiguration
+		offsets []int64
+	}{
+		{
+			name:    "Offsets",
+			cfg:     ReplayConfiguration{StartOffset: 2, EndOffset: 5},
+			offsets: []int64{2, 3, 4},
+		},
+		{
+			name:    "Time",
+			cfg:     ReplayConfiguration{StartTime: start.Add(7 * time.Minute), EndTime: start.Add(8 * time.Minute)},
+			offsets: []int64{7, 8},
+		},
+		{
+			name: "OtherPartition",
+			cfg:  ReplayConfiguration{Partitions: []int{1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var offsets []int64
+			tt.cfg.Topic = testTopic
+			tt.cfg.OnResult = func(r ReplayResult) { offsets = append(offsets, r.Offset) }
+
+			_, err := NewReplayer(tt.cfg, newReplaySource(broker, "replay-"+tt.name), router).Run(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.offsets, offsets)
+		})
+	}
+}
+
+func TestReplayerIncomplete(t *testing.T) {
+	broker := newTestBroker(t, 1, 3)
+	// Группа уже прочитала топик, поэтому сообщения до конца диапазона не придут.
+	source := newReplaySource(broker, "replay")
+	for _, msg := range broker.Messages(testTopic, 0) {
+		require.NoError(t, source.CommitMessages(context.Background(), msg))
+	}
+	require.NoError(t, source.Close())
+
+	_, err := NewReplayer(ReplayConfiguration{Topic: testTopic, IdleTimeout: 50 * time.Millisecond},
+		newReplaySource(broker, "replay"), NewTopicRouter()).Run(context.Background())
+	assert.ErrorIs(t, err, ErrReplayIncomplete)
+}
+
+func TestReplayOutput(t *testing.T) {
+	broker := newTestBroker(t, 1, 2)
+
+	router := NewTopicRouter()
+	router.Handle(testTopic, func(_ context.Context, msg *kafka.Message) error {
+		if msg.Offset == 1 {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+
+	var out bytes.Buffer
+	err := Replay(context.Background(), ReplayConfiguration{Topic: testTopic},
+		newReplaySource(broker, "replay"), router, &out)
+	assert.EqualError(t, err, "1 messages failed")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var result map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &result))
+	assert.Equal(t, "failed", result["outcome"])
+	assert.Equal(t, "handler failed", result["error"])
+	assert.EqualValues(t, 1, result["offset"])
+	assert.JSONEq(t, `{"summary":{"processed":1,"failed":1},"dry_run":false}`, lines[2])
+}
+
+func TestParseReplayFlags(t *testing.T) {
+	cfg := Configuration{GroupID: "account", Topic: testTopic}
+
+	opts, err := ParseReplayFlags([]string{
+		"-partitions", "0, 2", "-from-offset", "10", "-from-time", "2024-01-01T00:00:00Z", "-dry-run",
+	}, cfg, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, testTopic, opts.Replay.Topic)
+	assert.Equal(t, []int{0, 2}, opts.Replay.Partitions)
+	assert.EqualValues(t, 10, opts.Replay.StartOffset)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), opts.Replay.StartTime)
+	assert.True(t, opts.Replay.DryRun)
+	assert.True(t, strings.HasPrefix(opts.GroupID, "account-replay-"))
+
+	_, err = ParseReplayFlags([]string{"-group", "account"}, cfg, &bytes.Buffer{})
+	assert.Error(t, err, "live consumer group must be rejected")
+
+	_, err = ParseReplayFlags([]string{"-partitions", "a"}, cfg, &bytes.Buffer{})
+	assert.Error(t, err)
+}
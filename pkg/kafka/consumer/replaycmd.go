@@ -0,0 +1,157 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ReplayCommand - имя подкоманды сервисов для повторной обработки сообщений.
+const ReplayCommand = "replay"
+
+// ReplayOptions - параметры подкоманды replay.
+type ReplayOptions struct {
+	Replay ReplayConfiguration
+	// GroupID - группа консьюмеров для повторной обработки.
+	GroupID string
+}
+
+// ParseReplayFlags разбирает аргументы подкоманды replay. Значения по умолчанию
+// берутся из конфигурации рабочего консьюмера cfg: топик - cfg.Topic, группа -
+// новая группа с суффиксом "-replay-<время запуска>". Группа cfg.GroupID
+// не допускается, чтобы не сдвинуть смещения рабочего консьюмера.
+func ParseReplayFlags(args []string, cfg Configuration, output io.Writer) (ReplayOptions, error) {
+	fs := flag.NewFlagSet(ReplayCommand, flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	var (
+		opts       ReplayOptions
+		partitions string
+		startTime  string
+		endTime    string
+	)
+	fs.StringVar(&opts.Replay.Topic, "topic", cfg.Topic, "Topic to replay")
+	fs.StringVar(&partitions, "partitions", "", "Comma-separated partitions to replay. Defaults to all partitions")
+	fs.Int64Var(&opts.Replay.StartOffset, "from-offset", 0, "First offset to replay in each partition")
+	fs.Int64Var(&opts.Replay.EndOffset, "to-offset", 0, "Offset after the last one to replay. Defaults to the end of partition")
+	fs.StringVar(&startTime, "from-time", "", "Replay messages written at or after this RFC 3339 time")
+	fs.StringVar(&endTime, "to-time", "", "Replay messages written at or before this RFC 3339 time")
+	fs.BoolVar(&opts.Replay.DryRun, "dry-run", false, "Only report matched routes without calling handlers or committing offsets")
+	fs.DurationVar(&opts.Replay.IdleTimeout, "idle-timeout", 10*time.Second, "Stop if no message arrives for this long")
+	fs.StringVar(&opts.GroupID, "group", "", "Consumer group for replay. Defaults to a new group derived from the service group")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if opts.Replay.Topic == "" {
+		return opts, errors.New("replay topic is not set")
+	}
+
+	var err error
+	if opts.Replay.Partitions, err = parsePartitions(partitions); err != nil {
+		return opts, err
+	}
+	if opts.Replay.StartTime, err = parseReplayTime(startTime); err != nil {
+		return opts, err
+	}
+	if opts.Replay.EndTime, err = parseReplayTime(endTime); err != nil {
+		return opts, err
+	}
+
+	if opts.GroupID == "" {
+		prefix := cfg.GroupID
+		if prefix == "" {
+			prefix = opts.Replay.Topic
+		}
+		opts.GroupID = fmt.Sprintf("%s-replay-%d", prefix, time.Now().Unix())
+	}
+	if opts.GroupID == cfg.GroupID {
+		return opts, fmt.Errorf("replay group must differ from the consumer group %q", cfg.GroupID)
+	}
+
+	return opts, nil
+}
+
+func parseReplayTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %w", value, err)
+	}
+
+	return t, nil
+}
+
+// RunReplayCommand выполняет подкоманду replay: читает топик отдельной группой
+// консьюмеров, пропускает сообщения через router и пишет в output результат
+// обработки каждого сообщения и итог построчно в JSON. Возвращает ошибку,
+// если обработка хотя бы одного сообщения завершилась ошибкой.
+func RunReplayCommand(ctx context.Context, args []string, cfg Configuration, router *TopicRouter, output io.Writer) error {
+	opts, err := ParseReplayFlags(args, cfg, output)
+	if err != nil {
+		return err
+	}
+
+	cfg.GroupID = opts.GroupID
+	cfg.GroupTopics = nil
+	cfg.Topic = opts.Replay.Topic
+
+	source, err := NewKafkaReplaySource(cfg)
+	if err != nil {
+		return err
+	}
+
+	return Replay(ctx, opts.Replay, source, router, output)
+}
+
+// Replay выполняет повторную обработку и пишет результаты в output, как RunReplayCommand.
+func Replay(ctx context.Context, cfg ReplayConfiguration, source ReplaySource, router *TopicRouter, output io.Writer) error {
+	encoder := json.NewEncoder(output)
+	cfg.OnResult = func(result ReplayResult) {
+		line := replayResultLine{
+			Topic:     result.Topic,
+			Partition: result.Partition,
+			Offset:    result.Offset,
+			Key:       result.Key,
+			Time:      result.Time,
+			Outcome:   result.Outcome,
+		}
+		if result.Err != nil {
+			line.Error = result.Err.Error()
+		}
+		_ = encoder.Encode(line)
+	}
+
+	summary, err := NewReplayer(cfg, source, router).Run(ctx)
+	_ = encoder.Encode(struct {
+		Summary ReplaySummary `json:"summary"`
+		DryRun  bool          `json:"dry_run"`
+	}{summary, cfg.DryRun})
+	if err != nil {
+		return err
+	}
+
+	if failed := summary[ReplayFailed]; failed > 0 {
+		return fmt.Errorf("%d messages failed", failed)
+	}
+
+	return nil
+}
+
+type replayResultLine struct {
+	Topic     string        `json:"topic"`
+	Partition int           `json:"partition"`
+	Offset    int64         `json:"offset"`
+	Key       string        `json:"key,omitempty"`
+	Time      time.Time     `json:"time"`
+	Outcome   ReplayOutcome `json:"outcome"`
+	Error     string        `json:"error,omitempty"`
+}
@@ -0,0 +1,178 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ResetOffsetsCommand - имя подкоманды сервисов для сброса смещений группы.
+const ResetOffsetsCommand = "reset-offsets"
+
+// ResetOffsetsOptions - параметры подкоманды reset-offsets.
+type ResetOffsetsOptions struct {
+	GroupID string
+	Topic   string
+	// Offsets - явно заданные смещения партиций, kafka.FirstOffset или kafka.LastOffset.
+	Offsets    map[int]int64
+	Partitions []int
+	Start      StartOffset
+	StartTime  string
+}
+
+// ParseResetOffsetsFlags разбирает аргументы подкоманды reset-offsets. Группа
+// и топик по умолчанию берутся из конфигурации консьюмера cfg.
+func ParseResetOffsetsFlags(args []string, cfg Configuration, output io.Writer) (ResetOffsetsOptions, Configuration, error) {
+	fs := flag.NewFlagSet(ResetOffsetsCommand, flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	var (
+		opts       ResetOffsetsOptions
+		offsets    string
+		partitions string
+		start      string
+	)
+	fs.StringVar(&opts.GroupID, "group", cfg.GroupID, "Consumer group to reset")
+	fs.StringVar(&opts.Topic, "topic", cfg.Topic, "Topic to reset offsets in")
+	fs.StringVar(&start, "to", "", "Reset position: earliest, latest or timestamp")
+	fs.StringVar(&opts.StartTime, "time", "", "RFC 3339 time for -to timestamp")
+	fs.StringVar(&partitions, "partitions", "", "Comma-separated partitions to reset with -to. Defaults to all partitions")
+	fs.StringVar(&offsets, "offsets", "", "Explicit offsets as partition=offset pairs, offset may be earliest or latest")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, cfg, err
+	}
+
+	if opts.Topic == "" {
+		return opts, cfg, errors.New("topic is not set")
+	}
+	if (offsets == "") == (start == "") {
+		return opts, cfg, errors.New("exactly one of -offsets and -to must be set")
+	}
+
+	var err error
+	if offsets != "" {
+		if partitions != "" || opts.StartTime != "" {
+			return opts, cfg, errors.New("-partitions and -time can only be used with -to")
+		}
+		if opts.Offsets, err = parsePartitionOffsets(offsets); err != nil {
+			return opts, cfg, err
+		}
+	}
+
+	if start != "" {
+		opts.Start = StartOffset(start)
+		if opts.Partitions, err = parsePartitions(partitions); err != nil {
+			return opts, cfg, err
+		}
+	}
+
+	// Проверка позиции выполняется правилами Configuration.
+	cfg.GroupID = opts.GroupID
+	cfg.Topic = opts.Topic
+	cfg.GroupTopics = nil
+	cfg.StartOffset = opts.Start
+	if cfg.StartTime, err = parseReplayTime(opts.StartTime); err != nil {
+		return opts, cfg, err
+	}
+	if err = cfg.Validate(); err != nil {
+		return opts, cfg, err
+	}
+
+	return opts, cfg, nil
+}
+
+func parsePartitions(value string) ([]int, error) {
+	var partitions []int
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		partition, err := strconv.Atoi(p)
+		if err != nil || partition < 0 {
+			return nil, fmt.Errorf("invalid partition %q", p)
+		}
+		partitions = append(partitions, partition)
+	}
+
+	return partitions, nil
+}
+
+func parsePartitionOffsets(value string) (map[int]int64, error) {
+	offsets := make(map[int]int64)
+	for _, pair := range strings.Split(value, ",") {
+		p, o, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid partition offset %q", pair)
+		}
+
+		partitions, err := parsePartitions(p)
+		if err != nil || len(partitions) != 1 {
+			return nil, fmt.Errorf("invalid partition offset %q", pair)
+		}
+
+		var offset int64
+		switch o = strings.TrimSpace(o); o {
+		case string(StartOffsetEarliest):
+			offset = kafka.FirstOffset
+		case string(StartOffsetLatest):
+			offset = kafka.LastOffset
+		default:
+			if offset, err = strconv.ParseInt(o, 10, 64); err != nil || offset < 0 {
+				return nil, fmt.Errorf("invalid partition offset %q", pair)
+			}
+		}
+		offsets[partitions[0]] = offset
+	}
+
+	return offsets, nil
+}
+
+// RunResetOffsetsCommand выполняет подкоманду reset-offsets и выводит
+// установленные смещения в output в JSON. Консьюмеры группы должны быть остановлены.
+func RunResetOffsetsCommand(ctx context.Context, args []string, cfg Configuration, output io.Writer) error {
+	opts, cfg, err := ParseResetOffsetsFlags(args, cfg, output)
+	if err != nil {
+		return err
+	}
+
+	return ResetOffsets(ctx, NewAdmin(cfg), opts, cfg, output)
+}
+
+// ResetOffsets сбрасывает смещения группы и выводит результат, как RunResetOffsetsCommand.
+func ResetOffsets(ctx context.Context, m OffsetManager, opts ResetOffsetsOptions, cfg Configuration, output io.Writer) error {
+	offsets := opts.Offsets
+	if len(opts.Partitions) > 0 {
+		resolved, err := ResolveOffsets(ctx, m, opts.Topic, cfg.StartOffset, cfg.StartTime)
+		if err != nil {
+			return err
+		}
+
+		offsets = make(map[int]int64, len(opts.Partitions))
+		for _, partition := range opts.Partitions {
+			offset, ok := resolved[partition]
+			if !ok {
+				return fmt.Errorf("partition %d of topic %q not found", partition, opts.Topic)
+			}
+			offsets[partition] = offset
+		}
+	}
+
+	offsets, err := ResetGroupOffsets(ctx, m, opts.GroupID, opts.Topic, offsets, cfg.StartOffset, cfg.StartTime)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(output).Encode(struct {
+		Group   string        `json:"group"`
+		Topic   string        `json:"topic"`
+		Offsets map[int]int64 `json:"offsets"`
+	}{opts.GroupID, opts.Topic, offsets})
+}
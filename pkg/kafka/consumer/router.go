@@ -1,36 +1,283 @@
 package consumer
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/segmentio/kafka-go"
 )
 
+var ErrNoRoute = errors.New("no route matched incoming message")
+
+// MessageMatcher сообщает, должен ли маршрут обработать сообщение.
+type MessageMatcher func(*kafka.Message) bool
+
+type route struct {
+	match   MessageMatcher
+	handler RouteHandler
+
+	// batch и middlewares заданы у маршрутов, зарегистрированных через HandleBatch.
+	batch       BatchRouteHandler
+	middlewares []RouteMiddleware
+
+	// chain и batchChain - обработчики маршрута вместе с middleware роутера.
+	// Они собираются при регистрации маршрута и в Use, а не на каждое сообщение.
+	chain      RouteHandler
+	batchChain RouteHandler
+}
+
+func (rt *route) build(routerMiddlewares []RouteMiddleware) {
+	rt.chain = wrapHandler(rt.handler, routerMiddlewares)
+	if rt.batch != nil {
+		rt.batchChain = wrapHandler(wrapHandler(batchResultHandler, rt.middlewares), routerMiddlewares)
+	}
+}
+
+// batchResultKey - ключ контекста, в котором RouteBatch передаёт
+// результат обработки сообщения пачки в batchChain маршрута.
+type batchResultKey struct{}
+
+type batchResult struct {
+	err error
+}
+
+func batchResultHandler(ctx context.Context, _ *kafka.Message) error {
+	result, _ := ctx.Value(batchResultKey{}).(batchResult)
+	return result.err
+}
+
 type TopicRouter struct {
-	routes map[string]RouteHandler
+	routes         []route
+	unmatched      RouteHandler
+	unmatchedChain RouteHandler
+	middlewares    []RouteMiddleware
 }
 
 func NewTopicRouter() *TopicRouter {
-	return &TopicRouter{
-		routes: make(map[string]RouteHandler),
+	return &TopicRouter{}
+}
+
+// Use регистрирует middleware, применяемые ко всем маршрутам роутера,
+// включая обработчик несопоставленных сообщений.
+func (r *TopicRouter) Use(middlewareFns ...RouteMiddleware) {
+	r.middlewares = append(r.middlewares, middlewareFns...)
+
+	for i := range r.routes {
+		r.routes[i].build(r.middlewares)
+	}
+	if r.unmatched != nil {
+		r.unmatchedChain = wrapHandler(r.unmatched, r.middlewares)
 	}
 }
 
 func (r *TopicRouter) Handle(topic string, handler RouteHandler, middlewareFns ...RouteMiddleware) {
+	r.HandleMatch(MatchTopic(topic), handler, middlewareFns...)
+}
+
+func (r *TopicRouter) HandlePattern(pattern *regexp.Regexp, handler RouteHandler, middlewareFns ...RouteMiddleware) {
+	r.HandleMatch(MatchTopicPattern(pattern), handler, middlewareFns...)
+}
+
+func (r *TopicRouter) HandleHeader(key, value string, handler RouteHandler, middlewareFns ...RouteMiddleware) {
+	r.HandleMatch(MatchHeader(key, value), handler, middlewareFns...)
+}
+
+func (r *TopicRouter) HandlePayload(path, value string, handler RouteHandler, middlewareFns ...RouteMiddleware) {
+	r.HandleMatch(MatchPayloadField(path, value), handler, middlewareFns...)
+}
+
+// HandleMatch регистрирует обработчик для произвольного условия. Маршруты
+// проверяются в порядке регистрации, сообщение обрабатывается первым
+// подходящим маршрутом.
+func (r *TopicRouter) HandleMatch(matcher MessageMatcher, handler RouteHandler, middlewareFns ...RouteMiddleware) {
+	r.addRoute(route{
+		match:   matcher,
+		handler: wrapHandler(handler, middlewareFns),
+	})
+}
+
+// HandleBatch регистрирует обработчик пачек сообщений топика. RouteBatch
+// передаёт ему подходящие сообщения одной пачкой, а Route - по одному.
+// Middleware применяются к каждому сообщению пачки после её обработки
+// и получают результат обработки этого сообщения.
+func (r *TopicRouter) HandleBatch(topic string, handler BatchRouteHandler, middlewareFns ...RouteMiddleware) {
+	single := func(ctx context.Context, message *kafka.Message) error {
+		return callBatchHandler(ctx, handler, []*kafka.Message{message})[0]
+	}
+
+	r.addRoute(route{
+		match:       MatchTopic(topic),
+		handler:     wrapHandler(single, middlewareFns),
+		batch:       handler,
+		middlewares: middlewareFns,
+	})
+}
+
+func (r *TopicRouter) addRoute(rt route) {
+	rt.build(r.middlewares)
+	r.routes = append(r.routes, rt)
+}
+
+// HandleUnmatched регистрирует обработчик сообщений, не подошедших ни под один маршрут.
+func (r *TopicRouter) HandleUnmatched(handler RouteHandler, middlewareFns ...RouteMiddleware) {
+	r.unmatched = wrapHandler(handler, middlewareFns)
+	r.unmatchedChain = wrapHandler(r.unmatched, r.middlewares)
+}
+
+// Matches сообщает, есть ли для сообщения маршрут или обработчик несопоставленных сообщений.
+func (r *TopicRouter) Matches(message *kafka.Message) bool {
+	if r.unmatched != nil {
+		return true
+	}
+	for _, rt := range r.routes {
+		if rt.match(message) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *TopicRouter) Route(ctx context.Context, message *kafka.Message) error {
+	handler := r.unmatchedChain
+	if rt := r.match(message); rt != nil {
+		handler = rt.chain
+	}
+	if handler == nil {
+		return fmt.Errorf("%w: topic %q", ErrNoRoute, message.Topic)
+	}
+
+	return handler(ctx, message)
+}
+
+// RouteBatch обрабатывает сообщения и возвращает ошибки обработки по их индексам.
+// Сообщения маршрутов, зарегистрированных через HandleBatch, передаются
+// обработчику маршрута одной пачкой в исходном порядке, остальные
+// обрабатываются по одному, как в Route.
+func (r *TopicRouter) RouteBatch(ctx context.Context, messages []*kafka.Message) []error {
+	errs := make([]error, len(messages))
+
+	var (
+		batchRoutes []*route
+		batches     = make(map[*route][]int)
+	)
+	for i, message := range messages {
+		rt := r.match(message)
+		if rt == nil || rt.batch == nil {
+			errs[i] = r.Route(ctx, message)
+			continue
+		}
+
+		if _, ok := batches[rt]; !ok {
+			batchRoutes = append(batchRoutes, rt)
+		}
+		batches[rt] = append(batches[rt], i)
+	}
+
+	for _, rt := range batchRoutes {
+		indices := batches[rt]
+		batch := make([]*kafka.Message, len(indices))
+		for j, i := range indices {
+			batch[j] = messages[i]
+		}
+
+		results := callBatchHandler(ctx, rt.batch, batch)
+		for j, i := range indices {
+			rctx := context.WithValue(ctx, batchResultKey{}, batchResult{err: results[j]})
+			errs[i] = rt.batchChain(rctx, messages[i])
+		}
+	}
+
+	return errs
+}
+
+func (r *TopicRouter) match(message *kafka.Message) *route {
+	for i := range r.routes {
+		if r.routes[i].match(message) {
+			return &r.routes[i]
+		}
+	}
+
+	return nil
+}
+
+func wrapHandler(handler RouteHandler, middlewareFns []RouteMiddleware) RouteHandler {
 	h := handler
 
 	for _, middlewareFn := range middlewareFns {
 		h = middlewareFn(h)
 	}
 
-	r.routes[topic] = h
+	return h
 }
 
-func (r *TopicRouter) Route(ctx context.Context, message *kafka.Message) {
-	handler, ok := r.routes[message.Topic]
-	if !ok {
-		return
+func MatchTopic(topic string) MessageMatcher {
+	return func(message *kafka.Message) bool {
+		return message.Topic == topic
 	}
+}
+
+func MatchTopicPattern(pattern *regexp.Regexp) MessageMatcher {
+	return func(message *kafka.Message) bool {
+		return pattern.MatchString(message.Topic)
+	}
+}
+
+func MatchHeader(key, value string) MessageMatcher {
+	return func(message *kafka.Message) bool {
+		for _, header := range message.Headers {
+			if header.Key == key && string(header.Value) == value {
+				return true
+			}
+		}
 
-	_ = handler(ctx, message)
+		return false
+	}
+}
+
+// MatchPayloadField сопоставляет сообщения по значению поля JSON-payload'а.
+// Путь к полю задаётся через точку, например "payload.status".
+func MatchPayloadField(path, value string) MessageMatcher {
+	keys := strings.Split(path, ".")
+
+	return func(message *kafka.Message) bool {
+		fieldValue, ok := lookupJSONField(message.Value, keys)
+		return ok && fieldValue == value
+	}
+}
+
+func lookupJSONField(data []byte, keys []string) (string, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var node any
+	if err := decoder.Decode(&node); err != nil {
+		return "", false
+	}
+
+	for _, key := range keys {
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return "", false
+		}
+
+		node, ok = obj[key]
+		if !ok {
+			return "", false
+		}
+	}
+
+	switch v := node.(type) {
+	case string:
+		return v, true
+	case json.Number, bool:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
 }
@@ -0,0 +1,147 @@
+//go:build unit_test
+
+package consumer
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicRouterRoute(t *testing.T) {
+	var handled string
+
+	newHandler := func(name string) RouteHandler {
+		return func(_ context.Context, _ *kafka.Message) error {
+			handled = name
+			return nil
+		}
+	}
+
+	router := NewTopicRouter()
+	router.Handle("orders", newHandler("exact"))
+	router.HandleHeader("event-type", "account.paid", newHandler("header"))
+	router.HandlePayload("payload.status", "CANCELED", newHandler("payload"))
+	router.HandlePattern(regexp.MustCompile(`^accounts\..+`), newHandler("pattern"))
+
+	tests := []struct {
+		name     string
+		message  kafka.Message
+		expected string
+	}{
+		{
+			name:     "ExactTopic",
+			message:  kafka.Message{Topic: "orders"},
+			expected: "exact",
+		},
+		{
+			name: "Header",
+			message: kafka.Message{
+				Topic:   "accounts.public.account_events",
+				Headers: []kafka.Header{{Key: "event-type", Value: []byte("account.paid")}},
+			},
+			expected: "header",
+		},
+		{
+			name: "PayloadField",
+			message: kafka.Message{
+				Topic: "accounts.public.account_events",
+				Value: []byte(`{"payload": {"status": "CANCELED"}}`),
+			},
+			expected: "payload",
+		},
+		{
+			name: "TopicPattern",
+			message: kafka.Message{
+				Topic: "accounts.public.account_events",
+				Value: []byte(`{"payload": {"status": "PAID"}}`),
+			},
+			expected: "pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled = ""
+
+			err := router.Route(context.Background(), &tt.message)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, handled)
+		})
+	}
+}
+
+func TestTopicRouterUnmatched(t *testing.T) {
+	router := NewTopicRouter()
+	router.Handle("orders", func(_ context.Context, _ *kafka.Message) error {
+		return nil
+	})
+
+	err := router.Route(context.Background(), &kafka.Message{Topic: "unknown"})
+	assert.ErrorIs(t, err, ErrNoRoute)
+
+	var unmatchedTopic string
+	router.HandleUnmatched(func(_ context.Context, message *kafka.Message) error {
+		unmatchedTopic = message.Topic
+		return nil
+	})
+
+	err = router.Route(context.Background(), &kafka.Message{Topic: "unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, "unknown", unmatchedTopic)
+}
+
+func TestTopicRouterMiddlewareOrder(t *testing.T) {
+	var calls []string
+
+	newMiddleware := func(name string) RouteMiddleware {
+		return func(next RouteHandler) RouteHandler {
+			return func(ctx context.Context, message *kafka.Message) error {
+				calls = append(calls, name)
+				return next(ctx, message)
+			}
+		}
+	}
+
+	router := NewTopicRouter()
+	router.Handle(
+		"orders",
+		func(_ context.Context, _ *kafka.Message) error {
+			calls = append(calls, "handler")
+			return nil
+		},
+		newMiddleware("route"),
+	)
+	router.Use(newMiddleware("global"))
+
+	err := router.Route(context.Background(), &kafka.Message{Topic: "orders"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"global", "route", "handler"}, calls)
+}
+
+func TestTopicRouterBuildsMiddlewareOnce(t *testing.T) {
+	var built int
+	counting := func(next RouteHandler) RouteHandler {
+		built++
+		return next
+	}
+
+	router := NewTopicRouter()
+	router.Handle("orders", func(_ context.Context, _ *kafka.Message) error { return nil })
+	router.HandleBatch("payments", func(_ context.Context, _ []*kafka.Message) error { return nil })
+	router.Use(counting)
+	built = 0
+
+	messages := []*kafka.Message{{Topic: "orders"}, {Topic: "payments"}, {Topic: "payments"}}
+	for i := 0; i < 3; i++ {
+		for _, message := range messages {
+			assert.NoError(t, router.Route(context.Background(), message))
+		}
+		assert.Equal(t, []error{nil, nil, nil}, router.RouteBatch(context.Background(), messages))
+	}
+
+	assert.Zero(t, built, "middleware chain must not be rebuilt per message")
+}
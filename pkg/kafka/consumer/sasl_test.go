@@ -0,0 +1,36 @@
+//go:build unit_test
+
+package consumer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSASLMechanism(t *testing.T) {
+	tests := []struct {
+		mechanism string
+		expected  string
+	}{
+		{mechanism: "plain", expected: SASLMechanismPlain},
+		{mechanism: SASLMechanismSCRAMSHA256, expected: SASLMechanismSCRAMSHA256},
+		{mechanism: SASLMechanismSCRAMSHA512, expected: SASLMechanismSCRAMSHA512},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mechanism, func(t *testing.T) {
+			mechanism, err := NewSASLMechanism(tt.mechanism, "user", "pass")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, mechanism.Name())
+		})
+	}
+
+	mechanism, err := NewSASLMechanism("", "", "")
+	require.NoError(t, err)
+	assert.Nil(t, mechanism)
+
+	_, err = NewSASLMechanism("GSSAPI", "user", "pass")
+	assert.Error(t, err)
+}
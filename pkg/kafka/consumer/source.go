@@ -0,0 +1,190 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSource - MessageSource поверх kafka.Reader. В группе партиции читаются
+// участником kafka.ConsumerGroup, что позволяет сообщать о ребалансировках.
+type KafkaSource struct {
+	// r читает партицию без группы, group - назначенные партиции в группе.
+	r     *kafka.Reader
+	group *groupReader
+	admin *Admin
+	topic string
+	// groupID пуст при чтении без группы.
+	groupID string
+	// revoked вызывается при закрытии источника без группы.
+	revoked func(map[string][]int)
+}
+
+func NewKafkaSource(cfg Configuration) (*KafkaSource, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	admin := NewAdmin(cfg)
+	startOffset := kafka.LastOffset
+
+	switch cfg.StartOffset {
+	case StartOffsetEarliest:
+		startOffset = kafka.FirstOffset
+	case StartOffsetTimestamp:
+		// Партиции, появившиеся после фиксации смещений, читаются с начала.
+		startOffset = kafka.FirstOffset
+		if cfg.GroupID != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			err := initGroupOffsets(ctx, admin, cfg)
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("failed to set group offsets for start time: %w", err)
+			}
+		}
+	}
+
+	clientID := "kafka-go"
+	if hostname, err := os.Hostname(); err == nil {
+		clientID = hostname
+	}
+	clientID += "-" + uuid.NewString()
+
+	dialer := &kafka.Dialer{
+		ClientID:      clientID,
+		TLS:           cfg.TLS,
+		SASLMechanism: cfg.SASL,
+	}
+
+	if cfg.GroupID != "" {
+		topics := cfg.GroupTopics
+		if cfg.Topic != "" {
+			topics = []string{cfg.Topic}
+		}
+
+		logger := cfg.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+
+		group, err := newGroupReader(kafka.ConsumerGroupConfig{
+			ID:                cfg.GroupID,
+			Brokers:           cfg.BrokerURLs,
+			Dialer:            dialer,
+			Topics:            topics,
+			HeartbeatInterval: cfg.HeartbeatInterval,
+			SessionTimeout:    cfg.SessionTimeout,
+			// StartOffset применяется, только если у группы нет
+			// зафиксированных смещений.
+			StartOffset: startOffset,
+		}, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		return &KafkaSource{group: group, admin: admin, groupID: cfg.GroupID}, nil
+	}
+
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  cfg.BrokerURLs,
+		Topic:    cfg.Topic,
+		Dialer:   dialer,
+		MaxBytes: 10e6, // 10 MB
+	})
+
+	var err error
+	if cfg.StartOffset == StartOffsetTimestamp {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err = r.SetOffsetAt(ctx, cfg.StartTime)
+		cancel()
+	} else {
+		err = r.SetOffset(startOffset)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &KafkaSource{r: r, admin: admin, topic: cfg.Topic}, nil
+}
+
+// NewKafkaReplaySource создаёт источник для Replayer, читающий партиции с начала.
+// Группа cfg.GroupID должна отличаться от группы рабочего консьюмера.
+func NewKafkaReplaySource(cfg Configuration) (*KafkaSource, error) {
+	cfg.StartOffset = StartOffsetEarliest
+	cfg.StartTime = time.Time{}
+
+	return NewKafkaSource(cfg)
+}
+
+func (s *KafkaSource) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if s.group != nil {
+		return s.group.FetchMessage(ctx)
+	}
+
+	return s.r.FetchMessage(ctx)
+}
+
+// CommitMessages фиксирует смещения в группе консьюмеров. Без GroupID
+// смещения не хранятся, поэтому вызов ничего не делает.
+func (s *KafkaSource) CommitMessages(ctx context.Context, messages ...kafka.Message) error {
+	if s.group == nil {
+		return nil
+	}
+
+	return s.group.CommitMessages(ctx, messages...)
+}
+
+func (s *KafkaSource) HighWatermarks(ctx context.Context, topic string) (map[int]int64, error) {
+	return s.admin.HighWatermarks(ctx, topic)
+}
+
+// Assignment возвращает партиции, назначенные источнику в текущем поколении
+// группы. Без группы читается партиция 0 топика.
+func (s *KafkaSource) Assignment(ctx context.Context) (map[string][]int, error) {
+	if s.group == nil {
+		return map[string][]int{s.topic: {0}}, nil
+	}
+
+	return s.group.Assignment(ctx)
+}
+
+// SetRebalanceHooks задаёт функции, вызываемые при назначении партиций
+// источнику в новом поколении группы и при их отзыве по завершении поколения.
+// Без группы партиция назначается сразу и отзывается при закрытии источника.
+func (s *KafkaSource) SetRebalanceHooks(assigned, revoked func(map[string][]int)) {
+	if s.group != nil {
+		s.group.SetRebalanceHooks(assigned, revoked)
+		return
+	}
+
+	s.revoked = revoked
+	if assigned != nil {
+		assigned(map[string][]int{s.topic: {0}})
+	}
+}
+
+func (s *KafkaSource) CommittedOffsets(ctx context.Context, topic string) (map[int]int64, error) {
+	if s.groupID == "" {
+		return map[int]int64{}, nil
+	}
+
+	return s.admin.CommittedOffsets(ctx, s.groupID, topic)
+}
+
+func (s *KafkaSource) Close() error {
+	if s.group != nil {
+		return s.group.Close()
+	}
+
+	err := s.r.Close()
+	if s.revoked != nil {
+		s.revoked(map[string][]int{s.topic: {0}})
+	}
+
+	return err
+}
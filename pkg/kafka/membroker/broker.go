@@ -0,0 +1,296 @@
+// Package membroker реализует in-memory брокер с семантикой, близкой к Kafka:
+// топики с партициями, смещения, группы консьюмеров с фиксацией смещений
+// и ребалансировкой. Предназначен для тестов и локального запуска сервисов.
+package membroker
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+var ErrReaderClosed = errors.New("reader is closed")
+
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+type group struct {
+	committed  map[partitionKey]int64
+	members    []*Reader
+	generation int
+}
+
+type Broker struct {
+	mu      sync.Mutex
+	topics  map[string][][]kafka.Message
+	groups  map[string]*group
+	changed chan struct{}
+	// members - счётчик для идентификаторов участников групп.
+	members int
+	// hooks - хуки ребалансировки читателей, ожидающие вызова после
+	// освобождения мьютекса; delivering - вызываются ли они сейчас.
+	hooks      []func()
+	delivering bool
+
+	defaultPartitions int
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		topics:            make(map[string][][]kafka.Message),
+		groups:            make(map[string]*group),
+		changed:           make(chan struct{}),
+		defaultPartitions: 1,
+	}
+}
+
+// CreateTopic создаёт топик с указанным количеством партиций. Топики,
+// в которые пишут без предварительного создания, получают одну партицию.
+func (b *Broker) CreateTopic(topic string, partitions int) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	if partitions <= 0 {
+		partitions = b.defaultPartitions
+	}
+	if _, ok := b.topics[topic]; ok {
+		return
+	}
+
+	b.topics[topic] = make([][]kafka.Message, partitions)
+	b.rebalanceLocked()
+}
+
+// Produce записывает сообщения в конец партиций. Партиция выбирается по хэшу
+// ключа, смещение и время записи проставляются брокером.
+func (b *Broker) Produce(_ context.Context, messages ...kafka.Message) error {
+	b.mu.Lock()
+	defer b.unlock()
+
+	created := false
+	for _, msg := range messages {
+		if msg.Topic == "" {
+			return errors.New("message topic is not specified")
+		}
+
+		partitions, ok := b.topics[msg.Topic]
+		if !ok {
+			partitions = make([][]kafka.Message, b.defaultPartitions)
+			created = true
+		}
+
+		partition := partitionForKey(msg.Key, len(partitions))
+		msg.Partition = partition
+		msg.Offset = int64(len(partitions[partition]))
+		if msg.Time.IsZero() {
+			msg.Time = time.Now()
+		}
+
+		partitions[partition] = append(partitions[partition], msg)
+		b.topics[msg.Topic] = partitions
+	}
+
+	if created {
+		b.rebalanceLocked()
+	}
+	b.notifyLocked()
+
+	return nil
+}
+
+// Messages возвращает копию всех сообщений партиции.
+func (b *Broker) Messages(topic string, partition int) []kafka.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	partitions := b.topics[topic]
+	if partition < 0 || partition >= len(partitions) {
+		return nil
+	}
+
+	return append([]kafka.Message(nil), partitions[partition]...)
+}
+
+// Partitions возвращает количество партиций топика или 0, если топика нет.
+func (b *Broker) Partitions(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.topics[topic])
+}
+
+// CommittedOffset возвращает следующее к чтению смещение группы в партиции
+// или -1, если группа ещё ничего не фиксировала.
+func (b *Broker) CommittedOffset(groupID, topic string, partition int) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g, ok := b.groups[groupID]
+	if !ok {
+		return -1
+	}
+
+	offset, ok := g.committed[partitionKey{topic: topic, partition: partition}]
+	if !ok {
+		return -1
+	}
+
+	return offset
+}
+
+// Rebalance принудительно перераспределяет партиции между участниками группы.
+// Участники продолжают чтение с последних зафиксированных смещений.
+func (b *Broker) Rebalance(groupID string) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	if g, ok := b.groups[groupID]; ok {
+		b.rebalanceGroupLocked(g)
+		b.notifyLocked()
+	}
+}
+
+func (b *Broker) rebalanceLocked() {
+	for _, g := range b.groups {
+		b.rebalanceGroupLocked(g)
+	}
+}
+
+func (b *Broker) rebalanceGroupLocked(g *group) {
+	g.generation++
+
+	previous := make(map[*Reader]map[string][]int, len(g.members))
+	for _, member := range g.members {
+		previous[member] = member.assignmentLocked()
+		member.positions = make(map[partitionKey]int64)
+	}
+	defer func() {
+		for _, member := range g.members {
+			member.reassignedLocked(previous[member])
+		}
+	}()
+
+	if len(g.members) == 0 {
+		return
+	}
+
+	// Партиции раздаются участникам по кругу в детерминированном порядке.
+	var keys []partitionKey
+	for _, topic := range subscribedTopics(g.members) {
+		for p := range b.topics[topic] {
+			keys = append(keys, partitionKey{topic: topic, partition: p})
+		}
+	}
+
+	i := 0
+	for _, key := range keys {
+		for attempts := 0; attempts < len(g.members); attempts++ {
+			member := g.members[i%len(g.members)]
+			i++
+
+			if !member.subscribed(key.topic) {
+				continue
+			}
+
+			member.positions[key] = b.startOffsetLocked(g, member, key)
+			break
+		}
+	}
+}
+
+func (b *Broker) startOffsetLocked(g *group, r *Reader, key partitionKey) int64 {
+	if offset, ok := g.committed[key]; ok {
+		return offset
+	}
+
+	return b.resolveOffsetLocked(key, r.cfg.StartOffset)
+}
+
+func (b *Broker) resolveOffsetLocked(key partitionKey, offset int64) int64 {
+	size := int64(0)
+	if partitions := b.topics[key.topic]; key.partition < len(partitions) {
+		size = int64(len(partitions[key.partition]))
+	}
+
+	switch {
+	case offset == kafka.LastOffset:
+		return size
+	case offset == kafka.FirstOffset || offset < 0:
+		return 0
+	case offset > size:
+		return size
+	default:
+		return offset
+	}
+}
+
+// unlock освобождает мьютекс брокера и вызывает накопленные хуки
+// ребалансировки. Хуки вызываются без мьютекса по одному и в порядке
+// ребалансировок: если их уже вызывает другая горутина, новые хуки
+// вызовет она.
+func (b *Broker) unlock() {
+	if b.delivering {
+		b.mu.Unlock()
+		return
+	}
+
+	b.delivering = true
+	for len(b.hooks) > 0 {
+		hook := b.hooks[0]
+		b.hooks = b.hooks[1:]
+
+		b.mu.Unlock()
+		hook()
+		b.mu.Lock()
+	}
+	b.delivering = false
+	b.mu.Unlock()
+}
+
+func (b *Broker) notifyLocked() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func subscribedTopics(members []*Reader) []string {
+	seen := make(map[string]struct{})
+	var topics []string
+
+	for _, member := range members {
+		for _, topic := range member.topics() {
+			if _, ok := seen[topic]; ok {
+				continue
+			}
+
+			seen[topic] = struct{}{}
+			topics = append(topics, topic)
+		}
+	}
+
+	sort.Strings(topics)
+	return topics
+}
+
+func partitionForKey(key []byte, partitions int) int {
+	if partitions <= 1 || len(key) == 0 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write(key)
+
+	return int(h.Sum32() % uint32(partitions))
+}
+
+// WriteMessages записывает сообщения так же, как Produce, и реализует
+// consumer.MessageWriter.
+func (b *Broker) WriteMessages(ctx context.Context, messages ...kafka.Message) error {
+	return b.Produce(ctx, messages...)
+}
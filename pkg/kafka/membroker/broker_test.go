@@ -0,0 +1,145 @@
+//go:build unit_test
+
+package membroker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrokerProduceAndFetch(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("orders", 3)
+
+	for i := 0; i < 9; i++ {
+		err := broker.Produce(context.Background(), kafka.Message{
+			Topic: "orders",
+			Key:   []byte(fmt.Sprint(i)),
+		})
+		require.NoError(t, err)
+	}
+
+	reader := broker.NewReader(ReaderConfig{GroupID: "group", Topic: "orders"})
+	defer reader.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	received := make(map[int][]int64)
+	for i := 0; i < 9; i++ {
+		msg, err := reader.FetchMessage(ctx)
+		require.NoError(t, err)
+
+		received[msg.Partition] = append(received[msg.Partition], msg.Offset)
+	}
+
+	total := 0
+	for partition, offsets := range received {
+		assert.IsIncreasing(t, offsets, "partition %d", partition)
+		total += len(offsets)
+	}
+	assert.Equal(t, 9, total)
+
+	_, err := reader.FetchMessage(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestBrokerRebalanceRedeliversUncommitted(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("orders", 1)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, broker.Produce(context.Background(), kafka.Message{Topic: "orders"}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	first := broker.NewReader(ReaderConfig{GroupID: "group", Topic: "orders"})
+
+	msg, err := first.FetchMessage(ctx)
+	require.NoError(t, err)
+	require.NoError(t, first.CommitMessages(ctx, msg))
+
+	_, err = first.FetchMessage(ctx)
+	require.NoError(t, err)
+
+	// Второй участник забирает единственную партицию, незафиксированное
+	// сообщение должно быть доставлено повторно.
+	require.NoError(t, first.Close())
+	second := broker.NewReader(ReaderConfig{GroupID: "group", Topic: "orders"})
+	defer second.Close()
+
+	msg, err = second.FetchMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), msg.Offset)
+	assert.Equal(t, int64(1), broker.CommittedOffset("group", "orders", 0))
+}
+
+func TestBrokerCommitAfterRevoke(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("orders", 2)
+	require.NoError(t, broker.Produce(context.Background(), kafka.Message{Topic: "orders"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	first := broker.NewReader(ReaderConfig{GroupID: "group", Topic: "orders"})
+	defer first.Close()
+
+	msg, err := first.FetchMessage(ctx)
+	require.NoError(t, err)
+
+	second := broker.NewReader(ReaderConfig{GroupID: "group", Topic: "orders"})
+	defer second.Close()
+
+	assignment, err := first.Assignment(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int{"orders": {0}}, assignment)
+	assignment, err = second.Assignment(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int{"orders": {1}}, assignment)
+
+	broker.Rebalance("group")
+	assert.NoError(t, first.CommitMessages(ctx, msg))
+
+	err = second.CommitMessages(ctx, msg)
+	assert.ErrorIs(t, err, kafka.RebalanceInProgress)
+}
+
+func TestReaderRebalanceHooks(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("orders", 2)
+
+	var events []string
+	hook := func(event string) func(map[string][]int) {
+		return func(assignment map[string][]int) {
+			events = append(events, fmt.Sprint(event, assignment["orders"]))
+		}
+	}
+
+	first := broker.NewReader(ReaderConfig{GroupID: "group", Topic: "orders"})
+	first.SetRebalanceHooks(hook("assigned"), hook("revoked"))
+	assert.Equal(t, []string{"assigned[0 1]"}, events)
+
+	// Ребалансировка без изменения назначения хуки не вызывает.
+	broker.Rebalance("group")
+	assert.Len(t, events, 1)
+
+	second := broker.NewReader(ReaderConfig{GroupID: "group", Topic: "orders"})
+	assert.Equal(t, []string{"assigned[0 1]", "revoked[0 1]", "assigned[0]"}, events)
+
+	require.NoError(t, second.Close())
+	require.NoError(t, first.Close())
+	assert.Equal(t, []string{
+		"assigned[0 1]", "revoked[0 1]", "assigned[0]",
+		"revoked[0]", "assigned[0 1]",
+		"revoked[0 1]",
+	}, events)
+}
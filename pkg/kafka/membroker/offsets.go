@@ -0,0 +1,149 @@
+package membroker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrGroupHasMembers возвращается при фиксации смещений без участия в группе,
+// у которой есть активные участники.
+var ErrGroupHasMembers = errors.New("group has active members")
+
+// Методы ниже реализуют consumer.GroupAdmin. Сообщения не удаляются,
+// поэтому первое доступное смещение всегда равно 0.
+
+func (b *Broker) LowWatermarks(_ context.Context, topic string) (map[int]int64, error) {
+	return b.partitionOffsets(topic, func(int, int) int64 { return 0 })
+}
+
+func (b *Broker) HighWatermarks(_ context.Context, topic string) (map[int]int64, error) {
+	return b.partitionOffsets(topic, func(_, size int) int64 { return int64(size) })
+}
+
+func (b *Broker) OffsetsForTime(_ context.Context, topic string, t time.Time) (map[int]int64, error) {
+	return b.partitionOffsets(topic, func(partition, size int) int64 {
+		log := b.topics[topic][partition]
+		for i := 0; i < size; i++ {
+			if !log[i].Time.Before(t) {
+				return int64(i)
+			}
+		}
+		return int64(size)
+	})
+}
+
+func (b *Broker) CommittedOffsets(_ context.Context, groupID, topic string) (map[int]int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	offsets := make(map[int]int64)
+	if g, ok := b.groups[groupID]; ok {
+		for key, offset := range g.committed {
+			if key.topic == topic {
+				offsets[key.partition] = offset
+			}
+		}
+	}
+
+	return offsets, nil
+}
+
+// CommitOffsets фиксирует смещения группы так же, как Kafka принимает фиксацию
+// от клиента вне группы: только если в группе нет участников.
+func (b *Broker) CommitOffsets(_ context.Context, groupID, topic string, offsets map[int]int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g, ok := b.groups[groupID]
+	if !ok {
+		g = &group{committed: make(map[partitionKey]int64)}
+		b.groups[groupID] = g
+	}
+	if len(g.members) > 0 {
+		return ErrGroupHasMembers
+	}
+
+	for partition, offset := range offsets {
+		if partition < 0 || partition >= len(b.topics[topic]) {
+			return fmt.Errorf("partition %d of topic %q not found", partition, topic)
+		}
+		g.committed[partitionKey{topic: topic, partition: partition}] = offset
+	}
+
+	return nil
+}
+
+func (b *Broker) GroupMembers(_ context.Context, groupID string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if g, ok := b.groups[groupID]; ok {
+		return len(g.members), nil
+	}
+
+	return 0, nil
+}
+
+// DescribeGroup возвращает участников группы и назначенные им партиции
+// в формате ответа Kafka DescribeGroups.
+func (b *Broker) DescribeGroup(_ context.Context, groupID string) (kafka.DescribeGroupsResponseGroup, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	description := kafka.DescribeGroupsResponseGroup{GroupID: groupID, GroupState: "Empty"}
+	g, ok := b.groups[groupID]
+	if !ok || len(g.members) == 0 {
+		return description, nil
+	}
+
+	description.GroupState = "Stable"
+	for _, member := range g.members {
+		var topics []kafka.GroupMemberTopic
+		assignment := member.assignmentLocked()
+		for _, topic := range sortedTopics(assignment) {
+			topics = append(topics, kafka.GroupMemberTopic{Topic: topic, Partitions: assignment[topic]})
+		}
+
+		description.Members = append(description.Members, kafka.DescribeGroupsResponseMember{
+			MemberID:          member.memberID,
+			ClientID:          member.clientID(),
+			ClientHost:        "localhost",
+			MemberMetadata:    kafka.DescribeGroupsResponseMemberMetadata{Topics: member.topics()},
+			MemberAssignments: kafka.DescribeGroupsResponseAssignments{Topics: topics},
+		})
+	}
+
+	return description, nil
+}
+
+func sortedTopics(assignment map[string][]int) []string {
+	topics := make([]string, 0, len(assignment))
+	for topic := range assignment {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	return topics
+}
+
+func (b *Broker) partitionOffsets(topic string, offset func(partition, size int) int64) (map[int]int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	partitions, ok := b.topics[topic]
+	if !ok {
+		return nil, fmt.Errorf("topic %q not found", topic)
+	}
+
+	offsets := make(map[int]int64, len(partitions))
+	for p, log := range partitions {
+		offsets[p] = offset(p, len(log))
+	}
+
+	return offsets, nil
+}
@@ -0,0 +1,299 @@
+package membroker
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type ReaderConfig struct {
+	// ClientID - идентификатор клиента участника группы. По умолчанию "membroker".
+	ClientID    string
+	GroupID     string
+	GroupTopics []string
+	Topic       string
+	// Partition - партиция для чтения без группы, как в kafka.ReaderConfig.
+	Partition int
+	// StartOffset - kafka.FirstOffset, kafka.LastOffset или конкретное смещение.
+	// Для групп применяется, только если у группы нет зафиксированного смещения.
+	StartOffset int64
+}
+
+// Reader читает сообщения из брокера и реализует consumer.MessageSource.
+type Reader struct {
+	broker *Broker
+	cfg    ReaderConfig
+
+	// positions - назначенные читателю партиции и следующие к чтению смещения.
+	// Защищено мьютексом брокера.
+	positions map[partitionKey]int64
+	cursor    int
+	closed    bool
+	memberID  string
+
+	// assigned и revoked - хуки ребалансировки, см. SetRebalanceHooks.
+	assigned, revoked func(map[string][]int)
+}
+
+func (b *Broker) NewReader(cfg ReaderConfig) *Reader {
+	b.mu.Lock()
+	defer b.unlock()
+
+	r := &Reader{
+		broker:    b,
+		cfg:       cfg,
+		positions: make(map[partitionKey]int64),
+	}
+
+	if cfg.GroupID == "" {
+		r.assignStandaloneLocked()
+		return r
+	}
+
+	g, ok := b.groups[cfg.GroupID]
+	if !ok {
+		g = &group{committed: make(map[partitionKey]int64)}
+		b.groups[cfg.GroupID] = g
+	}
+	b.members++
+	r.memberID = fmt.Sprintf("%s-%d", r.clientID(), b.members)
+	g.members = append(g.members, r)
+	b.rebalanceGroupLocked(g)
+	b.notifyLocked()
+
+	return r
+}
+
+// FetchMessage блокируется до появления сообщения в одной из назначенных
+// партиций, отмены контекста или закрытия читателя.
+func (r *Reader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	for {
+		r.broker.mu.Lock()
+		if r.closed {
+			r.broker.unlock()
+			return kafka.Message{}, ErrReaderClosed
+		}
+
+		msg, ok := r.nextLocked()
+		changed := r.broker.changed
+		r.broker.unlock()
+
+		if ok {
+			return msg, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// nextLocked выбирает партиции по кругу, чтобы одна активная партиция
+// не блокировала чтение остальных.
+func (r *Reader) nextLocked() (kafka.Message, bool) {
+	if r.cfg.GroupID == "" {
+		r.assignStandaloneLocked()
+	}
+
+	keys := make([]partitionKey, 0, len(r.positions))
+	for key := range r.positions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].topic != keys[j].topic {
+			return keys[i].topic < keys[j].topic
+		}
+		return keys[i].partition < keys[j].partition
+	})
+
+	for i := range keys {
+		key := keys[(r.cursor+i)%len(keys)]
+		log := r.broker.topics[key.topic][key.partition]
+
+		offset := r.positions[key]
+		if offset >= int64(len(log)) {
+			continue
+		}
+
+		r.positions[key] = offset + 1
+		r.cursor = (r.cursor + i + 1) % len(keys)
+
+		return log[offset], true
+	}
+
+	return kafka.Message{}, false
+}
+
+// CommitMessages фиксирует смещения в группе. Фиксация партиций, которые
+// после ребалансировки принадлежат другому участнику, отклоняется так же,
+// как это делает Kafka. Без группы вызов ничего не делает.
+func (r *Reader) CommitMessages(_ context.Context, messages ...kafka.Message) error {
+	if r.cfg.GroupID == "" {
+		return nil
+	}
+
+	r.broker.mu.Lock()
+	defer r.broker.mu.Unlock()
+
+	if r.closed {
+		return ErrReaderClosed
+	}
+
+	g := r.broker.groups[r.cfg.GroupID]
+	for _, msg := range messages {
+		key := partitionKey{topic: msg.Topic, partition: msg.Partition}
+		if _, assigned := r.positions[key]; !assigned {
+			return kafka.RebalanceInProgress
+		}
+
+		if next := msg.Offset + 1; next > g.committed[key] {
+			g.committed[key] = next
+		}
+	}
+
+	return nil
+}
+
+// Close выводит читателя из группы, что приводит к ребалансировке
+// оставшихся участников.
+func (r *Reader) Close() error {
+	r.broker.mu.Lock()
+	defer r.broker.unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	previous := r.assignmentLocked()
+	r.positions = make(map[partitionKey]int64)
+	r.reassignedLocked(previous)
+
+	if g, ok := r.broker.groups[r.cfg.GroupID]; ok {
+		for i, member := range g.members {
+			if member == r {
+				g.members = append(g.members[:i], g.members[i+1:]...)
+				break
+			}
+		}
+		r.broker.rebalanceGroupLocked(g)
+	}
+	r.broker.notifyLocked()
+
+	return nil
+}
+
+// Assignment возвращает назначенные читателю партиции по топикам.
+func (r *Reader) Assignment(_ context.Context) (map[string][]int, error) {
+	r.broker.mu.Lock()
+	defer r.broker.mu.Unlock()
+
+	return r.assignmentLocked(), nil
+}
+
+func (r *Reader) assignmentLocked() map[string][]int {
+	assignment := make(map[string][]int)
+	for key := range r.positions {
+		assignment[key.topic] = append(assignment[key.topic], key.partition)
+	}
+	for topic := range assignment {
+		sort.Ints(assignment[topic])
+	}
+
+	return assignment
+}
+
+// CommittedOffsets возвращает зафиксированные группой читателя смещения партиций топика.
+func (r *Reader) CommittedOffsets(ctx context.Context, topic string) (map[int]int64, error) {
+	if r.cfg.GroupID == "" {
+		return map[int]int64{}, nil
+	}
+
+	return r.broker.CommittedOffsets(ctx, r.cfg.GroupID, topic)
+}
+
+func (r *Reader) clientID() string {
+	if r.cfg.ClientID != "" {
+		return r.cfg.ClientID
+	}
+
+	return "membroker"
+}
+
+// assignStandaloneLocked назначает читателю без группы его партицию, как только
+// топик появится в брокере.
+func (r *Reader) assignStandaloneLocked() {
+	key := partitionKey{topic: r.cfg.Topic, partition: r.cfg.Partition}
+	if _, ok := r.positions[key]; ok || r.closed {
+		return
+	}
+
+	if key.partition < len(r.broker.topics[key.topic]) {
+		r.positions[key] = r.broker.resolveOffsetLocked(key, r.cfg.StartOffset)
+		r.reassignedLocked(nil)
+	}
+}
+
+// SetRebalanceHooks задаёт функции, вызываемые с партициями по топикам при
+// изменении назначения читателя: revoked - с прежними партициями, assigned -
+// с новыми. Как и в Kafka, при ребалансировке, изменившей назначение
+// читателя, отзываются все его партиции, а не только перешедшие к другим.
+// Если партиции уже назначены, assigned вызывается сразу. Хуки вызываются
+// без блокировки брокера.
+func (r *Reader) SetRebalanceHooks(assigned, revoked func(map[string][]int)) {
+	r.broker.mu.Lock()
+	defer r.broker.unlock()
+
+	r.assigned, r.revoked = assigned, revoked
+	if current := r.assignmentLocked(); len(current) > 0 && assigned != nil {
+		r.broker.hooks = append(r.broker.hooks, func() { assigned(current) })
+	}
+}
+
+// reassignedLocked ставит в очередь хуки ребалансировки, если назначение
+// читателя отличается от previous.
+func (r *Reader) reassignedLocked(previous map[string][]int) {
+	current := r.assignmentLocked()
+	if reflect.DeepEqual(previous, current) {
+		return
+	}
+
+	if revoked := r.revoked; revoked != nil && len(previous) > 0 {
+		r.broker.hooks = append(r.broker.hooks, func() { revoked(previous) })
+	}
+	if assigned := r.assigned; assigned != nil && len(current) > 0 {
+		r.broker.hooks = append(r.broker.hooks, func() { assigned(current) })
+	}
+}
+
+func (r *Reader) topics() []string {
+	if len(r.cfg.GroupTopics) > 0 {
+		return r.cfg.GroupTopics
+	}
+	if r.cfg.Topic != "" {
+		return []string{r.cfg.Topic}
+	}
+
+	return nil
+}
+
+func (r *Reader) subscribed(topic string) bool {
+	for _, t := range r.topics() {
+		if t == topic {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HighWatermarks возвращает для каждой партиции топика смещение, следующее
+// за последним записанным сообщением.
+func (r *Reader) HighWatermarks(ctx context.Context, topic string) (map[int]int64, error) {
+	return r.broker.HighWatermarks(ctx, topic)
+}
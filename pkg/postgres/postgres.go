@@ -2,9 +2,17 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,6 +23,13 @@ type Configuration struct {
 	Password string
 	Name     string
 
+	// SSLMode, SSLRootCert, SSLCert и SSLKey соответствуют одноимённым
+	// параметрам libpq (sslmode, sslrootcert, sslcert, sslkey).
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
 	MaxOpenConns        int
 	MaxConnLifetime     time.Duration
 	MaxIdleConnLifetime time.Duration
@@ -24,19 +39,28 @@ type Configuration struct {
 }
 
 func New(ctx context.Context, cfg Configuration) (*pgxpool.Pool, error) {
-	connStr := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.Name,
-	)
+	connStr := ConnString(cfg)
 	connCfg, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
 		return nil, err
 	}
 
+	// pgx читает файлы сертификатов только при разборе строки подключения,
+	// поэтому, чтобы подхватить обновлённые сертификаты, она разбирается
+	// заново перед каждым новым соединением.
+	if cfg.SSLRootCert != "" || cfg.SSLCert != "" {
+		connCfg.BeforeConnect = func(_ context.Context, connConfig *pgx.ConnConfig) error {
+			fresh, perr := pgconn.ParseConfig(connStr)
+			if perr != nil {
+				return fmt.Errorf("failed to reload tls configuration: %w", perr)
+			}
+
+			connConfig.TLSConfig = fresh.TLSConfig
+			connConfig.Fallbacks = fresh.Fallbacks
+			return nil
+		}
+	}
+
 	if cfg.MaxOpenConns > 0 {
 		connCfg.MaxConns = int32(cfg.MaxOpenConns)
 	}
@@ -69,3 +93,73 @@ func New(ctx context.Context, cfg Configuration) (*pgxpool.Pool, error) {
 
 	return dbpool, fmt.Errorf("failed to ping database after %d retries: %w", cfg.ConnectionRetries, err)
 }
+
+// ConnString возвращает строку подключения в формате URL.
+func ConnString(cfg Configuration) string {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"sslmode":     cfg.SSLMode,
+		"sslrootcert": cfg.SSLRootCert,
+		"sslcert":     cfg.SSLCert,
+		"sslkey":      cfg.SSLKey,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.Name,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+// IsUnavailable сообщает, вызвана ли ошибка недоступностью базы данных:
+// ошибкой соединения, таймаутом или отказом сервера принимать подключения.
+// Ошибки выполнения запросов, например нарушения ограничений, сюда не относятся.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		// Класс 08 - connection exception.
+		case strings.HasPrefix(pgErr.Code, "08"):
+			return true
+		// admin_shutdown, crash_shutdown, cannot_connect_now, too_many_connections.
+		case pgErr.Code == "57P01", pgErr.Code == "57P02", pgErr.Code == "57P03", pgErr.Code == "53300":
+			return true
+		default:
+			return false
+		}
+	}
+
+	var connErr *pgconn.ConnectError
+	var netErr net.Error
+
+	return errors.As(err, &connErr) ||
+		errors.As(err, &netErr) ||
+		pgconn.Timeout(err) ||
+		pgconn.SafeToRetry(err) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsSerializationFailure сообщает, откатил ли сервер транзакцию из-за конфликта
+// с параллельной транзакцией: ошибки сериализации или взаимоблокировки.
+// Такую транзакцию можно повторить целиком.
+func IsSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	// serialization_failure, deadlock_detected.
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
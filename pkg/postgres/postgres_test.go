@@ -0,0 +1,78 @@
+//go:build unit_test
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnString(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Configuration
+		expected string
+	}{
+		{
+			name:     "Plain",
+			cfg:      Configuration{Host: "db", Port: 5432, User: "user", Password: "pass", Name: "orders"},
+			expected: "postgres://user:pass@db:5432/orders",
+		},
+		{
+			name: "SSL",
+			cfg: Configuration{
+				Host:        "db",
+				Port:        5432,
+				User:        "user",
+				Password:    "p@ss/word",
+				Name:        "orders",
+				SSLMode:     "verify-full",
+				SSLRootCert: "/certs/ca.pem",
+			},
+			expected: "postgres://user:p%40ss%2Fword@db:5432/orders?sslmode=verify-full&sslrootcert=%2Fcerts%2Fca.pem",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ConnString(tt.cfg))
+		})
+	}
+}
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "Nil", err: nil, expected: false},
+		{name: "ConnectionException", err: &pgconn.PgError{Code: "08006"}, expected: true},
+		{name: "AdminShutdown", err: fmt.Errorf("query: %w", &pgconn.PgError{Code: "57P01"}), expected: true},
+		{name: "UniqueViolation", err: &pgconn.PgError{Code: "23505"}, expected: false},
+		{name: "Timeout", err: fmt.Errorf("query: %w", context.DeadlineExceeded), expected: true},
+		{name: "Network", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expected: true},
+		{name: "NoRows", err: pgx.ErrNoRows, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsUnavailable(tt.err))
+		})
+	}
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	assert.True(t, IsSerializationFailure(fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40001"})))
+	assert.True(t, IsSerializationFailure(&pgconn.PgError{Code: "40P01"}))
+	assert.False(t, IsSerializationFailure(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsSerializationFailure(context.DeadlineExceeded))
+}
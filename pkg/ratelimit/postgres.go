@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore хранит bucket'ы в таблице rate_limit_buckets, поэтому лимиты
+// общие для всех реплик, подключённых к одной базе:
+//
+//	CREATE TABLE rate_limit_buckets (
+//	  key TEXT PRIMARY KEY,
+//	  tokens DOUBLE PRECISION NOT NULL,
+//	  updated_at TIMESTAMPTZ NOT NULL
+//	);
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+// createBucketQuery создаёт полный bucket, если его ещё нет. Строка должна
+// существовать до takeQuery: FOR UPDATE не блокирует отсутствующую строку,
+// и одновременные первые запросы по ключу не видели бы изменений друг друга.
+const createBucketQuery = `
+INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+VALUES ($1, $2, now())
+ON CONFLICT (key) DO NOTHING`
+
+// takeQuery пополняет bucket по времени базы данных и забирает токен под
+// блокировкой строки, поэтому одновременные запросы с разных реплик не теряют
+// обновлений.
+const takeQuery = `
+WITH current AS (
+  SELECT LEAST($3::DOUBLE PRECISION,
+    tokens + EXTRACT(EPOCH FROM (now() - updated_at))::DOUBLE PRECISION * $2::DOUBLE PRECISION
+  ) AS tokens
+  FROM rate_limit_buckets WHERE key = $1 FOR UPDATE
+), taken AS (
+  UPDATE rate_limit_buckets
+  SET tokens = CASE WHEN current.tokens >= 1 THEN current.tokens - 1 ELSE current.tokens END, updated_at = now()
+  FROM current
+  WHERE rate_limit_buckets.key = $1
+)
+SELECT tokens FROM current`
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	// Оба запроса отправляются за одно обращение к базе и выполняются
+	// в одной неявной транзакции.
+	batch := &pgx.Batch{}
+	batch.Queue(createBucketQuery, key, float64(limit.Burst))
+	batch.Queue(takeQuery, key, limit.Rate, float64(limit.Burst))
+
+	results := s.pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	if _, err := results.Exec(); err != nil {
+		return false, 0, err
+	}
+
+	var tokens float64
+	if err := results.QueryRow().Scan(&tokens); err != nil {
+		return false, 0, err
+	}
+
+	if tokens < 1 {
+		return false, retryAfter(tokens, limit), nil
+	}
+
+	return true, 0, nil
+}
@@ -0,0 +1,128 @@
+// Package ratelimit ограничивает частоту и число одновременных запросов
+// по ключу (пользователь и метод) алгоритмом token bucket.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRateLimited       = errors.New("rate limit exceeded")
+	ErrTooManyConcurrent = errors.New("too many concurrent requests")
+)
+
+// LimitError возвращается при превышении лимита. RetryAfter - через сколько
+// имеет смысл повторить запрос.
+type LimitError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", e.Err, e.RetryAfter)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// Limit - параметры token bucket: Rate токенов в секунду, не больше Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Store хранит состояние token bucket'ов. Take забирает токен из bucket'а
+// key и при отказе возвращает время до появления следующего токена.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// Rule - ограничения для метода. Нулевой Limit.Rate отключает ограничение
+// частоты, нулевой MaxConcurrent - ограничение одновременных запросов.
+type Rule struct {
+	Method        string
+	Limit         Limit
+	MaxConcurrent int
+}
+
+// Limiter применяет правила к запросам. Частота запросов учитывается в Store
+// и может быть общей для нескольких реплик, число одновременных запросов -
+// только в пределах процесса.
+type Limiter struct {
+	store Store
+	rules map[string]Rule
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+func NewLimiter(store Store, rules []Rule) *Limiter {
+	l := &Limiter{
+		store:    store,
+		rules:    make(map[string]Rule, len(rules)),
+		inFlight: make(map[string]int),
+	}
+	for _, rule := range rules {
+		l.rules[rule.Method] = rule
+	}
+
+	return l
+}
+
+// Acquire проверяет лимиты для запроса subject к method. При успехе
+// возвращает функцию, которую нужно вызвать по завершении запроса.
+func (l *Limiter) Acquire(ctx context.Context, method, subject string) (func(), error) {
+	rule, ok := l.rules[method]
+	if !ok {
+		return func() {}, nil
+	}
+
+	key := method + "|" + subject
+
+	if rule.MaxConcurrent > 0 {
+		l.mu.Lock()
+		if l.inFlight[key] >= rule.MaxConcurrent {
+			l.mu.Unlock()
+			return nil, &LimitError{Err: ErrTooManyConcurrent, RetryAfter: time.Second}
+		}
+		l.inFlight[key]++
+		l.mu.Unlock()
+	}
+
+	release := func() {
+		if rule.MaxConcurrent <= 0 {
+			return
+		}
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if l.inFlight[key]--; l.inFlight[key] <= 0 {
+			delete(l.inFlight, key)
+		}
+	}
+
+	if rule.Limit.Rate > 0 {
+		allowed, retryAfter, err := l.store.Take(ctx, key, rule.Limit)
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("failed to check rate limit: %w", err)
+		}
+		if !allowed {
+			release()
+			return nil, &LimitError{Err: ErrRateLimited, RetryAfter: retryAfter}
+		}
+	}
+
+	return release, nil
+}
+
+// retryAfter возвращает время, за которое в bucket'е наберётся целый токен.
+func retryAfter(tokens float64, limit Limit) time.Duration {
+	return time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+}
@@ -0,0 +1,110 @@
+//go:build unit_test
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	limit := Limit{Rate: 2, Burst: 3}
+	for i := 0; i < 3; i++ {
+		allowed, _, err := store.Take(context.Background(), "key", limit)
+		require.NoError(t, err)
+		assert.True(t, allowed, "request %d must fit into burst", i)
+	}
+
+	allowed, retryAfter, err := store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	allowed, _, err = store.Take(context.Background(), "other", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed, "buckets must be independent")
+
+	now = now.Add(500 * time.Millisecond)
+	allowed, _, err = store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed, "token must be refilled")
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		allowed, _, err = store.Take(context.Background(), "key", limit)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, _, err = store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed, "bucket must not exceed burst")
+}
+
+func TestMemoryStore_EvictsFullBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	limit := Limit{Rate: 1, Burst: 2}
+	for _, key := range []string{"idle", "busy"} {
+		_, _, err := store.Take(context.Background(), key, limit)
+		require.NoError(t, err)
+	}
+
+	// Bucket "idle" успевает наполниться, "busy" расходуется перед очисткой.
+	now = now.Add(sweepInterval - time.Second)
+	for i := 0; i < 2; i++ {
+		_, _, err := store.Take(context.Background(), "busy", limit)
+		require.NoError(t, err)
+	}
+
+	now = now.Add(time.Second)
+	allowed, _, err := store.Take(context.Background(), "new", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	assert.NotContains(t, store.buckets, "idle")
+	assert.Contains(t, store.buckets, "busy")
+}
+
+func TestLimiter(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), []Rule{
+		{Method: "/order.Order/CreateOrder", Limit: Limit{Rate: 0.001, Burst: 2}, MaxConcurrent: 1},
+	})
+	ctx := context.Background()
+
+	release, err := limiter.Acquire(ctx, "/order.Order/CreateOrder", "user:1")
+	require.NoError(t, err)
+
+	_, err = limiter.Acquire(ctx, "/order.Order/CreateOrder", "user:1")
+	assert.ErrorIs(t, err, ErrTooManyConcurrent)
+
+	otherRelease, err := limiter.Acquire(ctx, "/order.Order/CreateOrder", "user:2")
+	require.NoError(t, err, "limits must be tracked per subject")
+	otherRelease()
+
+	release()
+	release, err = limiter.Acquire(ctx, "/order.Order/CreateOrder", "user:1")
+	require.NoError(t, err)
+	release()
+
+	_, err = limiter.Acquire(ctx, "/order.Order/CreateOrder", "user:1")
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Greater(t, limitErr.RetryAfter, time.Minute)
+
+	for i := 0; i < 10; i++ {
+		release, err = limiter.Acquire(ctx, "/order.Order/GetOrder", "user:1")
+		require.NoError(t, err, "methods without rules must not be limited")
+		release()
+	}
+}
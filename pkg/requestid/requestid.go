@@ -0,0 +1,76 @@
+// Package requestid передаёт идентификатор запроса через контекст, чтобы
+// все записи журнала, относящиеся к одному запросу или сообщению, можно
+// было связать между собой.
+package requestid
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MetadataKey - ключ идентификатора в метаданных GRPC и заголовках сообщений Kafka.
+	MetadataKey = "x-request-id"
+	// LogKey - имя атрибута с идентификатором в записях журнала.
+	LogKey = "request_id"
+
+	maxLength = 128
+)
+
+type contextKey struct{}
+
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid сообщает, можно ли использовать полученный извне идентификатор.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok
+}
+
+// LogHandler добавляет идентификатор запроса из контекста к записям журнала,
+// сделанным через методы slog.Logger с контекстом (InfoContext и т.п.).
+type LogHandler struct {
+	slog.Handler
+}
+
+func NewLogHandler(handler slog.Handler) *LogHandler {
+	return &LogHandler{Handler: handler}
+}
+
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id, ok := FromContext(ctx); ok {
+		record.AddAttrs(slog.String(LogKey, id))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
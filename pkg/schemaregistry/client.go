@@ -0,0 +1,206 @@
+// Package schemaregistry реализует клиент Confluent-совместимого реестра схем
+// и формат сообщений с идентификатором схемы в префиксе.
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SchemaType string
+
+const (
+	SchemaTypeProtobuf SchemaType = "PROTOBUF"
+	SchemaTypeJSON     SchemaType = "JSON"
+	SchemaTypeAvro     SchemaType = "AVRO"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+var ErrNotFound = errors.New("schema or subject not found")
+
+type Schema struct {
+	Schema     string     `json:"schema"`
+	SchemaType SchemaType `json:"schemaType,omitempty"`
+}
+
+// Error - ошибка, возвращённая реестром.
+type Error struct {
+	StatusCode int
+	Code       int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("schema registry error %d: %s", e.Code, e.Message)
+}
+
+type Configuration struct {
+	URL      string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// Client кэширует схемы по идентификатору и идентификаторы зарегистрированных
+// схем, поэтому повторные обращения к реестру не выполняются.
+type Client struct {
+	baseURL  string
+	username string
+	password string
+	http     *http.Client
+
+	mu         sync.RWMutex
+	schemas    map[int]Schema
+	registered map[string]int
+}
+
+func NewClient(cfg Configuration) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(cfg.URL, "/"),
+		username:   cfg.Username,
+		password:   cfg.Password,
+		http:       &http.Client{Timeout: cfg.Timeout},
+		schemas:    make(map[int]Schema),
+		registered: make(map[string]int),
+	}
+}
+
+// Register регистрирует схему в subject'е и возвращает её идентификатор.
+// Повторная регистрация уже существующей схемы возвращает прежний идентификатор.
+func (c *Client) Register(ctx context.Context, subject string, schema Schema) (int, error) {
+	key := registeredKey(subject, schema)
+
+	c.mu.RLock()
+	id, ok := c.registered[key]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	var resp struct {
+		ID int `json:"id"`
+	}
+	path := fmt.Sprintf("/subjects/%s/versions", url.PathEscape(subject))
+	if err := c.do(ctx, http.MethodPost, path, normalize(schema), &resp); err != nil {
+		return 0, fmt.Errorf("failed to register schema for subject %q: %w", subject, err)
+	}
+
+	c.mu.Lock()
+	c.registered[key] = resp.ID
+	c.schemas[resp.ID] = normalize(schema)
+	c.mu.Unlock()
+
+	return resp.ID, nil
+}
+
+func (c *Client) SchemaByID(ctx context.Context, id int) (Schema, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &schema); err != nil {
+		return schema, fmt.Errorf("failed to get schema with id %d: %w", id, err)
+	}
+	schema = normalize(schema)
+
+	c.mu.Lock()
+	c.schemas[id] = schema
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+// CheckCompatibility проверяет совместимость схемы с последней версией subject'а
+// согласно настроенному в реестре уровню совместимости. Если subject ещё
+// не существует, схема считается совместимой.
+func (c *Client) CheckCompatibility(ctx context.Context, subject string, schema Schema) (bool, []string, error) {
+	var resp struct {
+		IsCompatible bool     `json:"is_compatible"`
+		Messages     []string `json:"messages"`
+	}
+
+	path := fmt.Sprintf("/compatibility/subjects/%s/versions/latest?verbose=true", url.PathEscape(subject))
+	err := c.do(ctx, http.MethodPost, path, normalize(schema), &resp)
+	if errors.Is(err, ErrNotFound) {
+		return true, nil, nil
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to check compatibility for subject %q: %w", subject, err)
+	}
+
+	return resp.IsCompatible, resp.Messages, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		regErr := &Error{StatusCode: resp.StatusCode}
+		_ = json.NewDecoder(resp.Body).Decode(regErr)
+
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %w", ErrNotFound, regErr)
+		}
+
+		return regErr
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// normalize приводит тип схемы к виду, в котором его хранит реестр:
+// отсутствие типа означает Avro.
+func normalize(schema Schema) Schema {
+	if schema.SchemaType == "" {
+		schema.SchemaType = SchemaTypeAvro
+	}
+
+	return schema
+}
+
+func registeredKey(subject string, schema Schema) string {
+	schema = normalize(schema)
+	return subject + "\x00" + string(schema.SchemaType) + "\x00" + schema.Schema
+}
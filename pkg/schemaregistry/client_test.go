@@ -0,0 +1,140 @@
+//go:build unit_test
+
+package schemaregistry_test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/pkg/schemaregistry"
+	"github.com/hickar/crtex_test_assignment/pkg/schemaregistry/registrytest"
+)
+
+func TestClientRegisterCaches(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	client := schemaregistry.NewClient(schemaregistry.Configuration{URL: server.URL})
+	schema := schemaregistry.Schema{Schema: `syntax = "proto3";`, SchemaType: schemaregistry.SchemaTypeProtobuf}
+
+	id, err := client.Register(context.Background(), "events.Test", schema)
+	require.NoError(t, err)
+
+	again, err := client.Register(context.Background(), "events.Test", schema)
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+
+	fetched, err := client.SchemaByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, schema, fetched)
+	assert.Equal(t, 1, server.Requests())
+}
+
+func TestClientSchemaByID(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	producer := schemaregistry.NewClient(schemaregistry.Configuration{URL: server.URL})
+	id, err := producer.Register(context.Background(), "events.Test", schemaregistry.Schema{Schema: `{"type":"object"}`, SchemaType: schemaregistry.SchemaTypeJSON})
+	require.NoError(t, err)
+
+	consumer := schemaregistry.NewClient(schemaregistry.Configuration{URL: server.URL})
+	for i := 0; i < 3; i++ {
+		schema, err := consumer.SchemaByID(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, schemaregistry.SchemaTypeJSON, schema.SchemaType)
+	}
+	assert.Equal(t, 2, server.Requests())
+
+	_, err = consumer.SchemaByID(context.Background(), 100)
+	assert.ErrorIs(t, err, schemaregistry.ErrNotFound)
+
+	var regErr *schemaregistry.Error
+	require.ErrorAs(t, err, &regErr)
+	assert.Equal(t, 40403, regErr.Code)
+}
+
+func TestClientCheckCompatibility(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	client := schemaregistry.NewClient(schemaregistry.Configuration{URL: server.URL})
+	schema := schemaregistry.Schema{Schema: "v1", SchemaType: schemaregistry.SchemaTypeProtobuf}
+
+	compatible, _, err := client.CheckCompatibility(context.Background(), "events.Test", schema)
+	require.NoError(t, err)
+	assert.True(t, compatible, "schema for new subject must be compatible")
+
+	_, err = client.Register(context.Background(), "events.Test", schema)
+	require.NoError(t, err)
+
+	server.SetCompatibility(func(latest, candidate schemaregistry.Schema) (bool, []string) {
+		return false, []string{latest.Schema + " -> " + candidate.Schema}
+	})
+
+	compatible, messages, err := client.CheckCompatibility(context.Background(), "events.Test", schemaregistry.Schema{Schema: "v2", SchemaType: schemaregistry.SchemaTypeProtobuf})
+	require.NoError(t, err)
+	assert.False(t, compatible)
+	assert.Equal(t, []string{"v1 -> v2"}, messages)
+}
+
+func TestMessageIndexes(t *testing.T) {
+	tests := []struct {
+		name    string
+		indexes []int
+		encoded []byte
+	}{
+		{name: "First", indexes: []int{0}, encoded: []byte{0}},
+		{name: "Second", indexes: []int{1}, encoded: []byte{2, 2}},
+		{name: "Nested", indexes: []int{1, 0}, encoded: []byte{4, 2, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := schemaregistry.AppendMessageIndexes(nil, tt.indexes)
+			assert.Equal(t, tt.encoded, value)
+
+			indexes, rest, err := schemaregistry.ParseMessageIndexes(append(value, 0xff))
+			require.NoError(t, err)
+			assert.Equal(t, tt.indexes, indexes)
+			assert.Equal(t, []byte{0xff}, rest)
+		})
+	}
+}
+
+func TestMessageIndexes_Malformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded []byte
+	}{
+		{name: "Empty", encoded: nil},
+		{name: "NegativeCount", encoded: []byte{1}},
+		{name: "Truncated", encoded: []byte{4, 2}},
+		{name: "HugeCount", encoded: binary.AppendVarint(nil, 1<<62)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := schemaregistry.ParseMessageIndexes(tt.encoded)
+			assert.ErrorIs(t, err, schemaregistry.ErrInvalidWireFormat)
+		})
+	}
+}
+
+func TestHeader(t *testing.T) {
+	value := schemaregistry.AppendHeader(nil, 258)
+	assert.Equal(t, []byte{0, 0, 0, 1, 2}, value)
+	assert.True(t, schemaregistry.IsFramed(value))
+
+	id, rest, err := schemaregistry.ParseHeader(append(value, 'x'))
+	require.NoError(t, err)
+	assert.Equal(t, 258, id)
+	assert.Equal(t, []byte("x"), rest)
+
+	_, _, err = schemaregistry.ParseHeader([]byte(`{"payload":{}}`))
+	assert.ErrorIs(t, err, schemaregistry.ErrInvalidWireFormat)
+}
@@ -0,0 +1,144 @@
+// Package registrytest реализует заглушку реестра схем поверх httptest.Server.
+package registrytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hickar/crtex_test_assignment/pkg/schemaregistry"
+)
+
+// CompatibilityFunc решает, совместима ли новая схема с последней версией subject'а.
+type CompatibilityFunc func(latest, candidate schemaregistry.Schema) (bool, []string)
+
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	schemas       []schemaregistry.Schema
+	subjects      map[string][]int
+	requests      int
+	compatibility CompatibilityFunc
+}
+
+// NewServer запускает заглушку, в которой любая схема совместима с предыдущей.
+func NewServer() *Server {
+	s := &Server{
+		subjects: make(map[string][]int),
+		compatibility: func(_, _ schemaregistry.Schema) (bool, []string) {
+			return true, nil
+		},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+func (s *Server) SetCompatibility(fn CompatibilityFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.compatibility = fn
+}
+
+// Requests возвращает количество обработанных запросов.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "subjects" && parts[2] == "versions":
+		s.register(w, r, parts[1])
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "schemas" && parts[1] == "ids":
+		s.schemaByID(w, parts[2])
+	case r.Method == http.MethodPost && len(parts) == 5 && parts[0] == "compatibility" && parts[4] == "latest":
+		s.checkCompatibility(w, r, parts[2])
+	default:
+		writeError(w, http.StatusNotFound, 404, "HTTP 404 Not Found")
+	}
+}
+
+func (s *Server) register(w http.ResponseWriter, r *http.Request, subject string) {
+	var schema schemaregistry.Schema
+	if err := json.NewDecoder(r.Body).Decode(&schema); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, 42201, "Invalid schema")
+		return
+	}
+
+	id := -1
+	for i, existing := range s.schemas {
+		if existing == schema {
+			id = i + 1
+			break
+		}
+	}
+	if id < 0 {
+		s.schemas = append(s.schemas, schema)
+		id = len(s.schemas)
+	}
+
+	versions := s.subjects[subject]
+	if len(versions) == 0 || versions[len(versions)-1] != id {
+		s.subjects[subject] = append(versions, id)
+	}
+
+	writeJSON(w, map[string]int{"id": id})
+}
+
+func (s *Server) schemaByID(w http.ResponseWriter, rawID string) {
+	id, err := strconv.Atoi(rawID)
+	if err != nil || id < 1 || id > len(s.schemas) {
+		writeError(w, http.StatusNotFound, 40403, "Schema not found")
+		return
+	}
+
+	writeJSON(w, s.schemas[id-1])
+}
+
+func (s *Server) checkCompatibility(w http.ResponseWriter, r *http.Request, subject string) {
+	versions := s.subjects[subject]
+	if len(versions) == 0 {
+		writeError(w, http.StatusNotFound, 40401, "Subject not found")
+		return
+	}
+
+	var schema schemaregistry.Schema
+	if err := json.NewDecoder(r.Body).Decode(&schema); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, 42201, "Invalid schema")
+		return
+	}
+
+	compatible, messages := s.compatibility(s.schemas[versions[len(versions)-1]-1], schema)
+	writeJSON(w, map[string]any{
+		"is_compatible": compatible,
+		"messages":      messages,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error_code": code,
+		"message":    message,
+	})
+}
@@ -0,0 +1,80 @@
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// magicByte открывает каждое сообщение в формате Confluent. За ним следует
+// идентификатор схемы (4 байта, big-endian), а для Protobuf - ещё и индексы
+// сообщения внутри .proto файла.
+const magicByte = 0x0
+
+const headerSize = 5
+
+var ErrInvalidWireFormat = errors.New("invalid schema registry wire format")
+
+// IsFramed сообщает, начинается ли значение с префикса формата Confluent.
+// Ни JSON, ни корректно закодированный Protobuf не могут начинаться с нулевого байта.
+func IsFramed(data []byte) bool {
+	return len(data) >= headerSize && data[0] == magicByte
+}
+
+func AppendHeader(dst []byte, schemaID int) []byte {
+	dst = append(dst, magicByte)
+	return binary.BigEndian.AppendUint32(dst, uint32(schemaID))
+}
+
+func ParseHeader(data []byte) (int, []byte, error) {
+	if !IsFramed(data) {
+		return 0, nil, ErrInvalidWireFormat
+	}
+
+	return int(binary.BigEndian.Uint32(data[1:headerSize])), data[headerSize:], nil
+}
+
+// AppendMessageIndexes добавляет путь к сообщению в .proto файле. Путь [0]
+// (первое сообщение верхнего уровня) кодируется одним нулевым байтом.
+func AppendMessageIndexes(dst []byte, indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return append(dst, 0)
+	}
+
+	dst = binary.AppendVarint(dst, int64(len(indexes)))
+	for _, index := range indexes {
+		dst = binary.AppendVarint(dst, int64(index))
+	}
+
+	return dst
+}
+
+func ParseMessageIndexes(data []byte) ([]int, []byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 {
+		return nil, nil, fmt.Errorf("%w: malformed message indexes", ErrInvalidWireFormat)
+	}
+	data = data[n:]
+
+	if count == 0 {
+		return []int{0}, data, nil
+	}
+	// Каждый индекс занимает хотя бы байт, поэтому больший count заведомо
+	// некорректен и не должен влиять на размер выделяемой памяти.
+	if count > int64(len(data)) {
+		return nil, nil, fmt.Errorf("%w: malformed message indexes", ErrInvalidWireFormat)
+	}
+
+	indexes := make([]int, 0, count)
+	for i := int64(0); i < count; i++ {
+		index, n := binary.Varint(data)
+		if n <= 0 {
+			return nil, nil, fmt.Errorf("%w: malformed message indexes", ErrInvalidWireFormat)
+		}
+
+		indexes = append(indexes, int(index))
+		data = data[n:]
+	}
+
+	return indexes, data, nil
+}
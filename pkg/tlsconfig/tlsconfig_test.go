@@ -0,0 +1,262 @@
+//go:build unit_test
+
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t, "ca")
+	ca.writeCA(t, filepath.Join(dir, "ca.pem"))
+	ca.issue(t, "localhost", filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+	ca.issue(t, "client", filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key"))
+
+	serverCfg, err := NewServerConfig(Configuration{
+		CertFile: filepath.Join(dir, "server.pem"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+	})
+	require.NoError(t, err)
+
+	clientCfg, err := NewClientConfig(Configuration{
+		CertFile:   filepath.Join(dir, "client.pem"),
+		KeyFile:    filepath.Join(dir, "client.key"),
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		ServerName: "localhost",
+	})
+	require.NoError(t, err)
+	assert.NoError(t, handshake(t, serverCfg, clientCfg))
+
+	noCertCfg, err := NewClientConfig(Configuration{CAFile: filepath.Join(dir, "ca.pem"), ServerName: "localhost"})
+	require.NoError(t, err)
+	assert.Error(t, handshake(t, serverCfg, noCertCfg), "server must require client certificate")
+
+	newCA(t, "other").issue(t, "client", filepath.Join(dir, "other.pem"), filepath.Join(dir, "other.key"))
+	otherCACfg, err := NewClientConfig(Configuration{
+		CertFile:   filepath.Join(dir, "other.pem"),
+		KeyFile:    filepath.Join(dir, "other.key"),
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		ServerName: "localhost",
+	})
+	require.NoError(t, err)
+	assert.Error(t, handshake(t, serverCfg, otherCACfg), "server must verify client certificate")
+
+	wrongNameCfg, err := NewClientConfig(Configuration{
+		CertFile:   filepath.Join(dir, "client.pem"),
+		KeyFile:    filepath.Join(dir, "client.key"),
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		ServerName: "example.com",
+	})
+	require.NoError(t, err)
+	assert.Error(t, handshake(t, serverCfg, wrongNameCfg), "client must verify server name")
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	certPath, keyPath := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")
+
+	oldCA := newCA(t, "old")
+	oldCA.writeCA(t, caPath)
+	oldCA.issue(t, "localhost", certPath, keyPath)
+
+	serverCfg, err := NewServerConfig(Configuration{CertFile: certPath, KeyFile: keyPath, ReloadInterval: time.Nanosecond})
+	require.NoError(t, err)
+	clientCfg, err := NewClientConfig(Configuration{CAFile: caPath, ServerName: "localhost", ReloadInterval: time.Nanosecond})
+	require.NoError(t, err)
+	require.NoError(t, handshake(t, serverCfg, clientCfg))
+
+	// Сервер перевыпускает сертификат у нового CA, клиент ещё доверяет старому.
+	newCA := newCA(t, "new")
+	newCA.issue(t, "localhost", certPath, keyPath)
+	touch(t, certPath, keyPath)
+	assert.Error(t, handshake(t, serverCfg, clientCfg))
+
+	newCA.writeCA(t, caPath)
+	touch(t, caPath)
+	assert.NoError(t, handshake(t, serverCfg, clientCfg))
+
+	// Повреждённые файлы не должны ломать уже загруженную конфигурацию.
+	require.NoError(t, os.WriteFile(certPath, []byte("broken"), 0o600))
+	touch(t, certPath)
+	assert.NoError(t, handshake(t, serverCfg, clientCfg))
+}
+
+func TestServerConfigKeepsNextProtos(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t, "ca")
+	ca.writeCA(t, filepath.Join(dir, "ca.pem"))
+	ca.issue(t, "localhost", filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+	ca.issue(t, "client", filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key"))
+
+	serverCfg, err := NewServerConfig(Configuration{
+		CertFile: filepath.Join(dir, "server.pem"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+	})
+	require.NoError(t, err)
+	clientCfg, err := NewClientConfig(Configuration{
+		CertFile:   filepath.Join(dir, "client.pem"),
+		KeyFile:    filepath.Join(dir, "client.key"),
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		ServerName: "localhost",
+	})
+	require.NoError(t, err)
+
+	// Так конфигурацию дополняет credentials.NewTLS.
+	serverCfg = serverCfg.Clone()
+	serverCfg.NextProtos = []string{"h2"}
+	clientCfg.NextProtos = []string{"h2"}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	go func() {
+		conn, aerr := ln.Accept()
+		if aerr != nil {
+			return
+		}
+		defer conn.Close()
+
+		_ = conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientCfg)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, "h2", conn.ConnectionState().NegotiatedProtocol)
+}
+
+func TestNewServerConfig_NoCertificate(t *testing.T) {
+	_, err := NewServerConfig(Configuration{CAFile: "ca.pem"})
+	assert.ErrorIs(t, err, ErrNoCertificate)
+}
+
+// handshake устанавливает соединение и обменивается одним байтом, чтобы
+// при TLS 1.3 ошибка проверки сертификата клиента дошла до клиента.
+func handshake(t *testing.T, serverCfg, clientCfg *tls.Config) error {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	go func() {
+		conn, aerr := ln.Accept()
+		if aerr != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, _ = conn.Write([]byte{1})
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientCfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	return err
+}
+
+var modTime = time.Now()
+
+// touch сдвигает время изменения файлов, чтобы изменения гарантированно
+// обнаруживались независимо от точности времени файловой системы.
+func touch(t *testing.T, paths ...string) {
+	t.Helper()
+
+	modTime = modTime.Add(time.Second)
+	for _, path := range paths {
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newCA(t *testing.T, name string) testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return testCA{cert: cert, key: key}
+}
+
+func (ca testCA) writeCA(t *testing.T, path string) {
+	t.Helper()
+
+	writePEM(t, path, "CERTIFICATE", ca.cert.Raw)
+}
+
+func (ca testCA) issue(t *testing.T, name, certPath, keyPath string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	writePEM(t, certPath, "CERTIFICATE", der)
+	writePEM(t, keyPath, "EC PRIVATE KEY", keyDER)
+}
+
+func writePEM(t *testing.T, path, blockType string, data []byte) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0o600))
+}
@@ -0,0 +1,2 @@
+// Package testpb содержит сообщения с ограничениями validation.rules для тестов.
+package testpb
@@ -0,0 +1,191 @@
+// Package validation проверяет сообщения protobuf по ограничениям, заданным
+// в опциях полей (validation.rules), см. proto/validation.proto.
+package validation
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	validationpb "github.com/hickar/crtex_test_assignment/pkg/validation/proto"
+)
+
+// Violation - нарушение ограничения поля. Field - путь к полю из имён полей
+// protobuf через точку с индексами элементов списков, например "items[1].sku".
+type Violation struct {
+	Field       string
+	Description string
+}
+
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.Field+": "+v.Description)
+	}
+
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+// Validate проверяет сообщение и все вложенные сообщения. При нарушениях
+// возвращает *Error со всеми найденными нарушениями.
+func Validate(msg proto.Message) error {
+	var violations []Violation
+	validateMessage(msg.ProtoReflect(), "", &violations)
+
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+
+	return nil
+}
+
+func validateMessage(m protoreflect.Message, prefix string, violations *[]Violation) {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := prefix + string(fd.Name())
+		rules, _ := proto.GetExtension(fd.Options(), validationpb.E_Rules).(*validationpb.FieldRules)
+
+		switch {
+		case fd.IsList():
+			list := m.Get(fd).List()
+			validateList(list.Len(), rules, path, violations)
+			for j := 0; j < list.Len(); j++ {
+				validateValue(fd, list.Get(j), rules, fmt.Sprintf("%s[%d]", path, j), violations)
+			}
+		case fd.IsMap():
+			if rules.GetRequired() && m.Get(fd).Map().Len() == 0 {
+				addViolation(violations, path, "is required")
+			}
+			m.Get(fd).Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+				validateValue(fd.MapValue(), value, nil, fmt.Sprintf("%s[%v]", path, key.Interface()), violations)
+				return true
+			})
+		case !m.Has(fd):
+			if rules.GetRequired() {
+				addViolation(violations, path, "is required")
+				continue
+			}
+			// Незаданные скалярные поля проверяются как нулевые значения,
+			// иначе ограничение gt: 0 пропускало бы отсутствующее поле.
+			if fd.Message() == nil {
+				validateValue(fd, m.Get(fd), rules, path, violations)
+			}
+		default:
+			validateValue(fd, m.Get(fd), rules, path, violations)
+		}
+	}
+}
+
+func validateValue(
+	fd protoreflect.FieldDescriptor,
+	value protoreflect.Value,
+	rules *validationpb.FieldRules,
+	path string,
+	violations *[]Violation,
+) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		validateMessage(value.Message(), path+".", violations)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		validateInt(value.Int(), rules.GetInt(), path, violations)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v := value.Uint()
+		if v > math.MaxInt64 {
+			v = math.MaxInt64
+		}
+		validateInt(int64(v), rules.GetInt(), path, violations)
+	case protoreflect.StringKind:
+		validateString(value.String(), rules.GetString_(), path, violations)
+	}
+}
+
+func validateInt(v int64, rules *validationpb.IntRules, path string, violations *[]Violation) {
+	if rules == nil {
+		return
+	}
+
+	if rules.Gt != nil && v <= rules.GetGt() {
+		addViolation(violations, path, fmt.Sprintf("must be greater than %d", rules.GetGt()))
+	}
+	if rules.Gte != nil && v < rules.GetGte() {
+		addViolation(violations, path, fmt.Sprintf("must be greater than or equal to %d", rules.GetGte()))
+	}
+	if rules.Lt != nil && v >= rules.GetLt() {
+		addViolation(violations, path, fmt.Sprintf("must be less than %d", rules.GetLt()))
+	}
+	if rules.Lte != nil && v > rules.GetLte() {
+		addViolation(violations, path, fmt.Sprintf("must be less than or equal to %d", rules.GetLte()))
+	}
+}
+
+func validateString(v string, rules *validationpb.StringRules, path string, violations *[]Violation) {
+	if rules == nil {
+		return
+	}
+
+	length := uint64(utf8.RuneCountInString(v))
+	if rules.MinLen != nil && length < rules.GetMinLen() {
+		addViolation(violations, path, fmt.Sprintf("must be at least %d characters long", rules.GetMinLen()))
+	}
+	if rules.MaxLen != nil && length > rules.GetMaxLen() {
+		addViolation(violations, path, fmt.Sprintf("must be at most %d characters long", rules.GetMaxLen()))
+	}
+	if rules.Pattern != nil {
+		re, err := compilePattern(rules.GetPattern())
+		if err != nil {
+			addViolation(violations, path, fmt.Sprintf("has invalid validation pattern: %s", err))
+		} else if !re.MatchString(v) {
+			addViolation(violations, path, fmt.Sprintf("must match pattern %q", rules.GetPattern()))
+		}
+	}
+}
+
+func validateList(length int, rules *validationpb.FieldRules, path string, violations *[]Violation) {
+	if rules.GetRequired() && length == 0 {
+		addViolation(violations, path, "is required")
+	}
+
+	repeated := rules.GetRepeated()
+	if repeated == nil {
+		return
+	}
+
+	if repeated.MinItems != nil && uint64(length) < repeated.GetMinItems() {
+		addViolation(violations, path, fmt.Sprintf("must contain at least %d items", repeated.GetMinItems()))
+	}
+	if repeated.MaxItems != nil && uint64(length) > repeated.GetMaxItems() {
+		addViolation(violations, path, fmt.Sprintf("must contain at most %d items", repeated.GetMaxItems()))
+	}
+}
+
+func addViolation(violations *[]Violation, field, description string) {
+	*violations = append(*violations, Violation{Field: field, Description: description})
+}
+
+var patterns sync.Map
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+
+	return re, nil
+}
@@ -0,0 +1,115 @@
+//go:build unit_test
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hickar/crtex_test_assignment/pkg/validation/testpb"
+)
+
+func validRequest() *testpb.Request {
+	return &testpb.Request{
+		Name:    "order",
+		Amount:  100,
+		Items:   []*testpb.Item{{Sku: "A-1", Quantity: 2}},
+		Primary: &testpb.Item{Sku: "B-2", Quantity: 1},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(req *testpb.Request)
+		violations []Violation
+	}{
+		{
+			name:   "valid",
+			modify: func(*testpb.Request) {},
+		},
+		{
+			name: "missing required fields",
+			modify: func(req *testpb.Request) {
+				req.Name = ""
+				req.Primary = nil
+			},
+			violations: []Violation{
+				{Field: "name", Description: "is required"},
+				{Field: "primary", Description: "is required"},
+			},
+		},
+		{
+			name:   "unset scalar is checked as zero value",
+			modify: func(req *testpb.Request) { req.Amount = 0 },
+			violations: []Violation{
+				{Field: "amount", Description: "must be greater than or equal to 1"},
+			},
+		},
+		{
+			name:   "int upper bound",
+			modify: func(req *testpb.Request) { req.Amount = 1001 },
+			violations: []Violation{
+				{Field: "amount", Description: "must be less than or equal to 1000"},
+			},
+		},
+		{
+			name:   "too few items",
+			modify: func(req *testpb.Request) { req.Items = nil },
+			violations: []Violation{
+				{Field: "items", Description: "must contain at least 1 items"},
+			},
+		},
+		{
+			name: "too many items",
+			modify: func(req *testpb.Request) {
+				for i := 0; i < 3; i++ {
+					req.Items = append(req.Items, &testpb.Item{Sku: "C", Quantity: 1})
+				}
+			},
+			violations: []Violation{
+				{Field: "items", Description: "must contain at most 3 items"},
+			},
+		},
+		{
+			name: "nested messages",
+			modify: func(req *testpb.Request) {
+				req.Items = append(req.Items, &testpb.Item{Sku: "lower", Quantity: 100})
+				req.Primary.Sku = "TOO-LONG-SKU"
+			},
+			violations: []Violation{
+				{Field: "items[1].sku", Description: `must match pattern "^[A-Z0-9-]+$"`},
+				{Field: "items[1].quantity", Description: "must be less than 100"},
+				{Field: "primary.sku", Description: "must be at most 8 characters long"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			tt.modify(req)
+
+			err := Validate(req)
+			if tt.violations == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var validationErr *Error
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.violations, validationErr.Violations)
+		})
+	}
+}
+
+func TestValidateErrorMessage(t *testing.T) {
+	require.NoError(t, Validate(&testpb.Item{Sku: "A", Quantity: 1}))
+
+	err := Validate(&testpb.Item{})
+	require.Error(t, err)
+	assert.Equal(t, `invalid request: sku: must be at least 1 characters long; `+
+		`sku: must match pattern "^[A-Z0-9-]+$"; quantity: must be greater than 0`, err.Error())
+}